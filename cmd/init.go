@@ -2,11 +2,20 @@ package main
 
 import (
 	"ToDo/configs"
+	"ToDo/internal/attachments"
 	"ToDo/internal/auth"
+	"ToDo/internal/comments"
 	"ToDo/internal/models"
 	"ToDo/internal/notes"
+	"ToDo/internal/projects"
+	"ToDo/internal/tags"
+	"ToDo/internal/templates"
 	"ToDo/internal/user"
+	"ToDo/internal/workflows"
+	"ToDo/pkg/blobstore"
 	"ToDo/pkg/db"
+	"ToDo/pkg/di"
+	"ToDo/pkg/mailer"
 	"ToDo/pkg/middleware"
 	"database/sql"
 	"log/slog"
@@ -23,7 +32,8 @@ type App struct {
 	SQLDB   *sql.DB
 	GormDB  *gorm.DB
 	Config  *configs.Config
-	Cleanup func() // Функция для закрытия ресурсов
+	Tasks   []BackgroundTask // Фоновые задачи, запускаемые вместе с сервером
+	Cleanup func()           // Функция для закрытия ресурсов
 }
 
 // InitializeApp инициализирует приложение и возвращает структуру App
@@ -52,8 +62,22 @@ func InitializeApp() (*App, error) {
 		return nil, err
 	}
 
+	// Хранилище вложений
+	blobStore, err := blobstore.New(cfg)
+	if err != nil {
+		sqlDB.Close()
+		return nil, err
+	}
+
+	// Отправка писем
+	mailSender, err := mailer.New(cfg)
+	if err != nil {
+		sqlDB.Close()
+		return nil, err
+	}
+
 	// Инициализируем зависимости и маршрутизатор
-	router := setupRouter(gormDB, cfg)
+	router, tasks := setupRouter(gormDB, blobStore, mailSender, cfg)
 
 	// Функция для очистки (закрытие базы данных)
 	cleanup := func() {
@@ -67,6 +91,7 @@ func InitializeApp() (*App, error) {
 		SQLDB:   sqlDB,
 		GormDB:  gormDB,
 		Config:  cfg,
+		Tasks:   tasks,
 		Cleanup: cleanup,
 	}, nil
 }
@@ -74,30 +99,128 @@ func InitializeApp() (*App, error) {
 // runMigrations выполняет миграции базы данных
 func runMigrations(db *gorm.DB) error {
 	db.Logger = db.Logger.LogMode(logger.Info)
-	return db.AutoMigrate(&models.User{}, &models.Note{})
+	// Маркеры статусов появились позже самих workflow: существующим статусам done и in_progress их нужно выставить
+	backfillMarkers := db.Migrator().HasTable(&models.WorkflowStatus{}) && !db.Migrator().HasColumn(&models.WorkflowStatus{}, "Terminal")
+	if err := db.AutoMigrate(&models.User{}, &models.Note{}, &models.Tag{}, &models.ChecklistItem{}, &models.Project{}, &models.NoteRevision{}, &models.Workflow{}, &models.WorkflowStatus{}, &models.NoteShare{}, &models.Comment{}, &models.CommentMention{}, &models.Attachment{}, &models.NoteLink{}, &models.Dependency{}, &models.Template{}, &models.RefreshToken{}, &models.RevokedToken{}, &models.PasswordResetToken{}, &models.EmailVerificationToken{}); err != nil {
+		return err
+	}
+
+	// Полнотекстовый поиск: генерируемый tsvector (заголовок весомее содержимого) и GIN-индекс
+	if err := db.Exec(`ALTER TABLE notes ADD COLUMN IF NOT EXISTS search_vector tsvector
+		GENERATED ALWAYS AS (
+			setweight(to_tsvector('simple', coalesce(title, '')), 'A') ||
+			setweight(to_tsvector('simple', coalesce(content, '')), 'B')
+		) STORED`).Error; err != nil {
+		return err
+	}
+	if err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_notes_search_vector ON notes USING GIN (search_vector)`).Error; err != nil {
+		return err
+	}
+
+	if backfillMarkers {
+		if err := db.Exec(`UPDATE workflow_statuses SET terminal = true WHERE name = ?`, workflows.StatusDone).Error; err != nil {
+			return err
+		}
+		if err := db.Exec(`UPDATE workflow_statuses SET in_progress = true WHERE name = ?`, workflows.StatusInProgress).Error; err != nil {
+			return err
+		}
+	}
+
+	// Заметкам без позиции на доске выдаются ключи по порядку создания внутри колонки
+	return db.Exec(`UPDATE notes SET position = 'a' || lpad(to_hex(ordered.rn), 8, '0') || 'V'
+		FROM (
+			SELECT id, row_number() OVER (PARTITION BY user_id, status ORDER BY created_at, id) AS rn
+			FROM notes WHERE position = ''
+		) AS ordered
+		WHERE notes.id = ordered.id`).Error
 }
 
-// setupRouter инициализирует маршрутизатор с зависимостями
-func setupRouter(gormDB *gorm.DB, cfg *configs.Config) http.Handler {
+// setupRouter инициализирует маршрутизатор с зависимостями и фоновые задачи
+func setupRouter(gormDB *gorm.DB, blobStore di.IBlobStore, mailSender di.IMailer, cfg *configs.Config) (http.Handler, []BackgroundTask) {
 	router := http.NewServeMux()
 
 	userRepo := user.NewUserRepository(gormDB)
+	refreshTokenRepo := auth.NewRefreshTokenRepository(gormDB)
+	resetTokenRepo := auth.NewResetTokenRepository(gormDB)
+	verifyTokenRepo := auth.NewVerifyTokenRepository(gormDB)
+	tokenDenylist := auth.NewTokenDenylist(gormDB)
 	noteRepo := notes.NewNoteRepository(gormDB)
-	authSvc := auth.NewUserService(userRepo)
-	noteSvc := notes.NewNoteService(noteRepo)
+	tagRepo := tags.NewTagRepository(gormDB)
+	projectRepo := projects.NewProjectRepository(gormDB)
+	workflowRepo := workflows.NewWorkflowRepository(gormDB)
+	commentRepo := comments.NewCommentRepository(gormDB)
+	attachmentRepo := attachments.NewAttachmentRepository(gormDB)
+	templateRepo := templates.NewTemplateRepository(gormDB)
+	authSvc := auth.NewUserService(userRepo, refreshTokenRepo, resetTokenRepo, verifyTokenRepo, tokenDenylist, mailSender, cfg)
+	userSvc := user.NewUserService(userRepo, authSvc)
+	workflowSvc := workflows.NewWorkflowService(workflowRepo)
+	noteSvc := notes.NewNoteService(noteRepo, userRepo, workflowSvc, cfg)
+	commentSvc := comments.NewCommentService(commentRepo, userRepo, noteSvc)
+	attachmentSvc := attachments.NewAttachmentService(attachmentRepo, blobStore, cfg)
+	templateSvc := templates.NewTemplateService(templateRepo, noteSvc)
+	tagSvc := tags.NewTagService(tagRepo)
+	projectSvc := projects.NewProjectService(projectRepo)
 
 	notes.NewNoteHandler(router, &notes.NoteHandlerDeps{
-		NoteService: noteSvc,
-		Config:      cfg,
+		NoteService:    noteSvc,
+		ProjectService: projectSvc,
+		Config:         cfg,
+		TokenDenylist:  tokenDenylist,
+	})
+	comments.NewCommentHandler(router, &comments.CommentHandlerDeps{
+		CommentService: commentSvc,
+		NoteService:    noteSvc,
+		Config:         cfg,
+		TokenDenylist:  tokenDenylist,
+	})
+	attachments.NewAttachmentHandler(router, &attachments.AttachmentHandlerDeps{
+		AttachmentService: attachmentSvc,
+		NoteService:       noteSvc,
+		Config:            cfg,
+		TokenDenylist:     tokenDenylist,
+	})
+	templates.NewTemplateHandler(router, &templates.TemplateHandlerDeps{
+		TemplateService: templateSvc,
+		ProjectService:  projectSvc,
+		Config:          cfg,
+		TokenDenylist:   tokenDenylist,
+	})
+	projects.NewProjectHandler(router, &projects.ProjectHandlerDeps{
+		ProjectService: projectSvc,
+		Config:         cfg,
+		TokenDenylist:  tokenDenylist,
+	})
+	workflows.NewWorkflowHandler(router, &workflows.WorkflowHandlerDeps{
+		WorkflowService: workflowSvc,
+		ProjectService:  projectSvc,
+		Config:          cfg,
+		TokenDenylist:   tokenDenylist,
+	})
+	tags.NewTagHandler(router, &tags.TagHandlerDeps{
+		TagService:    tagSvc,
+		Config:        cfg,
+		TokenDenylist: tokenDenylist,
 	})
 	auth.NewAuthHandler(router, &auth.AuthHandlerDeps{
-		AuthService: authSvc,
-		Config:      cfg,
+		AuthService:   authSvc,
+		Config:        cfg,
+		TokenDenylist: tokenDenylist,
 	})
+	user.NewUserHandler(router, &user.UserHandlerDeps{
+		UserService:   userSvc,
+		Config:        cfg,
+		TokenDenylist: tokenDenylist,
+	})
+
+	tasks := []BackgroundTask{
+		trashPurger(noteSvc, cfg.Notes.TrashPurgeInterval),
+		attachmentSweeper(attachmentSvc, cfg.Attachments.SweepInterval),
+		refreshTokenPurger(authSvc, cfg.Auth.TokenPurgeInterval),
+	}
 
 	return middleware.Chain(
 		middleware.CORS,
 		middleware.Logging,
 		middleware.RateLimiter(cfg.RateLimit.MaxRequests, cfg.RateLimit.Burst, cfg.RateLimit.TTL),
-	)(router)
+	)(router), tasks
 }
@@ -9,6 +9,7 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata" // Встроенная база часовых поясов для параметра tz
 )
 
 const (
@@ -32,6 +33,13 @@ func main() {
 		WriteTimeout: app.Config.Server.WriteTimeout,
 	}
 
+	// Запуск фоновых задач; они останавливаются вместе с сервером
+	tasksCtx, stopTasks := context.WithCancel(context.Background())
+	defer stopTasks()
+	for _, task := range app.Tasks {
+		go task(tasksCtx)
+	}
+
 	// Запуск сервера в горутине
 	go startServer(server)
 
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"ToDo/internal/attachments"
+	"ToDo/internal/auth"
+	"ToDo/internal/notes"
+	"context"
+	"log/slog"
+	"time"
+)
+
+// BackgroundTask — фоновая задача, работающая до отмены контекста
+type BackgroundTask func(ctx context.Context)
+
+// periodic запускает fn сразу и затем раз в interval, пока не отменен контекст. Число обработанных записей
+// fn пишет в лог сама, здесь логируются только ошибки с именем задачи name
+func periodic(name string, interval time.Duration, fn func(ctx context.Context) (int64, error)) BackgroundTask {
+	return func(ctx context.Context) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := fn(ctx); err != nil && ctx.Err() == nil {
+				slog.Error("Background task failed", "task", name, "error", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}
+}
+
+// trashPurger периодически стирает заметки, пролежавшие в корзине дольше срока хранения
+func trashPurger(noteSvc *notes.NoteService, interval time.Duration) BackgroundTask {
+	return periodic("purge trash", interval, func(ctx context.Context) (int64, error) {
+		return noteSvc.PurgeTrash(ctx, time.Now())
+	})
+}
+
+// attachmentSweeper периодически удаляет из хранилища вложения заметок, стертых окончательно
+func attachmentSweeper(attachmentSvc *attachments.AttachmentService, interval time.Duration) BackgroundTask {
+	return periodic("sweep orphaned attachments", interval, func(ctx context.Context) (int64, error) {
+		swept, err := attachmentSvc.SweepOrphans(ctx)
+		return int64(swept), err
+	})
+}
+
+// refreshTokenPurger периодически стирает истекшие токены обновления
+func refreshTokenPurger(authSvc *auth.AuthService, interval time.Duration) BackgroundTask {
+	return periodic("purge expired refresh tokens", interval, func(ctx context.Context) (int64, error) {
+		return authSvc.PurgeExpiredTokens(ctx, time.Now())
+	})
+}
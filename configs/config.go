@@ -12,14 +12,53 @@ type Config struct {
 		Dsn string `mapstructure:"DSN"`
 	} `mapstructure:"DB"`
 	Auth struct {
-		Secret        string        `mapstructure:"SECRET"`
-		TokenLifetime time.Duration `mapstructure:"TOKEN_LIFETIME"`
+		Secret               string        `mapstructure:"SECRET"`
+		TokenLifetime        time.Duration `mapstructure:"TOKEN_LIFETIME"`         // Время жизни токена доступа
+		RefreshTokenLifetime time.Duration `mapstructure:"REFRESH_TOKEN_LIFETIME"` // Время жизни токена обновления
+		ResetTokenLifetime   time.Duration `mapstructure:"RESET_TOKEN_LIFETIME"`   // Сколько действует ссылка сброса пароля
+		VerifyTokenLifetime  time.Duration `mapstructure:"VERIFY_TOKEN_LIFETIME"`  // Сколько действует ссылка подтверждения email
+		UnverifiedEmail      string        `mapstructure:"UNVERIFIED_EMAIL"`       // flag — только помечать, block — не пускать к заметкам
+		TokenPurgeInterval   time.Duration `mapstructure:"TOKEN_PURGE_INTERVAL"`   // Как часто удалять истекшие токены обновления
 	} `mapstructure:"AUTH"`
 	Server struct {
 		Port         int           `mapstructure:"PORT"`
 		ReadTimeout  time.Duration `mapstructure:"READ_TIMEOUT"`
 		WriteTimeout time.Duration `mapstructure:"WRITE_TIMEOUT"`
 	} `mapstructure:"SERVER"`
+	Notes struct {
+		ChecklistAutoDone  bool          `mapstructure:"CHECKLIST_AUTO_DONE"`  // Переводить заметку в done, когда отмечены все пункты
+		TrashRetention     time.Duration `mapstructure:"TRASH_RETENTION"`      // Сколько хранить заметки в корзине
+		TrashPurgeInterval time.Duration `mapstructure:"TRASH_PURGE_INTERVAL"` // Как часто очищать корзину
+		MaxRevisions       int           `mapstructure:"MAX_REVISIONS"`        // Сколько ревизий хранить на заметку
+		RequireIfMatch     bool          `mapstructure:"REQUIRE_IF_MATCH"`     // Отклонять PATCH/DELETE заметки без заголовка If-Match
+	} `mapstructure:"NOTES"`
+	Attachments struct {
+		Storage       string        `mapstructure:"STORAGE"`        // local или s3
+		Dir           string        `mapstructure:"DIR"`            // Каталог для локального хранилища
+		MaxFileSize   int64         `mapstructure:"MAX_FILE_SIZE"`  // Максимальный размер файла в байтах
+		UserQuota     int64         `mapstructure:"USER_QUOTA"`     // Сколько байт вложений может загрузить пользователь
+		AllowedTypes  []string      `mapstructure:"ALLOWED_TYPES"`  // Допустимые MIME-типы (определяются по содержимому)
+		SweepInterval time.Duration `mapstructure:"SWEEP_INTERVAL"` // Как часто удалять файлы вложений окончательно удаленных заметок
+		S3            struct {
+			Endpoint  string `mapstructure:"ENDPOINT"`
+			Region    string `mapstructure:"REGION"`
+			Bucket    string `mapstructure:"BUCKET"`
+			AccessKey string `mapstructure:"ACCESS_KEY"`
+			SecretKey string `mapstructure:"SECRET_KEY"`
+		} `mapstructure:"S3"`
+	} `mapstructure:"ATTACHMENTS"`
+	Mail struct {
+		Transport string `mapstructure:"TRANSPORT"` // smtp, file или log
+		From      string `mapstructure:"FROM"`      // Адрес отправителя
+		Dir       string `mapstructure:"DIR"`       // Каталог для транспорта file
+		AppURL    string `mapstructure:"APP_URL"`   // Адрес клиента, на который ведут ссылки из писем
+		SMTP      struct {
+			Host     string `mapstructure:"HOST"`
+			Port     int    `mapstructure:"PORT"`
+			Username string `mapstructure:"USERNAME"`
+			Password string `mapstructure:"PASSWORD"`
+		} `mapstructure:"SMTP"`
+	} `mapstructure:"MAIL"`
 	RateLimit struct {
 		MaxRequests float64       `mapstructure:"MAX_REQUESTS"`
 		Burst       int           `mapstructure:"BURST"`
@@ -56,7 +95,59 @@ func LoadConfig() (*Config, error) {
 		return nil, fmt.Errorf("server port is required")
 	}
 	if config.Auth.TokenLifetime == 0 { // Добавляем валидацию TokenLifetime
-		config.Auth.TokenLifetime = time.Hour * 24 // Значение по умолчанию
+		config.Auth.TokenLifetime = time.Minute * 15 // Значение по умолчанию
+	}
+	if config.Auth.RefreshTokenLifetime == 0 {
+		config.Auth.RefreshTokenLifetime = time.Hour * 24 * 30
+	}
+	if config.Auth.ResetTokenLifetime == 0 {
+		config.Auth.ResetTokenLifetime = time.Hour
+	}
+	if config.Auth.VerifyTokenLifetime == 0 {
+		config.Auth.VerifyTokenLifetime = time.Hour * 48
+	}
+	if config.Auth.TokenPurgeInterval == 0 {
+		config.Auth.TokenPurgeInterval = time.Hour
+	}
+	switch config.Auth.UnverifiedEmail {
+	case "":
+		config.Auth.UnverifiedEmail = "flag"
+	case "flag", "block":
+	default:
+		return nil, fmt.Errorf("unknown unverified email policy %q", config.Auth.UnverifiedEmail)
+	}
+	if config.Notes.TrashRetention == 0 {
+		config.Notes.TrashRetention = time.Hour * 24 * 30
+	}
+	if config.Notes.TrashPurgeInterval == 0 {
+		config.Notes.TrashPurgeInterval = time.Hour
+	}
+	if config.Notes.MaxRevisions <= 0 {
+		config.Notes.MaxRevisions = 50
+	}
+	if config.Attachments.Dir == "" {
+		config.Attachments.Dir = "data/attachments"
+	}
+	if config.Attachments.MaxFileSize <= 0 {
+		config.Attachments.MaxFileSize = 10 << 20 // 10 МБ
+	}
+	if config.Attachments.UserQuota <= 0 {
+		config.Attachments.UserQuota = 100 << 20 // 100 МБ
+	}
+	if config.Attachments.SweepInterval == 0 {
+		config.Attachments.SweepInterval = time.Hour
+	}
+	if len(config.Attachments.AllowedTypes) == 0 {
+		config.Attachments.AllowedTypes = []string{"image/png", "image/jpeg", "image/gif", "image/webp", "application/pdf", "text/plain"}
+	}
+	if config.Mail.From == "" {
+		config.Mail.From = "todo@localhost"
+	}
+	if config.Mail.Dir == "" {
+		config.Mail.Dir = "data/mail"
+	}
+	if config.Mail.AppURL == "" {
+		config.Mail.AppURL = "http://localhost:8080"
 	}
 
 	return &config, nil
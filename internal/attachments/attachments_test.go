@@ -0,0 +1,143 @@
+package attachments
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"ToDo/configs"
+	"ToDo/internal/models"
+	"ToDo/pkg/blobstore"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockAttachmentRepository — мок для IAttachmentRepository
+type MockAttachmentRepository struct {
+	mock.Mock
+}
+
+func (m *MockAttachmentRepository) Create(ctx context.Context, attachment *models.Attachment, quota int64) (*models.Attachment, error) {
+	args := m.Called(ctx, attachment, quota)
+	result, _ := args.Get(0).(*models.Attachment)
+	return result, args.Error(1)
+}
+
+func (m *MockAttachmentRepository) Get(ctx context.Context, attachmentID string) (*models.Attachment, error) {
+	args := m.Called(ctx, attachmentID)
+	result, _ := args.Get(0).(*models.Attachment)
+	return result, args.Error(1)
+}
+
+func (m *MockAttachmentRepository) GetByNote(ctx context.Context, noteID string) ([]models.Attachment, error) {
+	args := m.Called(ctx, noteID)
+	result, _ := args.Get(0).([]models.Attachment)
+	return result, args.Error(1)
+}
+
+func (m *MockAttachmentRepository) Delete(ctx context.Context, attachmentID string) error {
+	args := m.Called(ctx, attachmentID)
+	return args.Error(0)
+}
+
+func (m *MockAttachmentRepository) GetUsage(ctx context.Context, userID string) (int64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockAttachmentRepository) GetOrphans(ctx context.Context, limit int) ([]models.Attachment, error) {
+	args := m.Called(ctx, limit)
+	result, _ := args.Get(0).([]models.Attachment)
+	return result, args.Error(1)
+}
+
+// pngHeader — сигнатура PNG, по которой mimetype определяет тип
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func newTestConfig() *configs.Config {
+	cfg := &configs.Config{}
+	cfg.Attachments.MaxFileSize = 1024
+	cfg.Attachments.UserQuota = 2048
+	cfg.Attachments.AllowedTypes = []string{"image/png", "application/pdf"}
+	return cfg
+}
+
+func TestAttachmentService_UploadAttachment(t *testing.T) {
+	note := &models.Note{ID: "note123", UserID: "user123"}
+	png := append(append([]byte{}, pngHeader...), bytes.Repeat([]byte{0}, 100)...)
+
+	tests := []struct {
+		name    string
+		content []byte
+		size    int64
+		used    int64
+		wantErr error
+	}{
+		{name: "PNG is stored", content: png, size: int64(len(png)), used: 100},
+		{name: "Empty file", content: nil, size: 0, wantErr: ErrEmptyFile},
+		{name: "File over size limit", content: png, size: 2000, wantErr: ErrFileTooLarge},
+		{name: "Executable renamed to png", content: []byte("MZ\x90\x00\x03\x00\x00\x00"), size: 8, wantErr: ErrUnsupportedType},
+		{name: "Quota exceeded", content: png, size: int64(len(png)), used: 2000, wantErr: ErrQuotaExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := blobstore.NewLocalStore(t.TempDir())
+			assert.NoError(t, err, "create store")
+			mockRepo := new(MockAttachmentRepository)
+			mockRepo.On("GetUsage", mock.Anything, "user123").Return(tt.used, nil)
+			mockRepo.On("Create", mock.Anything, mock.Anything, int64(2048)).Return(&models.Attachment{ID: "att1"}, nil)
+			service := NewAttachmentService(mockRepo, store, newTestConfig())
+
+			attachment := &models.Attachment{UserID: "user123", FileName: "../../shot.png", Size: tt.size}
+			result, err := service.UploadAttachment(context.Background(), note, attachment, bytes.NewReader(tt.content))
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr, "error mismatch")
+				mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+			assert.NoError(t, err, "unexpected error")
+			assert.Equal(t, "att1", result.ID, "attachment mismatch")
+			assert.Equal(t, "image/png", attachment.ContentType, "content type should be sniffed")
+			assert.Equal(t, "shot.png", attachment.FileName, "path should be stripped from the file name")
+			assert.True(t, strings.HasPrefix(attachment.StorageKey, "notes/note123/"), "storage key mismatch")
+
+			stored, err := store.Open(context.Background(), attachment.StorageKey)
+			assert.NoError(t, err, "blob should be stored")
+			data, _ := io.ReadAll(stored)
+			stored.Close()
+			assert.Equal(t, png, data, "stored content mismatch")
+		})
+	}
+}
+
+// TestAttachmentService_UploadAttachment_QuotaRace — квоту исчерпала параллельная загрузка: файл удаляется из хранилища
+func TestAttachmentService_UploadAttachment_QuotaRace(t *testing.T) {
+	note := &models.Note{ID: "note123", UserID: "user123"}
+	png := append(append([]byte{}, pngHeader...), bytes.Repeat([]byte{0}, 100)...)
+
+	store, err := blobstore.NewLocalStore(t.TempDir())
+	assert.NoError(t, err, "create store")
+	mockRepo := new(MockAttachmentRepository)
+	mockRepo.On("GetUsage", mock.Anything, "user123").Return(int64(100), nil)
+	mockRepo.On("Create", mock.Anything, mock.Anything, int64(2048)).Return(nil, ErrQuotaExceeded)
+	service := NewAttachmentService(mockRepo, store, newTestConfig())
+
+	attachment := &models.Attachment{UserID: "user123", FileName: "shot.png", Size: int64(len(png))}
+	_, err = service.UploadAttachment(context.Background(), note, attachment, bytes.NewReader(png))
+	assert.ErrorIs(t, err, ErrQuotaExceeded, "error mismatch")
+
+	_, err = store.Open(context.Background(), attachment.StorageKey)
+	assert.Error(t, err, "blob of rejected attachment should be deleted")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCleanFileName(t *testing.T) {
+	assert.Equal(t, "report.pdf", cleanFileName(`C:\Users\me\report.pdf`), "windows path")
+	assert.Equal(t, "file", cleanFileName("  "), "empty name")
+	assert.Equal(t, "file", cleanFileName("/"), "root")
+	assert.Len(t, cleanFileName(strings.Repeat("я", 200)), 254, "long name is cut on a rune boundary")
+}
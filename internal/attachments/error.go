@@ -0,0 +1,12 @@
+package attachments
+
+import "errors"
+
+var (
+	ErrAttachmentNotFound = errors.New("attachment not found")
+	ErrCreateAttachment   = errors.New("failed to create attachment")
+	ErrEmptyFile          = errors.New("file is empty")
+	ErrFileTooLarge       = errors.New("file is too large")
+	ErrUnsupportedType    = errors.New("file type is not allowed")
+	ErrQuotaExceeded      = errors.New("storage quota exceeded")
+)
@@ -0,0 +1,41 @@
+package attachments
+
+import (
+	"ToDo/configs"
+	"ToDo/pkg/di"
+	"ToDo/pkg/middleware"
+	"net/http"
+)
+
+type AttachmentHandlerDeps struct {
+	Config            *configs.Config
+	TokenDenylist     di.ITokenDenylist
+	AttachmentService di.IAttachmentService
+	NoteService       di.INoteService
+}
+
+type AttachmentHandler struct {
+	Config            *configs.Config
+	AttachmentService di.IAttachmentService
+	NoteService       di.INoteService
+}
+
+func NewAttachmentHandler(router *http.ServeMux, deps *AttachmentHandlerDeps) {
+	handler := &AttachmentHandler{
+		Config:            deps.Config,
+		AttachmentService: deps.AttachmentService,
+		NoteService:       deps.NoteService,
+	}
+	middlewares := middleware.Chain(
+		middleware.CORS,
+		middleware.Logging,
+		middleware.RateLimiter(deps.Config.RateLimit.MaxRequests, deps.Config.RateLimit.Burst, deps.Config.RateLimit.TTL),
+		middleware.IsAuthenticated(deps.Config, deps.TokenDenylist),
+		middleware.RequireVerifiedEmail(deps.Config),
+	)
+
+	router.Handle("GET /notes/{id}/attachments", middlewares(handler.GetAttachments()))
+	router.Handle("POST /notes/{id}/attachments", middlewares(handler.UploadAttachment()))
+	router.Handle("GET /notes/{id}/attachments/{attachmentId}", middlewares(handler.DownloadAttachment()))
+	router.Handle("DELETE /notes/{id}/attachments/{attachmentId}", middlewares(handler.DeleteAttachment()))
+}
@@ -0,0 +1,14 @@
+package attachments
+
+import "ToDo/internal/models"
+
+type GetAttachmentsResponse struct {
+	Attachments []models.Attachment `json:"attachments"`
+}
+
+// UploadAttachmentResponse — загруженное вложение и занятое место в квоте пользователя
+type UploadAttachmentResponse struct {
+	Attachment *models.Attachment `json:"attachment"`
+	Used       int64              `json:"used"`
+	Quota      int64              `json:"quota"`
+}
@@ -0,0 +1,102 @@
+package attachments
+
+import (
+	"ToDo/internal/models"
+	"ToDo/pkg/idgen"
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+)
+
+type AttachmentRepository struct {
+	db *gorm.DB
+}
+
+func NewAttachmentRepository(dataBase *gorm.DB) *AttachmentRepository {
+	return &AttachmentRepository{
+		db: dataBase,
+	}
+}
+
+// Create сохраняет вложение, если вместе с ним вложения пользователя укладываются в quota.
+// Подсчет и вставка идут под advisory-блокировкой пользователя, чтобы параллельные загрузки не превысили квоту
+func (r *AttachmentRepository) Create(ctx context.Context, attachment *models.Attachment, quota int64) (*models.Attachment, error) {
+	attachment.ID = idgen.GenerateNanoID()
+	if attachment.ID == "" {
+		return nil, fmt.Errorf("generate id: %w", ErrCreateAttachment)
+	}
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "attachments:"+attachment.UserID).Error; err != nil {
+			return err
+		}
+		var used int64
+		err := tx.Model(&models.Attachment{}).Where("user_id = ?", attachment.UserID).
+			Select("COALESCE(SUM(size), 0)").Scan(&used).Error
+		if err != nil {
+			return err
+		}
+		if used+attachment.Size > quota {
+			return ErrQuotaExceeded
+		}
+		return tx.Create(attachment).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create attachment on note %s: %w", attachment.NoteID, err)
+	}
+	return attachment, nil
+}
+
+func (r *AttachmentRepository) Get(ctx context.Context, attachmentId string) (*models.Attachment, error) {
+	var attachment models.Attachment
+	result := r.db.WithContext(ctx).First(&attachment, "id = ?", attachmentId)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("get attachment %s: %w", attachmentId, ErrAttachmentNotFound)
+		}
+		return nil, fmt.Errorf("get attachment %s: %w", attachmentId, result.Error)
+	}
+	return &attachment, nil
+}
+
+func (r *AttachmentRepository) GetByNote(ctx context.Context, noteId string) ([]models.Attachment, error) {
+	var attachments []models.Attachment
+	if err := r.db.WithContext(ctx).Where("note_id = ?", noteId).Order("created_at asc").Find(&attachments).Error; err != nil {
+		return nil, fmt.Errorf("get attachments of note %s: %w", noteId, err)
+	}
+	return attachments, nil
+}
+
+func (r *AttachmentRepository) Delete(ctx context.Context, attachmentId string) error {
+	result := r.db.WithContext(ctx).Delete(&models.Attachment{}, "id = ?", attachmentId)
+	if result.Error != nil {
+		return fmt.Errorf("delete attachment %s: %w", attachmentId, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("delete attachment %s: %w", attachmentId, ErrAttachmentNotFound)
+	}
+	return nil
+}
+
+// GetUsage возвращает суммарный размер вложений, загруженных пользователем
+func (r *AttachmentRepository) GetUsage(ctx context.Context, userId string) (int64, error) {
+	var used int64
+	result := r.db.WithContext(ctx).Model(&models.Attachment{}).Where("user_id = ?", userId).
+		Select("COALESCE(SUM(size), 0)").Scan(&used)
+	if result.Error != nil {
+		return 0, fmt.Errorf("get storage usage of user %s: %w", userId, result.Error)
+	}
+	return used, nil
+}
+
+// GetOrphans возвращает вложения, заметки которых удалены окончательно
+func (r *AttachmentRepository) GetOrphans(ctx context.Context, limit int) ([]models.Attachment, error) {
+	var attachments []models.Attachment
+	result := r.db.WithContext(ctx).
+		Where("NOT EXISTS (SELECT 1 FROM notes WHERE notes.id = attachments.note_id)").
+		Limit(limit).Find(&attachments)
+	if result.Error != nil {
+		return nil, fmt.Errorf("get orphaned attachments: %w", result.Error)
+	}
+	return attachments, nil
+}
@@ -0,0 +1,209 @@
+package attachments
+
+import (
+	"ToDo/internal/models"
+	"ToDo/internal/notes"
+	"ToDo/pkg/blobstore"
+	"ToDo/pkg/middleware"
+	"ToDo/pkg/res"
+	"errors"
+	"mime"
+	"net/http"
+)
+
+const (
+	multipartMemory   = 1 << 20 // Части формы больше этого размера сохраняются во временные файлы
+	multipartOverhead = 1 << 20 // Запас на заголовки и прочие поля формы сверх MAX_FILE_SIZE
+)
+
+func getUserId(r *http.Request) string {
+	userId, _ := r.Context().Value(middleware.ContextUserIDKey).(string)
+	return userId
+}
+
+// loadNote загружает заметку из пути запроса и проверяет, что у пользователя есть доступ не ниже required.
+// При ошибке ответ уже записан и возвращается nil
+func (h *AttachmentHandler) loadNote(w http.ResponseWriter, r *http.Request, required string) *models.Note {
+	userId := getUserId(r)
+	if userId == "" {
+		res.JsonResponse(w, res.ErrorResponse{Error: "unauthorized"}, http.StatusUnauthorized)
+		return nil
+	}
+
+	note, err := h.NoteService.GetNote(r.Context(), r.PathValue("id"))
+	if err != nil {
+		if errors.Is(err, notes.ErrNoteNotFound) {
+			res.JsonResponse(w, res.ErrorResponse{Error: "note not found"}, http.StatusNotFound)
+		} else {
+			res.JsonResponse(w, res.ErrorResponse{Error: "failed to get note by id"}, http.StatusInternalServerError)
+		}
+		return nil
+	}
+	permission, err := h.NoteService.GetPermission(r.Context(), note, userId)
+	if err != nil {
+		res.JsonResponse(w, res.ErrorResponse{Error: "failed to get note by id"}, http.StatusInternalServerError)
+		return nil
+	}
+	if permission == "" {
+		res.JsonResponse(w, res.ErrorResponse{Error: "unauthorized"}, http.StatusUnauthorized)
+		return nil
+	}
+	if !models.PermissionAllows(permission, required) {
+		res.JsonResponse(w, res.ErrorResponse{Error: notes.ErrPermissionDenied.Error()}, http.StatusForbidden)
+		return nil
+	}
+	return note
+}
+
+// loadAttachment загружает вложение из пути запроса и проверяет, что оно прикреплено к note
+func (h *AttachmentHandler) loadAttachment(w http.ResponseWriter, r *http.Request, note *models.Note) *models.Attachment {
+	attachment, err := h.AttachmentService.GetAttachment(r.Context(), r.PathValue("attachmentId"))
+	if err != nil {
+		if errors.Is(err, ErrAttachmentNotFound) {
+			res.JsonResponse(w, res.ErrorResponse{Error: "attachment not found"}, http.StatusNotFound)
+		} else {
+			res.JsonResponse(w, res.ErrorResponse{Error: "failed to get attachment"}, http.StatusInternalServerError)
+		}
+		return nil
+	}
+	if attachment.NoteID != note.ID {
+		res.JsonResponse(w, res.ErrorResponse{Error: "attachment not found"}, http.StatusNotFound)
+		return nil
+	}
+	return attachment
+}
+
+func (h *AttachmentHandler) GetAttachments() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		note := h.loadNote(w, r, models.PermissionViewer)
+		if note == nil {
+			return
+		}
+
+		attachments, err := h.AttachmentService.GetAttachments(r.Context(), note.ID)
+		if err != nil {
+			res.JsonResponse(w, res.ErrorResponse{Error: "failed to get attachments"}, http.StatusInternalServerError)
+			return
+		}
+		res.JsonResponse(w, GetAttachmentsResponse{Attachments: attachments}, http.StatusOK)
+	}
+}
+
+// UploadAttachment принимает файл в поле file формы multipart/form-data
+func (h *AttachmentHandler) UploadAttachment() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		note := h.loadNote(w, r, models.PermissionEditor)
+		if note == nil {
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, h.Config.Attachments.MaxFileSize+multipartOverhead)
+		if err := r.ParseMultipartForm(multipartMemory); err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				res.JsonResponse(w, res.ErrorResponse{Error: ErrFileTooLarge.Error()}, http.StatusRequestEntityTooLarge)
+			} else {
+				res.JsonResponse(w, res.ErrorResponse{Error: "invalid multipart form"}, http.StatusBadRequest)
+			}
+			return
+		}
+		defer r.MultipartForm.RemoveAll()
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			res.JsonResponse(w, res.ErrorResponse{Error: "file is required"}, http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+
+		userId := getUserId(r)
+		attachment, err := h.AttachmentService.UploadAttachment(r.Context(), note, &models.Attachment{
+			UserID:   userId,
+			FileName: header.Filename,
+			Size:     header.Size,
+		}, file)
+		if err != nil {
+			switch {
+			case errors.Is(err, ErrEmptyFile):
+				res.JsonResponse(w, res.ErrorResponse{Error: ErrEmptyFile.Error()}, http.StatusBadRequest)
+			case errors.Is(err, ErrFileTooLarge):
+				res.JsonResponse(w, res.ErrorResponse{Error: ErrFileTooLarge.Error()}, http.StatusRequestEntityTooLarge)
+			case errors.Is(err, ErrUnsupportedType):
+				res.JsonResponse(w, res.ErrorResponse{Error: err.Error()}, http.StatusUnsupportedMediaType)
+			case errors.Is(err, ErrQuotaExceeded):
+				res.JsonResponse(w, res.ErrorResponse{Error: ErrQuotaExceeded.Error()}, http.StatusInsufficientStorage)
+			default:
+				res.JsonResponse(w, res.ErrorResponse{Error: "failed to upload attachment"}, http.StatusInternalServerError)
+			}
+			return
+		}
+
+		used, err := h.AttachmentService.GetUsage(r.Context(), userId)
+		if err != nil {
+			res.JsonResponse(w, res.ErrorResponse{Error: "failed to get storage usage"}, http.StatusInternalServerError)
+			return
+		}
+		res.JsonResponse(w, UploadAttachmentResponse{
+			Attachment: attachment,
+			Used:       used,
+			Quota:      h.Config.Attachments.UserQuota,
+		}, http.StatusCreated)
+	}
+}
+
+// DownloadAttachment отдает содержимое вложения; заголовки Range и If-Range обрабатывает http.ServeContent
+func (h *AttachmentHandler) DownloadAttachment() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		note := h.loadNote(w, r, models.PermissionViewer)
+		if note == nil {
+			return
+		}
+		attachment := h.loadAttachment(w, r, note)
+		if attachment == nil {
+			return
+		}
+
+		content, err := h.AttachmentService.OpenAttachment(r.Context(), attachment)
+		if err != nil {
+			if errors.Is(err, blobstore.ErrBlobNotFound) {
+				res.JsonResponse(w, res.ErrorResponse{Error: "attachment content not found"}, http.StatusNotFound)
+			} else {
+				res.JsonResponse(w, res.ErrorResponse{Error: "failed to open attachment"}, http.StatusInternalServerError)
+			}
+			return
+		}
+		defer content.Close()
+
+		w.Header().Set("Content-Type", attachment.ContentType)
+		disposition := mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName})
+		if disposition == "" {
+			disposition = "attachment"
+		}
+		w.Header().Set("Content-Disposition", disposition)
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("ETag", `"`+attachment.ID+`"`) // Содержимое вложения не меняется
+		http.ServeContent(w, r, attachment.FileName, attachment.CreatedAt, content)
+	}
+}
+
+func (h *AttachmentHandler) DeleteAttachment() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		note := h.loadNote(w, r, models.PermissionEditor)
+		if note == nil {
+			return
+		}
+		attachment := h.loadAttachment(w, r, note)
+		if attachment == nil {
+			return
+		}
+
+		if err := h.AttachmentService.DeleteAttachment(r.Context(), attachment); err != nil {
+			if errors.Is(err, ErrAttachmentNotFound) {
+				res.JsonResponse(w, res.ErrorResponse{Error: "attachment not found"}, http.StatusNotFound)
+			} else {
+				res.JsonResponse(w, res.ErrorResponse{Error: "failed to delete attachment"}, http.StatusInternalServerError)
+			}
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,161 @@
+package attachments
+
+import (
+	"ToDo/configs"
+	"ToDo/internal/models"
+	"ToDo/pkg/di"
+	"ToDo/pkg/idgen"
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gabriel-vasile/mimetype"
+)
+
+const (
+	sniffLength = 3072 // Сколько байт из начала файла читает mimetype
+	maxFileName = 255
+	orphanBatch = 100 // Сколько осиротевших вложений удаляется за проход
+)
+
+type AttachmentService struct {
+	attachmentRepository di.IAttachmentRepository
+	blobStore            di.IBlobStore
+	config               *configs.Config
+}
+
+func NewAttachmentService(attachmentRepo di.IAttachmentRepository, blobStore di.IBlobStore, config *configs.Config) *AttachmentService {
+	return &AttachmentService{attachmentRepository: attachmentRepo, blobStore: blobStore, config: config}
+}
+
+// cleanFileName оставляет от имени, присланного клиентом, только последний элемент пути разумной длины
+func cleanFileName(name string) string {
+	name = strings.TrimSpace(filepath.Base(strings.ReplaceAll(name, "\\", "/")))
+	if name == "." || name == "/" || name == "" {
+		return "file"
+	}
+	for len(name) > maxFileName {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	return name
+}
+
+// detectType определяет MIME-тип по первым байтам содержимого и проверяет, что он разрешен.
+// Возвращает тип и reader, отдающий содержимое целиком
+func (s *AttachmentService) detectType(content io.Reader) (string, io.Reader, error) {
+	buffered := bufio.NewReaderSize(content, sniffLength)
+	head, err := buffered.Peek(sniffLength)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+		return "", nil, err
+	}
+	detected := mimetype.Detect(head)
+	for _, allowed := range s.config.Attachments.AllowedTypes {
+		if detected.Is(allowed) {
+			return detected.String(), buffered, nil
+		}
+	}
+	return "", nil, fmt.Errorf("%w: %s", ErrUnsupportedType, detected.String())
+}
+
+// UploadAttachment сохраняет файл в хранилище и прикрепляет его к заметке.
+// attachment должен содержать загрузившего пользователя, имя и размер файла
+func (s *AttachmentService) UploadAttachment(ctx context.Context, note *models.Note, attachment *models.Attachment, content io.Reader) (*models.Attachment, error) {
+	if attachment.Size <= 0 {
+		return nil, ErrEmptyFile
+	}
+	if attachment.Size > s.config.Attachments.MaxFileSize {
+		return nil, ErrFileTooLarge
+	}
+	contentType, content, err := s.detectType(content)
+	if err != nil {
+		return nil, err
+	}
+	// Предварительная проверка квоты избавляет от заведомо лишней загрузки файла; окончательная — при сохранении
+	used, err := s.attachmentRepository.GetUsage(ctx, attachment.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if used+attachment.Size > s.config.Attachments.UserQuota {
+		return nil, ErrQuotaExceeded
+	}
+
+	blobId := idgen.GenerateNanoID()
+	if blobId == "" {
+		return nil, fmt.Errorf("generate id: %w", ErrCreateAttachment)
+	}
+	attachment.NoteID = note.ID
+	attachment.FileName = cleanFileName(attachment.FileName)
+	attachment.ContentType = contentType
+	attachment.StorageKey = "notes/" + note.ID + "/" + blobId
+
+	slog.Info("Uploading attachment", "note_id", note.ID, "user_id", attachment.UserID, "size", attachment.Size, "content_type", contentType)
+	if err := s.blobStore.Put(ctx, attachment.StorageKey, content, attachment.Size, contentType); err != nil {
+		return nil, err
+	}
+	created, err := s.attachmentRepository.Create(ctx, attachment, s.config.Attachments.UserQuota)
+	if err != nil {
+		if deleteErr := s.blobStore.Delete(context.WithoutCancel(ctx), attachment.StorageKey); deleteErr != nil {
+			slog.Error("Failed to delete blob of unsaved attachment", "key", attachment.StorageKey, "error", deleteErr)
+		}
+		return nil, err
+	}
+	return created, nil
+}
+
+func (s *AttachmentService) GetAttachments(ctx context.Context, noteID string) ([]models.Attachment, error) {
+	slog.Info("Fetching attachments", "note_id", noteID)
+	return s.attachmentRepository.GetByNote(ctx, noteID)
+}
+
+func (s *AttachmentService) GetAttachment(ctx context.Context, attachmentID string) (*models.Attachment, error) {
+	slog.Info("Fetching attachment", "attachment_id", attachmentID)
+	return s.attachmentRepository.Get(ctx, attachmentID)
+}
+
+func (s *AttachmentService) OpenAttachment(ctx context.Context, attachment *models.Attachment) (io.ReadSeekCloser, error) {
+	return s.blobStore.Open(ctx, attachment.StorageKey)
+}
+
+// DeleteAttachment удаляет содержимое из хранилища, затем запись о вложении
+func (s *AttachmentService) DeleteAttachment(ctx context.Context, attachment *models.Attachment) error {
+	slog.Info("Deleting attachment", "attachment_id", attachment.ID)
+	if err := s.blobStore.Delete(ctx, attachment.StorageKey); err != nil {
+		return err
+	}
+	return s.attachmentRepository.Delete(ctx, attachment.ID)
+}
+
+func (s *AttachmentService) GetUsage(ctx context.Context, userID string) (int64, error) {
+	return s.attachmentRepository.GetUsage(ctx, userID)
+}
+
+// SweepOrphans удаляет вложения заметок, стертых окончательно (из корзины или с permanent=true)
+func (s *AttachmentService) SweepOrphans(ctx context.Context) (int, error) {
+	swept := 0
+	for {
+		orphans, err := s.attachmentRepository.GetOrphans(ctx, orphanBatch)
+		if err != nil {
+			return swept, err
+		}
+		for i := range orphans {
+			if err := s.DeleteAttachment(ctx, &orphans[i]); err != nil && !errors.Is(err, ErrAttachmentNotFound) {
+				return swept, err
+			}
+			swept++
+		}
+		if len(orphans) < orphanBatch {
+			break
+		}
+	}
+	if swept > 0 {
+		slog.Info("Swept orphaned attachments", "count", swept)
+	}
+	return swept, nil
+}
@@ -5,17 +5,24 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"ToDo/configs"
 	"ToDo/internal/user"
+	"ToDo/pkg/di"
+	"ToDo/pkg/middleware"
 	"ToDo/pkg/res"
+	"ToDo/pkg/token"
 
+	"github.com/patrickmn/go-cache"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
 )
 
 // MockAuthService — структура для мокинга IAuthService
@@ -37,6 +44,233 @@ func (m *MockAuthService) Login(ctx context.Context, email, password string) (*m
 	return args.Get(0).(*models.User), args.Error(1) // Возвращаем *user.User и ошибку
 }
 
+func (m *MockAuthService) IssueTokens(ctx context.Context, user *models.User, now time.Time) (*models.TokenPair, error) {
+	args := m.Called(ctx, user, now)
+	result, _ := args.Get(0).(*models.TokenPair)
+	return result, args.Error(1)
+}
+
+func (m *MockAuthService) Refresh(ctx context.Context, refreshToken string, now time.Time) (*models.TokenPair, error) {
+	args := m.Called(ctx, refreshToken, now)
+	result, _ := args.Get(0).(*models.TokenPair)
+	return result, args.Error(1)
+}
+
+func (m *MockAuthService) Logout(ctx context.Context, userID, jti string, expiresAt time.Time, refreshToken string, now time.Time) error {
+	args := m.Called(ctx, userID, jti, expiresAt, refreshToken, now)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) LogoutAll(ctx context.Context, userID string, now time.Time) error {
+	args := m.Called(ctx, userID, now)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) RequestPasswordReset(ctx context.Context, email string, now time.Time) error {
+	args := m.Called(ctx, email, now)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) ResetPassword(ctx context.Context, resetToken, password string, now time.Time) error {
+	args := m.Called(ctx, resetToken, password, now)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) SendVerificationEmail(ctx context.Context, userID string, now time.Time) error {
+	args := m.Called(ctx, userID, now)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) VerifyEmail(ctx context.Context, verifyToken string, now time.Time) error {
+	args := m.Called(ctx, verifyToken, now)
+	return args.Error(0)
+}
+
+// MockVerifyTokenRepository — мок для IVerifyTokenRepository
+type MockVerifyTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockVerifyTokenRepository) Create(ctx context.Context, token *models.EmailVerificationToken) (*models.EmailVerificationToken, error) {
+	args := m.Called(ctx, token)
+	result, _ := args.Get(0).(*models.EmailVerificationToken)
+	return result, args.Error(1)
+}
+
+func (m *MockVerifyTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*models.EmailVerificationToken, error) {
+	args := m.Called(ctx, tokenHash)
+	result, _ := args.Get(0).(*models.EmailVerificationToken)
+	return result, args.Error(1)
+}
+
+func (m *MockVerifyTokenRepository) MarkUsed(ctx context.Context, tokenID string, at time.Time) error {
+	args := m.Called(ctx, tokenID, at)
+	return args.Error(0)
+}
+
+func (m *MockVerifyTokenRepository) InvalidateUser(ctx context.Context, userID string, at time.Time) error {
+	args := m.Called(ctx, userID, at)
+	return args.Error(0)
+}
+
+func (m *MockVerifyTokenRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// MockResetTokenRepository — мок для IResetTokenRepository
+type MockResetTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockResetTokenRepository) Create(ctx context.Context, token *models.PasswordResetToken) (*models.PasswordResetToken, error) {
+	args := m.Called(ctx, token)
+	result, _ := args.Get(0).(*models.PasswordResetToken)
+	return result, args.Error(1)
+}
+
+func (m *MockResetTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*models.PasswordResetToken, error) {
+	args := m.Called(ctx, tokenHash)
+	result, _ := args.Get(0).(*models.PasswordResetToken)
+	return result, args.Error(1)
+}
+
+func (m *MockResetTokenRepository) MarkUsed(ctx context.Context, tokenID string, at time.Time) error {
+	args := m.Called(ctx, tokenID, at)
+	return args.Error(0)
+}
+
+func (m *MockResetTokenRepository) InvalidateUser(ctx context.Context, userID string, at time.Time) error {
+	args := m.Called(ctx, userID, at)
+	return args.Error(0)
+}
+
+func (m *MockResetTokenRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// stubMailer запоминает отправленные письма
+type stubMailer struct {
+	to, subject, body string
+}
+
+func (m *stubMailer) Send(ctx context.Context, to, subject, body string) error {
+	m.to, m.subject, m.body = to, subject, body
+	return nil
+}
+
+// MockRefreshTokenRepository — мок для IRefreshTokenRepository
+type MockRefreshTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockRefreshTokenRepository) Create(ctx context.Context, token *models.RefreshToken) (*models.RefreshToken, error) {
+	args := m.Called(ctx, token)
+	result, _ := args.Get(0).(*models.RefreshToken)
+	return result, args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	args := m.Called(ctx, tokenHash)
+	result, _ := args.Get(0).(*models.RefreshToken)
+	return result, args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) MarkRotated(ctx context.Context, tokenID string, at time.Time) error {
+	args := m.Called(ctx, tokenID, at)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string, at time.Time) error {
+	args := m.Called(ctx, familyID, at)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) RevokeUser(ctx context.Context, userID string, at time.Time) error {
+	args := m.Called(ctx, userID, at)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// MockTokenDenylist — мок для ITokenDenylist
+type MockTokenDenylist struct {
+	mock.Mock
+}
+
+func (m *MockTokenDenylist) Revoke(ctx context.Context, jti, userID string, expiresAt time.Time) error {
+	args := m.Called(ctx, jti, userID, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockTokenDenylist) RevokeAll(ctx context.Context, userID string, issuedBefore, expiresAt time.Time) error {
+	args := m.Called(ctx, userID, issuedBefore, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockTokenDenylist) IsRevoked(ctx context.Context, jti, userID string, issuedAt time.Time) (bool, error) {
+	args := m.Called(ctx, jti, userID, issuedAt)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockTokenDenylist) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// MockUserRepository — мок для IUserRepository
+type MockUserRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserRepository) Create(ctx context.Context, user *models.User) (*models.User, error) {
+	args := m.Called(ctx, user)
+	result, _ := args.Get(0).(*models.User)
+	return result, args.Error(1)
+}
+
+func (m *MockUserRepository) FindById(ctx context.Context, userId string) (*models.User, error) {
+	args := m.Called(ctx, userId)
+	result, _ := args.Get(0).(*models.User)
+	return result, args.Error(1)
+}
+
+func (m *MockUserRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	args := m.Called(ctx, email)
+	result, _ := args.Get(0).(*models.User)
+	return result, args.Error(1)
+}
+
+func (m *MockUserRepository) UpdatePassword(ctx context.Context, userID, passwordHash string) error {
+	args := m.Called(ctx, userID, passwordHash)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) MarkEmailVerified(ctx context.Context, userID string, at time.Time) error {
+	args := m.Called(ctx, userID, at)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) Update(ctx context.Context, user *models.User) (*models.User, error) {
+	args := m.Called(ctx, user)
+	result, _ := args.Get(0).(*models.User)
+	return result, args.Error(1)
+}
+
+func (m *MockUserRepository) Delete(ctx context.Context, userID string) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+// WithTransaction не открывает транзакцию, а сразу вызывает fn с самим моком
+func (m *MockUserRepository) WithTransaction(ctx context.Context, fn func(repo di.IUserRepository) error) error {
+	return fn(m)
+}
+
 // TestAuthHandler_Register — тесты для хендлера Register
 func TestAuthHandler_Register(t *testing.T) {
 	// Таблица тестов с различными сценариями для проверки поведения Register
@@ -58,6 +292,10 @@ func TestAuthHandler_Register(t *testing.T) {
 				// Настраиваем мок: при вызове Register с указанными параметрами возвращаем "user123" и nil
 				m.On("Register", mock.Anything, "john@example.com", "password123", "John Doe").
 					Return("user123", nil)
+				m.On("IssueTokens", mock.Anything, &models.User{ID: "user123", Email: "john@example.com"}, mock.Anything).
+					Return(&models.TokenPair{AccessToken: "access", RefreshToken: "refresh"}, nil)
+				// Письмо подтверждения отправляется в фоне и может не успеть уйти до конца теста
+				m.On("SendVerificationEmail", mock.Anything, "user123", mock.Anything).Return(nil).Maybe()
 			},
 			expectedStatus: http.StatusOK, // Ожидаем успешный статус 200
 			checkResponse: func(t *testing.T, rr *httptest.ResponseRecorder) {
@@ -66,6 +304,7 @@ func TestAuthHandler_Register(t *testing.T) {
 				err := json.Unmarshal(rr.Body.Bytes(), &resp)
 				assert.NoError(t, err, "failed to unmarshal response")
 				assert.NotEmpty(t, resp.Token, "token should not be empty")
+				assert.NotEmpty(t, resp.RefreshToken, "refresh token should not be empty")
 			},
 		},
 		{
@@ -112,14 +351,8 @@ func TestAuthHandler_Register(t *testing.T) {
 			tt.mockRegister(mockService) // Настраиваем поведение мока для Register
 
 			// Создаем тестовую конфигурацию с секретом для JWT
-			cfg := &configs.Config{
-				Auth: struct {
-					Secret        string        `mapstructure:"SECRET"`
-					TokenLifetime time.Duration `mapstructure:"TOKEN_LIFETIME"`
-				}{
-					Secret: "test-secret",
-				},
-			}
+			cfg := &configs.Config{}
+			cfg.Auth.Secret = "test-secret"
 
 			// Инициализируем хендлер с конфигурацией и мок-сервисом
 			handler := &AuthHandler{
@@ -175,6 +408,8 @@ func TestAuthHandler_Login(t *testing.T) {
 						Password: "hashed_password",
 						Name:     "John Doe",
 					}, nil)
+				m.On("IssueTokens", mock.Anything, mock.MatchedBy(func(u *models.User) bool { return u.ID == "user123" }), mock.Anything).
+					Return(&models.TokenPair{AccessToken: "access", RefreshToken: "refresh"}, nil)
 			},
 			expectedStatus: http.StatusOK, // Ожидаем успешный статус 200
 			checkResponse: func(t *testing.T, rr *httptest.ResponseRecorder) {
@@ -183,6 +418,7 @@ func TestAuthHandler_Login(t *testing.T) {
 				err := json.Unmarshal(rr.Body.Bytes(), &resp)
 				assert.NoError(t, err, "failed to unmarshal response")
 				assert.NotEmpty(t, resp.Token, "token should not be empty")
+				assert.NotEmpty(t, resp.RefreshToken, "refresh token should not be empty")
 			},
 		},
 		{
@@ -226,14 +462,8 @@ func TestAuthHandler_Login(t *testing.T) {
 			tt.mockLogin(mockService) // Настраиваем поведение мока для Login
 
 			// Создаем тестовую конфигурацию с секретом для JWT
-			cfg := &configs.Config{
-				Auth: struct {
-					Secret        string        `mapstructure:"SECRET"`
-					TokenLifetime time.Duration `mapstructure:"TOKEN_LIFETIME"`
-				}{
-					Secret: "test-secret",
-				},
-			}
+			cfg := &configs.Config{}
+			cfg.Auth.Secret = "test-secret"
 
 			// Инициализируем хендлер с конфигурацией и мок-сервисом
 			handler := &AuthHandler{
@@ -263,3 +493,309 @@ func TestAuthHandler_Login(t *testing.T) {
 		})
 	}
 }
+
+// TestAuthService_Refresh — ротация токена обновления и отзыв семейства при повторном предъявлении
+func TestAuthService_Refresh(t *testing.T) {
+	now := time.Date(2025, time.March, 3, 9, 0, 0, 0, time.UTC)
+	rotatedAt := now.Add(-time.Minute)
+	cfg := &configs.Config{}
+	cfg.Auth.Secret = "test-secret"
+	cfg.Auth.TokenLifetime = 15 * time.Minute
+	cfg.Auth.RefreshTokenLifetime = 24 * time.Hour
+
+	active := &models.RefreshToken{ID: "rt1", UserID: "user123", FamilyID: "fam1", ExpiresAt: now.Add(time.Hour)}
+	rotated := &models.RefreshToken{ID: "rt0", UserID: "user123", FamilyID: "fam1", ExpiresAt: now.Add(time.Hour), RotatedAt: &rotatedAt}
+	expired := &models.RefreshToken{ID: "rt2", UserID: "user123", FamilyID: "fam2", ExpiresAt: now.Add(-time.Second)}
+
+	tokenRepo := new(MockRefreshTokenRepository)
+	tokenRepo.On("GetByHash", mock.Anything, hashToken("active")).Return(active, nil)
+	tokenRepo.On("GetByHash", mock.Anything, hashToken("rotated")).Return(rotated, nil)
+	tokenRepo.On("GetByHash", mock.Anything, hashToken("expired")).Return(expired, nil)
+	tokenRepo.On("GetByHash", mock.Anything, hashToken("unknown")).Return(nil, ErrRefreshTokenNotFound)
+	tokenRepo.On("MarkRotated", mock.Anything, "rt1", now).Return(nil)
+	tokenRepo.On("RevokeFamily", mock.Anything, "fam1", now).Return(nil)
+	tokenRepo.On("Create", mock.Anything, mock.MatchedBy(func(token *models.RefreshToken) bool {
+		return token.FamilyID == "fam1" && token.UserID == "user123" && token.ExpiresAt.Equal(now.Add(24*time.Hour))
+	})).Return(&models.RefreshToken{ID: "rt3", FamilyID: "fam1", ExpiresAt: now.Add(24 * time.Hour)}, nil)
+	userRepo := new(MockUserRepository)
+	userRepo.On("FindById", mock.Anything, "user123").Return(&models.User{ID: "user123", Email: "john@example.com"}, nil)
+	service := NewUserService(userRepo, tokenRepo, nil, nil, nil, nil, cfg)
+
+	tokens, err := service.Refresh(context.Background(), "active", now)
+	assert.NoError(t, err, "unexpected error")
+	assert.NotEmpty(t, tokens.AccessToken, "access token should not be empty")
+	assert.NotEqual(t, "active", tokens.RefreshToken, "refresh token should be rotated")
+	assert.Equal(t, now.Add(15*time.Minute), tokens.AccessExpiresAt, "access expiry mismatch")
+
+	_, err = service.Refresh(context.Background(), "rotated", now)
+	assert.ErrorIs(t, err, ErrRefreshTokenReused, "reused token should revoke the family")
+
+	_, err = service.Refresh(context.Background(), "expired", now)
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken, "expired token should be rejected")
+
+	_, err = service.Refresh(context.Background(), "unknown", now)
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken, "unknown token should be rejected")
+
+	tokenRepo.AssertExpectations(t)
+	userRepo.AssertExpectations(t)
+}
+
+// TestAuthService_Logout — выход отзывает токен доступа и свое семейство токенов обновления, но не чужое
+func TestAuthService_Logout(t *testing.T) {
+	now := time.Date(2025, time.March, 3, 9, 0, 0, 0, time.UTC)
+	expiresAt := now.Add(10 * time.Minute)
+	cfg := &configs.Config{}
+	cfg.Auth.TokenLifetime = 15 * time.Minute
+
+	tokenRepo := new(MockRefreshTokenRepository)
+	tokenRepo.On("GetByHash", mock.Anything, hashToken("own")).Return(&models.RefreshToken{ID: "rt1", UserID: "user123", FamilyID: "fam1"}, nil)
+	tokenRepo.On("GetByHash", mock.Anything, hashToken("foreign")).Return(&models.RefreshToken{ID: "rt2", UserID: "other", FamilyID: "fam2"}, nil)
+	tokenRepo.On("RevokeFamily", mock.Anything, "fam1", now).Return(nil).Once()
+	tokenRepo.On("RevokeUser", mock.Anything, "user123", now).Return(nil)
+	denylist := new(MockTokenDenylist)
+	denylist.On("Revoke", mock.Anything, "jti1", "user123", expiresAt).Return(nil)
+	denylist.On("RevokeAll", mock.Anything, "user123", now, now.Add(15*time.Minute)).Return(nil)
+	service := NewUserService(nil, tokenRepo, nil, nil, denylist, nil, cfg)
+
+	assert.NoError(t, service.Logout(context.Background(), "user123", "jti1", expiresAt, "own", now), "unexpected error")
+	assert.NoError(t, service.Logout(context.Background(), "user123", "jti1", expiresAt, "foreign", now), "unexpected error")
+	assert.NoError(t, service.LogoutAll(context.Background(), "user123", now), "unexpected error")
+
+	tokenRepo.AssertExpectations(t)
+	denylist.AssertExpectations(t)
+}
+
+// TestAuthService_RequestPasswordReset — письмо уходит только существующему пользователю, в базе остается хеш токена
+func TestAuthService_RequestPasswordReset(t *testing.T) {
+	now := time.Date(2025, time.March, 3, 9, 0, 0, 0, time.UTC)
+	cfg := &configs.Config{}
+	cfg.Auth.ResetTokenLifetime = time.Hour
+	cfg.Mail.AppURL = "https://todo.example.com/"
+
+	userRepo := new(MockUserRepository)
+	userRepo.On("FindByEmail", mock.Anything, "john@example.com").Return(&models.User{ID: "user123", Email: "john@example.com", Name: "John"}, nil)
+	userRepo.On("FindByEmail", mock.Anything, "ghost@example.com").Return(nil, user.ErrUserNotFound)
+	resetRepo := new(MockResetTokenRepository)
+	resetRepo.On("InvalidateUser", mock.Anything, "user123", now).Return(nil)
+	var storedHash string
+	resetRepo.On("Create", mock.Anything, mock.MatchedBy(func(token *models.PasswordResetToken) bool {
+		storedHash = token.TokenHash
+		return token.UserID == "user123" && token.ExpiresAt.Equal(now.Add(time.Hour))
+	})).Return(&models.PasswordResetToken{ID: "reset1", ExpiresAt: now.Add(time.Hour)}, nil)
+	mailer := &stubMailer{}
+	service := NewUserService(userRepo, nil, resetRepo, nil, nil, mailer, cfg)
+
+	assert.NoError(t, service.RequestPasswordReset(context.Background(), "ghost@example.com", now), "unknown email is not an error")
+	assert.Empty(t, mailer.to, "no email for unknown address")
+
+	assert.NoError(t, service.RequestPasswordReset(context.Background(), "john@example.com", now), "unexpected error")
+	assert.Equal(t, "john@example.com", mailer.to, "recipient mismatch")
+	prefix := "https://todo.example.com/reset-password?token="
+	start := strings.Index(mailer.body, prefix)
+	if assert.GreaterOrEqual(t, start, 0, "email should contain the reset link") {
+		link := mailer.body[start+len(prefix):]
+		resetToken := link[:strings.IndexByte(link, '\n')]
+		assert.Equal(t, storedHash, hashToken(resetToken), "only the token hash should be stored")
+	}
+	userRepo.AssertExpectations(t)
+	resetRepo.AssertExpectations(t)
+}
+
+// TestAuthService_ResetPassword — токен одноразовый и ограничен по времени, сброс завершает все сессии
+func TestAuthService_ResetPassword(t *testing.T) {
+	now := time.Date(2025, time.March, 3, 9, 0, 0, 0, time.UTC)
+	usedAt := now.Add(-time.Minute)
+	cfg := &configs.Config{}
+	cfg.Auth.TokenLifetime = 15 * time.Minute
+
+	resetRepo := new(MockResetTokenRepository)
+	resetRepo.On("GetByHash", mock.Anything, hashToken("valid")).Return(&models.PasswordResetToken{ID: "reset1", UserID: "user123", ExpiresAt: now.Add(time.Hour)}, nil)
+	resetRepo.On("GetByHash", mock.Anything, hashToken("used")).Return(&models.PasswordResetToken{ID: "reset2", UserID: "user123", ExpiresAt: now.Add(time.Hour), UsedAt: &usedAt}, nil)
+	resetRepo.On("GetByHash", mock.Anything, hashToken("expired")).Return(&models.PasswordResetToken{ID: "reset3", UserID: "user123", ExpiresAt: now}, nil)
+	resetRepo.On("MarkUsed", mock.Anything, "reset1", now).Return(nil)
+	resetRepo.On("InvalidateUser", mock.Anything, "user123", now).Return(nil)
+	userRepo := new(MockUserRepository)
+	userRepo.On("UpdatePassword", mock.Anything, "user123", mock.MatchedBy(func(hash string) bool {
+		return bcrypt.CompareHashAndPassword([]byte(hash), []byte("new-password")) == nil
+	})).Return(nil)
+	tokenRepo := new(MockRefreshTokenRepository)
+	tokenRepo.On("RevokeUser", mock.Anything, "user123", now).Return(nil)
+	denylist := new(MockTokenDenylist)
+	denylist.On("RevokeAll", mock.Anything, "user123", now, now.Add(15*time.Minute)).Return(nil)
+	service := NewUserService(userRepo, tokenRepo, resetRepo, nil, denylist, nil, cfg)
+
+	assert.NoError(t, service.ResetPassword(context.Background(), "valid", "new-password", now), "unexpected error")
+	assert.ErrorIs(t, service.ResetPassword(context.Background(), "used", "new-password", now), ErrInvalidResetToken, "used token should be rejected")
+	assert.ErrorIs(t, service.ResetPassword(context.Background(), "expired", "new-password", now), ErrInvalidResetToken, "expired token should be rejected")
+
+	resetRepo.AssertExpectations(t)
+	userRepo.AssertExpectations(t)
+	tokenRepo.AssertExpectations(t)
+	denylist.AssertExpectations(t)
+}
+
+// TestAuthService_SendVerificationEmail — ссылка уходит только неподтвержденному пользователю, в базе остается хеш токена
+func TestAuthService_SendVerificationEmail(t *testing.T) {
+	now := time.Date(2025, time.March, 3, 9, 0, 0, 0, time.UTC)
+	verifiedAt := now.Add(-time.Hour)
+	cfg := &configs.Config{}
+	cfg.Auth.VerifyTokenLifetime = 48 * time.Hour
+	cfg.Mail.AppURL = "https://todo.example.com"
+
+	userRepo := new(MockUserRepository)
+	userRepo.On("FindById", mock.Anything, "user123").Return(&models.User{ID: "user123", Email: "john@example.com", Name: "John"}, nil)
+	userRepo.On("FindById", mock.Anything, "verified").Return(&models.User{ID: "verified", Email: "jane@example.com", EmailVerifiedAt: &verifiedAt}, nil)
+	verifyRepo := new(MockVerifyTokenRepository)
+	verifyRepo.On("InvalidateUser", mock.Anything, "user123", now).Return(nil)
+	var storedHash string
+	verifyRepo.On("Create", mock.Anything, mock.MatchedBy(func(token *models.EmailVerificationToken) bool {
+		storedHash = token.TokenHash
+		return token.UserID == "user123" && token.ExpiresAt.Equal(now.Add(48*time.Hour))
+	})).Return(&models.EmailVerificationToken{ID: "verify1", ExpiresAt: now.Add(48 * time.Hour)}, nil)
+	mailer := &stubMailer{}
+	service := NewUserService(userRepo, nil, nil, verifyRepo, nil, mailer, cfg)
+
+	assert.ErrorIs(t, service.SendVerificationEmail(context.Background(), "verified", now), ErrEmailAlreadyVerified, "verified user should not get a link")
+	assert.Empty(t, mailer.to, "no email for verified user")
+
+	assert.NoError(t, service.SendVerificationEmail(context.Background(), "user123", now), "unexpected error")
+	assert.Equal(t, "john@example.com", mailer.to, "recipient mismatch")
+	prefix := "https://todo.example.com/verify-email?token="
+	start := strings.Index(mailer.body, prefix)
+	if assert.GreaterOrEqual(t, start, 0, "email should contain the verification link") {
+		link := mailer.body[start+len(prefix):]
+		verifyToken := link[:strings.IndexByte(link, '\n')]
+		assert.Equal(t, storedHash, hashToken(verifyToken), "only the token hash should be stored")
+	}
+	userRepo.AssertExpectations(t)
+	verifyRepo.AssertExpectations(t)
+}
+
+// TestAuthService_VerifyEmail — токен одноразовый и ограничен по времени
+func TestAuthService_VerifyEmail(t *testing.T) {
+	now := time.Date(2025, time.March, 3, 9, 0, 0, 0, time.UTC)
+	usedAt := now.Add(-time.Minute)
+
+	verifyRepo := new(MockVerifyTokenRepository)
+	verifyRepo.On("GetByHash", mock.Anything, hashToken("valid")).Return(&models.EmailVerificationToken{ID: "verify1", UserID: "user123", ExpiresAt: now.Add(time.Hour)}, nil)
+	verifyRepo.On("GetByHash", mock.Anything, hashToken("used")).Return(&models.EmailVerificationToken{ID: "verify2", UserID: "user123", ExpiresAt: now.Add(time.Hour), UsedAt: &usedAt}, nil)
+	verifyRepo.On("GetByHash", mock.Anything, hashToken("expired")).Return(&models.EmailVerificationToken{ID: "verify3", UserID: "user123", ExpiresAt: now}, nil)
+	verifyRepo.On("GetByHash", mock.Anything, hashToken("unknown")).Return(nil, ErrInvalidVerifyToken)
+	verifyRepo.On("MarkUsed", mock.Anything, "verify1", now).Return(nil)
+	verifyRepo.On("InvalidateUser", mock.Anything, "user123", now).Return(nil)
+	userRepo := new(MockUserRepository)
+	userRepo.On("MarkEmailVerified", mock.Anything, "user123", now).Return(nil).Once()
+	service := NewUserService(userRepo, nil, nil, verifyRepo, nil, nil, &configs.Config{})
+
+	assert.NoError(t, service.VerifyEmail(context.Background(), "valid", now), "unexpected error")
+	assert.ErrorIs(t, service.VerifyEmail(context.Background(), "used", now), ErrInvalidVerifyToken, "used token should be rejected")
+	assert.ErrorIs(t, service.VerifyEmail(context.Background(), "expired", now), ErrInvalidVerifyToken, "expired token should be rejected")
+	assert.ErrorIs(t, service.VerifyEmail(context.Background(), "unknown", now), ErrInvalidVerifyToken, "unknown token should be rejected")
+
+	verifyRepo.AssertExpectations(t)
+	userRepo.AssertExpectations(t)
+}
+
+// TestRequireVerifiedEmail — в режиме block неподтвержденный пользователь получает 403, в режиме flag только заголовок
+func TestRequireVerifiedEmail(t *testing.T) {
+	jwt := token.NewJWT("test-secret")
+	unverified, _ := jwt.GenerateToken(token.JwtDate{UserId: "user123", Email: "john@example.com"}, time.Now(), time.Minute)
+	verified, _ := jwt.GenerateToken(token.JwtDate{UserId: "user123", Email: "john@example.com", EmailVerified: true}, time.Now(), time.Minute)
+
+	tests := []struct {
+		name           string
+		policy         string
+		accessToken    string
+		expectedStatus int
+		expectedHeader string
+	}{
+		{name: "Block unverified", policy: "block", accessToken: unverified, expectedStatus: http.StatusForbidden},
+		{name: "Block verified", policy: "block", accessToken: verified, expectedStatus: http.StatusOK},
+		{name: "Flag unverified", policy: "flag", accessToken: unverified, expectedStatus: http.StatusOK, expectedHeader: "false"},
+		{name: "Flag verified", policy: "flag", accessToken: verified, expectedStatus: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &configs.Config{}
+			cfg.Auth.Secret = "test-secret"
+			cfg.Auth.UnverifiedEmail = tt.policy
+			denylist := new(MockTokenDenylist)
+			denylist.On("IsRevoked", mock.Anything, mock.Anything, "user123", mock.Anything).Return(false, nil)
+			handler := middleware.Chain(middleware.IsAuthenticated(cfg, denylist), middleware.RequireVerifiedEmail(cfg))(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
+
+			req := httptest.NewRequest("GET", "/notes", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.accessToken)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code, "unexpected status code")
+			assert.Equal(t, tt.expectedHeader, rr.Header().Get("X-Email-Verified"), "unexpected flag header")
+			if tt.expectedStatus == http.StatusForbidden {
+				assert.Equal(t, "application/json", rr.Header().Get("Content-Type"), "unexpected content type")
+				assert.Contains(t, rr.Body.String(), middleware.ErrEmailNotVerified.Error(), "unexpected error message")
+			}
+		})
+	}
+}
+
+// TestIsAuthenticated_Denylist — отозванный токен и недоступный denylist не пропускаются
+func TestIsAuthenticated_Denylist(t *testing.T) {
+	accessToken, _ := token.NewJWT("test-secret").GenerateToken(token.JwtDate{UserId: "user123", Email: "john@example.com"}, time.Now(), time.Minute)
+
+	tests := []struct {
+		name           string
+		revoked        bool
+		err            error
+		expectedStatus int
+	}{
+		{name: "Active token", expectedStatus: http.StatusOK},
+		{name: "Revoked token", revoked: true, expectedStatus: http.StatusUnauthorized},
+		{name: "Denylist unavailable", err: errors.New("db is down"), expectedStatus: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &configs.Config{}
+			cfg.Auth.Secret = "test-secret"
+			denylist := new(MockTokenDenylist)
+			denylist.On("IsRevoked", mock.Anything, mock.Anything, "user123", mock.Anything).Return(tt.revoked, tt.err)
+			handler := middleware.IsAuthenticated(cfg, denylist)(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
+
+			req := httptest.NewRequest("GET", "/notes", nil)
+			req.Header.Set("Authorization", "Bearer "+accessToken)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code, "unexpected status code")
+			denylist.AssertExpectations(t)
+		})
+	}
+}
+
+// TestTokenDenylist_RevokeAllSameSecond — вход в ту же секунду, что и выход со всех устройств, дает рабочий токен,
+// а токены, выпущенные раньше, остаются отозванными
+func TestTokenDenylist_RevokeAllSameSecond(t *testing.T) {
+	now := time.Now()
+	denylist := &TokenDenylist{cache: cache.New(negativeCacheTTL, time.Minute)}
+	denylist.remember(userEntryID("user123"), newUserEntry("user123", now, now.Add(15*time.Minute)), now.Add(15*time.Minute))
+
+	jwt := token.NewJWT("test-secret")
+	fresh, err := jwt.GenerateToken(token.JwtDate{UserId: "user123"}, now, 15*time.Minute)
+	assert.NoError(t, err, "failed to generate token")
+	_, freshData := jwt.ParseToken(fresh)
+	old, err := jwt.GenerateToken(token.JwtDate{UserId: "user123"}, now.Add(-time.Second), 15*time.Minute)
+	assert.NoError(t, err, "failed to generate token")
+	_, oldData := jwt.ParseToken(old)
+	// Отдельно эти токены не отзывались: кэш отвечает вместо базы
+	denylist.cache.Set(freshData.ID, (*models.RevokedToken)(nil), negativeCacheTTL)
+	denylist.cache.Set(oldData.ID, (*models.RevokedToken)(nil), negativeCacheTTL)
+
+	revoked, err := denylist.IsRevoked(context.Background(), freshData.ID, "user123", freshData.IssuedAt)
+	assert.NoError(t, err, "unexpected error")
+	assert.False(t, revoked, "token issued in the same second after the revoke should stay valid")
+
+	revoked, err = denylist.IsRevoked(context.Background(), oldData.ID, "user123", oldData.IssuedAt)
+	assert.NoError(t, err, "unexpected error")
+	assert.True(t, revoked, "token issued before the revoke should be rejected")
+}
@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"ToDo/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/patrickmn/go-cache"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// negativeCacheTTL — сколько помнить, что токен не отозван. Отзыв на этом экземпляре виден сразу,
+// на остальных — не позже чем через этот интервал
+const negativeCacheTTL = 30 * time.Second
+
+// TokenDenylist хранит отозванные токены доступа в базе, а результаты проверок — в памяти процесса
+type TokenDenylist struct {
+	db    *gorm.DB
+	cache *cache.Cache
+}
+
+func NewTokenDenylist(dataBase *gorm.DB) *TokenDenylist {
+	return &TokenDenylist{
+		db:    dataBase,
+		cache: cache.New(negativeCacheTTL, 10*time.Minute),
+	}
+}
+
+// Revoke отзывает токен с идентификатором jti до его истечения
+func (d *TokenDenylist) Revoke(ctx context.Context, jti, userId string, expiresAt time.Time) error {
+	entry := &models.RevokedToken{ID: jti, UserID: userId, ExpiresAt: expiresAt}
+	if err := d.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(entry).Error; err != nil {
+		return fmt.Errorf("revoke token %s: %w", jti, err)
+	}
+	d.remember(entry.ID, entry, expiresAt)
+	return nil
+}
+
+// RevokeAll отзывает все токены пользователя, выпущенные раньше issuedBefore; expiresAt — когда истечет последний из них
+func (d *TokenDenylist) RevokeAll(ctx context.Context, userId string, issuedBefore, expiresAt time.Time) error {
+	entry := newUserEntry(userId, issuedBefore, expiresAt)
+	err := d.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"issued_before", "expires_at"}),
+	}).Create(entry).Error
+	if err != nil {
+		return fmt.Errorf("revoke tokens of user %s: %w", userId, err)
+	}
+	d.remember(entry.ID, entry, expiresAt)
+	return nil
+}
+
+// IsRevoked сообщает, отозван ли токен сам по себе или вместе со всеми токенами пользователя
+func (d *TokenDenylist) IsRevoked(ctx context.Context, jti, userId string, issuedAt time.Time) (bool, error) {
+	entry, err := d.lookup(ctx, jti)
+	if err != nil || entry != nil {
+		return entry != nil, err
+	}
+	entry, err = d.lookup(ctx, userEntryID(userId))
+	if err != nil || entry == nil {
+		return false, err
+	}
+	return issuedAt.Before(*entry.IssuedBefore), nil
+}
+
+// DeleteExpired стирает записи, чьи токены истекли раньше before
+func (d *TokenDenylist) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	result := d.db.WithContext(ctx).Where("expires_at < ?", before).Delete(&models.RevokedToken{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("delete expired revoked tokens: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// lookup ищет запись сначала в кэше, затем в базе; nil — записи нет
+func (d *TokenDenylist) lookup(ctx context.Context, id string) (*models.RevokedToken, error) {
+	if cached, ok := d.cache.Get(id); ok {
+		entry, _ := cached.(*models.RevokedToken)
+		return entry, nil
+	}
+
+	var entry models.RevokedToken
+	err := d.db.WithContext(ctx).Where("id = ? AND expires_at > ?", id, time.Now()).First(&entry).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		d.cache.Set(id, (*models.RevokedToken)(nil), negativeCacheTTL)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("check revoked token %s: %w", id, err)
+	}
+	d.remember(id, &entry, entry.ExpiresAt)
+	return &entry, nil
+}
+
+func (d *TokenDenylist) remember(id string, entry *models.RevokedToken, expiresAt time.Time) {
+	if ttl := time.Until(expiresAt); ttl > 0 {
+		d.cache.Set(id, entry, ttl)
+	}
+}
+
+// newUserEntry создает запись отзыва всех токенов пользователя. iat в токене хранится с точностью до секунды,
+// поэтому граница тоже округляется вниз до секунды: токен, выпущенный в ту же секунду после отзыва
+// (например, при входе сразу после выхода со всех устройств), остается действительным
+func newUserEntry(userId string, issuedBefore, expiresAt time.Time) *models.RevokedToken {
+	issuedBefore = issuedBefore.Truncate(time.Second)
+	return &models.RevokedToken{ID: userEntryID(userId), UserID: userId, IssuedBefore: &issuedBefore, ExpiresAt: expiresAt}
+}
+
+func userEntryID(userId string) string {
+	return "user:" + userId
+}
@@ -1,6 +1,18 @@
 package auth
 
+import "errors"
+
 const (
 	ErrUserExisted      = "User already existed"
 	ErrWrongCredentials = "Password or Email does not match"
 )
+
+var (
+	ErrCreateToken          = errors.New("failed to create token")
+	ErrInvalidRefreshToken  = errors.New("invalid refresh token")
+	ErrRefreshTokenReused   = errors.New("refresh token reuse detected")
+	ErrRefreshTokenNotFound = errors.New("refresh token not found")
+	ErrInvalidResetToken    = errors.New("invalid or expired reset token")
+	ErrInvalidVerifyToken   = errors.New("invalid or expired verification token")
+	ErrEmailAlreadyVerified = errors.New("email already verified")
+)
@@ -14,8 +14,9 @@ type AuthHandler struct {
 }
 
 type AuthHandlerDeps struct {
-	Config      *configs.Config
-	AuthService di.IAuthService
+	Config        *configs.Config
+	TokenDenylist di.ITokenDenylist
+	AuthService   di.IAuthService
 }
 
 func NewAuthHandler(router *http.ServeMux, deps *AuthHandlerDeps) {
@@ -31,4 +32,19 @@ func NewAuthHandler(router *http.ServeMux, deps *AuthHandlerDeps) {
 
 	router.Handle("POST /auth/login", middlewares(handler.Login()))
 	router.Handle("POST /auth/register", middlewares(handler.Register()))
+	router.Handle("POST /auth/refresh", middlewares(handler.Refresh()))
+	router.Handle("POST /auth/password/forgot", middlewares(handler.ForgotPassword()))
+	router.Handle("POST /auth/password/reset", middlewares(handler.ResetPassword()))
+	router.Handle("POST /auth/verify", middlewares(handler.VerifyEmail()))
+
+	// Выход и повторная отправка ссылки подтверждения требуют действующего токена доступа
+	authenticated := middleware.Chain(
+		middleware.CORS,
+		middleware.Logging,
+		middleware.RateLimiter(deps.Config.RateLimit.MaxRequests, deps.Config.RateLimit.Burst, deps.Config.RateLimit.TTL),
+		middleware.IsAuthenticated(deps.Config, deps.TokenDenylist),
+	)
+	router.Handle("POST /auth/logout", authenticated(handler.Logout()))
+	router.Handle("POST /auth/logout-all", authenticated(handler.LogoutAll()))
+	router.Handle("POST /auth/verify/resend", authenticated(handler.ResendVerification()))
 }
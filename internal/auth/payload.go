@@ -1,12 +1,15 @@
 package auth
 
+import "time"
+
 type LoginRequest struct {
 	Email    string `json:"email" validate:"required,email,min=10"`
 	Password string `json:"password" validate:"required,min=8"`
 }
 
 type LoginResponse struct {
-	Token string `json:"token"`
+	TokenResponse
+	EmailVerified bool `json:"email_verified"`
 }
 
 type RegisterRequest struct {
@@ -16,5 +19,36 @@ type RegisterRequest struct {
 }
 
 type RegisterResponse struct {
-	Token string `json:"token"`
+	TokenResponse
+	EmailVerified bool `json:"email_verified"` // Всегда false: ссылка подтверждения отправляется на email
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// LogoutRequest — необязательное тело POST /auth/logout: токен обновления текущей сессии
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=8"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// TokenResponse — пара токенов: token передается в Authorization, refresh_token — в POST /auth/refresh
+type TokenResponse struct {
+	Token            string    `json:"token"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
 }
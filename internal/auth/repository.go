@@ -0,0 +1,225 @@
+package auth
+
+import (
+	"ToDo/internal/models"
+	"ToDo/pkg/idgen"
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"time"
+)
+
+type RefreshTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewRefreshTokenRepository(dataBase *gorm.DB) *RefreshTokenRepository {
+	return &RefreshTokenRepository{db: dataBase}
+}
+
+// Create сохраняет токен; токен без семейства открывает новое семейство со своим ID
+func (r *RefreshTokenRepository) Create(ctx context.Context, token *models.RefreshToken) (*models.RefreshToken, error) {
+	token.ID = idgen.GenerateNanoID()
+	if token.ID == "" {
+		return nil, fmt.Errorf("generate id: %w", ErrCreateToken)
+	}
+	if token.FamilyID == "" {
+		token.FamilyID = token.ID
+	}
+
+	if err := r.db.WithContext(ctx).Create(token).Error; err != nil {
+		return nil, fmt.Errorf("create refresh token for user %s: %w", token.UserID, err)
+	}
+	return token, nil
+}
+
+func (r *RefreshTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	result := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&token)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("get refresh token: %w", ErrRefreshTokenNotFound)
+		}
+		return nil, fmt.Errorf("get refresh token: %w", result.Error)
+	}
+	return &token, nil
+}
+
+// MarkRotated помечает токен обменянным. Если токен уже обменян или отозван (в том числе параллельным
+// запросом), возвращает ErrRefreshTokenReused
+func (r *RefreshTokenRepository) MarkRotated(ctx context.Context, tokenId string, at time.Time) error {
+	result := r.db.WithContext(ctx).Model(&models.RefreshToken{}).
+		Where("id = ? AND rotated_at IS NULL AND revoked_at IS NULL", tokenId).
+		Update("rotated_at", at)
+	if result.Error != nil {
+		return fmt.Errorf("rotate refresh token %s: %w", tokenId, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("rotate refresh token %s: %w", tokenId, ErrRefreshTokenReused)
+	}
+	return nil
+}
+
+// RevokeFamily отзывает все еще не отозванные токены семейства
+func (r *RefreshTokenRepository) RevokeFamily(ctx context.Context, familyId string, at time.Time) error {
+	err := r.db.WithContext(ctx).Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyId).
+		Update("revoked_at", at).Error
+	if err != nil {
+		return fmt.Errorf("revoke refresh token family %s: %w", familyId, err)
+	}
+	return nil
+}
+
+// RevokeUser отзывает все еще не отозванные токены пользователя
+func (r *RefreshTokenRepository) RevokeUser(ctx context.Context, userId string, at time.Time) error {
+	err := r.db.WithContext(ctx).Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userId).
+		Update("revoked_at", at).Error
+	if err != nil {
+		return fmt.Errorf("revoke refresh tokens of user %s: %w", userId, err)
+	}
+	return nil
+}
+
+// DeleteExpired стирает токены, истекшие раньше before
+func (r *RefreshTokenRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("expires_at < ?", before).Delete(&models.RefreshToken{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("delete expired refresh tokens: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+type ResetTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewResetTokenRepository(dataBase *gorm.DB) *ResetTokenRepository {
+	return &ResetTokenRepository{db: dataBase}
+}
+
+func (r *ResetTokenRepository) Create(ctx context.Context, token *models.PasswordResetToken) (*models.PasswordResetToken, error) {
+	token.ID = idgen.GenerateNanoID()
+	if token.ID == "" {
+		return nil, fmt.Errorf("generate id: %w", ErrCreateToken)
+	}
+
+	if err := r.db.WithContext(ctx).Create(token).Error; err != nil {
+		return nil, fmt.Errorf("create reset token for user %s: %w", token.UserID, err)
+	}
+	return token, nil
+}
+
+func (r *ResetTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*models.PasswordResetToken, error) {
+	var token models.PasswordResetToken
+	result := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&token)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("get reset token: %w", ErrInvalidResetToken)
+		}
+		return nil, fmt.Errorf("get reset token: %w", result.Error)
+	}
+	return &token, nil
+}
+
+// MarkUsed гасит токен. Если токен уже использован (в том числе параллельным запросом), возвращает ErrInvalidResetToken
+func (r *ResetTokenRepository) MarkUsed(ctx context.Context, tokenId string, at time.Time) error {
+	result := r.db.WithContext(ctx).Model(&models.PasswordResetToken{}).
+		Where("id = ? AND used_at IS NULL", tokenId).
+		Update("used_at", at)
+	if result.Error != nil {
+		return fmt.Errorf("use reset token %s: %w", tokenId, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("use reset token %s: %w", tokenId, ErrInvalidResetToken)
+	}
+	return nil
+}
+
+// InvalidateUser гасит все неиспользованные токены сброса пользователя
+func (r *ResetTokenRepository) InvalidateUser(ctx context.Context, userId string, at time.Time) error {
+	err := r.db.WithContext(ctx).Model(&models.PasswordResetToken{}).
+		Where("user_id = ? AND used_at IS NULL", userId).
+		Update("used_at", at).Error
+	if err != nil {
+		return fmt.Errorf("invalidate reset tokens of user %s: %w", userId, err)
+	}
+	return nil
+}
+
+// DeleteExpired стирает токены, истекшие раньше before
+func (r *ResetTokenRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("expires_at < ?", before).Delete(&models.PasswordResetToken{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("delete expired reset tokens: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+type VerifyTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewVerifyTokenRepository(dataBase *gorm.DB) *VerifyTokenRepository {
+	return &VerifyTokenRepository{db: dataBase}
+}
+
+func (r *VerifyTokenRepository) Create(ctx context.Context, token *models.EmailVerificationToken) (*models.EmailVerificationToken, error) {
+	token.ID = idgen.GenerateNanoID()
+	if token.ID == "" {
+		return nil, fmt.Errorf("generate id: %w", ErrCreateToken)
+	}
+
+	if err := r.db.WithContext(ctx).Create(token).Error; err != nil {
+		return nil, fmt.Errorf("create verification token for user %s: %w", token.UserID, err)
+	}
+	return token, nil
+}
+
+func (r *VerifyTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*models.EmailVerificationToken, error) {
+	var token models.EmailVerificationToken
+	result := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&token)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("get verification token: %w", ErrInvalidVerifyToken)
+		}
+		return nil, fmt.Errorf("get verification token: %w", result.Error)
+	}
+	return &token, nil
+}
+
+// MarkUsed гасит токен. Если токен уже использован (в том числе параллельным запросом), возвращает ErrInvalidVerifyToken
+func (r *VerifyTokenRepository) MarkUsed(ctx context.Context, tokenId string, at time.Time) error {
+	result := r.db.WithContext(ctx).Model(&models.EmailVerificationToken{}).
+		Where("id = ? AND used_at IS NULL", tokenId).
+		Update("used_at", at)
+	if result.Error != nil {
+		return fmt.Errorf("use verification token %s: %w", tokenId, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("use verification token %s: %w", tokenId, ErrInvalidVerifyToken)
+	}
+	return nil
+}
+
+// InvalidateUser гасит все неиспользованные токены подтверждения пользователя
+func (r *VerifyTokenRepository) InvalidateUser(ctx context.Context, userId string, at time.Time) error {
+	err := r.db.WithContext(ctx).Model(&models.EmailVerificationToken{}).
+		Where("user_id = ? AND used_at IS NULL", userId).
+		Update("used_at", at).Error
+	if err != nil {
+		return fmt.Errorf("invalidate verification tokens of user %s: %w", userId, err)
+	}
+	return nil
+}
+
+// DeleteExpired стирает токены, истекшие раньше before
+func (r *VerifyTokenRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("expires_at < ?", before).Delete(&models.EmailVerificationToken{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("delete expired verification tokens: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
@@ -1,14 +1,28 @@
 package auth
 
 import (
+	"ToDo/internal/models"
 	"ToDo/internal/user"
+	"ToDo/pkg/middleware"
 	"ToDo/pkg/req"
 	"ToDo/pkg/res"
-	token2 "ToDo/pkg/token"
+	"ToDo/pkg/token"
+	"context"
 	"errors"
+	"log/slog"
 	"net/http"
+	"time"
 )
 
+func newTokenResponse(pair *models.TokenPair) TokenResponse {
+	return TokenResponse{
+		Token:            pair.AccessToken,
+		ExpiresAt:        pair.AccessExpiresAt,
+		RefreshToken:     pair.RefreshToken,
+		RefreshExpiresAt: pair.RefreshExpiresAt,
+	}
+}
+
 func (h *AuthHandler) Register() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		body, err := req.HandleBody[RegisterRequest](&w, r)
@@ -24,16 +38,22 @@ func (h *AuthHandler) Register() http.HandlerFunc {
 			}
 			return
 		}
-		token, err := token2.NewJWT(h.Config.Auth.Secret).GenerateToken(token2.JwtDate{
-			UserId: userId,
-		})
+		now := time.Now()
+		tokens, err := h.AuthService.IssueTokens(r.Context(), &models.User{ID: userId, Email: body.Email}, now)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
+		// Письмо отправляется в фоне: сбой почты не должен ломать регистрацию, ссылку можно запросить повторно
+		ctx := context.WithoutCancel(r.Context())
+		go func() {
+			if err := h.AuthService.SendVerificationEmail(ctx, userId, now); err != nil {
+				slog.Error("Failed to send verification email", "user_id", userId, "error", err)
+			}
+		}()
 
 		data := RegisterResponse{
-			Token: token,
+			TokenResponse: newTokenResponse(tokens),
 		}
 		res.JsonResponse(w, data, http.StatusOK)
 
@@ -48,7 +68,7 @@ func (h *AuthHandler) Login() http.HandlerFunc {
 			return
 		}
 
-		email, err := h.AuthService.Login(r.Context(), body.Email, body.Password)
+		existingUser, err := h.AuthService.Login(r.Context(), body.Email, body.Password)
 		if err != nil {
 			if errors.Is(err, user.ErrUserNotFound) {
 				res.JsonResponse(w, res.ErrorResponse{Error: "invalid credentials"}, http.StatusUnauthorized)
@@ -58,18 +78,164 @@ func (h *AuthHandler) Login() http.HandlerFunc {
 			return
 		}
 
-		token, err := token2.NewJWT(h.Config.Auth.Secret).GenerateToken(token2.JwtDate{
-			Email: email.Email,
-		})
+		tokens, err := h.AuthService.IssueTokens(r.Context(), existingUser, time.Now())
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 
 		data := LoginResponse{
-			Token: token,
+			TokenResponse: newTokenResponse(tokens),
+			EmailVerified: existingUser.EmailVerifiedAt != nil,
 		}
 		res.JsonResponse(w, data, http.StatusOK)
 
 	}
 }
+
+// Refresh обменивает токен обновления на новую пару токенов; старый токен обновления больше не действует
+func (h *AuthHandler) Refresh() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := req.HandleBody[RefreshRequest](&w, r)
+		if err != nil {
+			return
+		}
+
+		tokens, err := h.AuthService.Refresh(r.Context(), body.RefreshToken, time.Now())
+		if err != nil {
+			if errors.Is(err, ErrInvalidRefreshToken) || errors.Is(err, ErrRefreshTokenReused) {
+				res.JsonResponse(w, res.ErrorResponse{Error: ErrInvalidRefreshToken.Error()}, http.StatusUnauthorized)
+			} else {
+				res.JsonResponse(w, res.ErrorResponse{Error: "internal server error"}, http.StatusInternalServerError)
+			}
+			return
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		res.JsonResponse(w, newTokenResponse(tokens), http.StatusOK)
+	}
+}
+
+// Logout отзывает текущий токен доступа; refresh_token в теле (необязательном) завершает и саму сессию
+func (h *AuthHandler) Logout() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := r.Context().Value(middleware.ContextTokenKey).(token.JwtDate)
+		if !ok || claims.UserId == "" {
+			res.JsonResponse(w, res.ErrorResponse{Error: "unauthorized"}, http.StatusUnauthorized)
+			return
+		}
+		var refreshToken string
+		if r.ContentLength != 0 {
+			body, err := req.HandleBody[LogoutRequest](&w, r)
+			if err != nil {
+				return
+			}
+			refreshToken = body.RefreshToken
+		}
+
+		if err := h.AuthService.Logout(r.Context(), claims.UserId, claims.ID, claims.ExpiresAt, refreshToken, time.Now()); err != nil {
+			res.JsonResponse(w, res.ErrorResponse{Error: "internal server error"}, http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// LogoutAll завершает все сессии пользователя на всех устройствах, включая текущую
+func (h *AuthHandler) LogoutAll() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := r.Context().Value(middleware.ContextTokenKey).(token.JwtDate)
+		if !ok || claims.UserId == "" {
+			res.JsonResponse(w, res.ErrorResponse{Error: "unauthorized"}, http.StatusUnauthorized)
+			return
+		}
+
+		if err := h.AuthService.LogoutAll(r.Context(), claims.UserId, time.Now()); err != nil {
+			res.JsonResponse(w, res.ErrorResponse{Error: "internal server error"}, http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// ForgotPassword всегда отвечает 202: письмо отправляется в фоне, поэтому ни ответ, ни время ответа
+// не выдают, зарегистрирован ли адрес
+func (h *AuthHandler) ForgotPassword() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := req.HandleBody[ForgotPasswordRequest](&w, r)
+		if err != nil {
+			return
+		}
+
+		ctx := context.WithoutCancel(r.Context())
+		go func() {
+			if err := h.AuthService.RequestPasswordReset(ctx, body.Email, time.Now()); err != nil {
+				slog.Error("Failed to send password reset email", "error", err)
+			}
+		}()
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+// ResetPassword устанавливает новый пароль по токену из письма и завершает все сессии пользователя
+func (h *AuthHandler) ResetPassword() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := req.HandleBody[ResetPasswordRequest](&w, r)
+		if err != nil {
+			return
+		}
+
+		if err := h.AuthService.ResetPassword(r.Context(), body.Token, body.Password, time.Now()); err != nil {
+			if errors.Is(err, ErrInvalidResetToken) {
+				res.JsonResponse(w, res.ErrorResponse{Error: ErrInvalidResetToken.Error()}, http.StatusBadRequest)
+			} else {
+				res.JsonResponse(w, res.ErrorResponse{Error: "internal server error"}, http.StatusInternalServerError)
+			}
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// VerifyEmail подтверждает email по токену из письма. Чтобы статус попал в токен доступа, клиент обновляет пару токенов
+func (h *AuthHandler) VerifyEmail() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := req.HandleBody[VerifyEmailRequest](&w, r)
+		if err != nil {
+			return
+		}
+
+		if err := h.AuthService.VerifyEmail(r.Context(), body.Token, time.Now()); err != nil {
+			if errors.Is(err, ErrInvalidVerifyToken) {
+				res.JsonResponse(w, res.ErrorResponse{Error: ErrInvalidVerifyToken.Error()}, http.StatusBadRequest)
+			} else {
+				res.JsonResponse(w, res.ErrorResponse{Error: "internal server error"}, http.StatusInternalServerError)
+			}
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// ResendVerification повторно отправляет ссылку подтверждения текущему пользователю; прежние ссылки перестают действовать
+func (h *AuthHandler) ResendVerification() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userId, ok := r.Context().Value(middleware.ContextUserIDKey).(string)
+		if !ok || userId == "" {
+			res.JsonResponse(w, res.ErrorResponse{Error: "unauthorized"}, http.StatusUnauthorized)
+			return
+		}
+
+		if err := h.AuthService.SendVerificationEmail(r.Context(), userId, time.Now()); err != nil {
+			switch {
+			case errors.Is(err, ErrEmailAlreadyVerified):
+				res.JsonResponse(w, res.ErrorResponse{Error: ErrEmailAlreadyVerified.Error()}, http.StatusConflict)
+			case errors.Is(err, user.ErrUserNotFound):
+				res.JsonResponse(w, res.ErrorResponse{Error: "unauthorized"}, http.StatusUnauthorized)
+			default:
+				res.JsonResponse(w, res.ErrorResponse{Error: "internal server error"}, http.StatusInternalServerError)
+			}
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -1,23 +1,44 @@
 package auth
 
 import (
+	"ToDo/configs"
 	"ToDo/internal/models"
 	"ToDo/internal/user"
 	"ToDo/pkg/di"
+	"ToDo/pkg/token"
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"golang.org/x/crypto/bcrypt"
 	"log/slog"
+	"net/url"
+	"strings"
+	"time"
 )
 
 type AuthService struct {
-	UserRepository di.IUserRepository
+	UserRepository         di.IUserRepository
+	RefreshTokenRepository di.IRefreshTokenRepository
+	ResetTokenRepository   di.IResetTokenRepository
+	VerifyTokenRepository  di.IVerifyTokenRepository
+	TokenDenylist          di.ITokenDenylist
+	Mailer                 di.IMailer
+	Config                 *configs.Config
 }
 
-func NewUserService(userRepository di.IUserRepository) *AuthService {
+func NewUserService(userRepository di.IUserRepository, refreshTokenRepository di.IRefreshTokenRepository, resetTokenRepository di.IResetTokenRepository, verifyTokenRepository di.IVerifyTokenRepository, tokenDenylist di.ITokenDenylist, mailer di.IMailer, config *configs.Config) *AuthService {
 	return &AuthService{
-		UserRepository: userRepository,
+		UserRepository:         userRepository,
+		RefreshTokenRepository: refreshTokenRepository,
+		ResetTokenRepository:   resetTokenRepository,
+		VerifyTokenRepository:  verifyTokenRepository,
+		TokenDenylist:          tokenDenylist,
+		Mailer:                 mailer,
+		Config:                 config,
 	}
 }
 
@@ -61,3 +82,277 @@ func (s *AuthService) Login(ctx context.Context, email, password string) (*model
 	}
 	return existingUser, nil
 }
+
+// IssueTokens выдает пару токенов при входе: короткоживущий токен доступа и токен обновления нового семейства
+func (s *AuthService) IssueTokens(ctx context.Context, existingUser *models.User, now time.Time) (*models.TokenPair, error) {
+	slog.Info("Issuing tokens", "user_id", existingUser.ID)
+	return s.issueTokens(ctx, existingUser, "", now)
+}
+
+// Refresh обменивает токен обновления на новую пару (ротация). Повторное предъявление уже обменянного
+// токена означает его утечку: все семейство отзывается и возвращается ErrRefreshTokenReused
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string, now time.Time) (*models.TokenPair, error) {
+	stored, err := s.RefreshTokenRepository.GetByHash(ctx, hashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, ErrRefreshTokenNotFound) {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+	if stored.RevokedAt != nil || !now.Before(stored.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if stored.RotatedAt != nil {
+		return nil, s.revokeReused(ctx, stored, now)
+	}
+	if err := s.RefreshTokenRepository.MarkRotated(ctx, stored.ID, now); err != nil {
+		if errors.Is(err, ErrRefreshTokenReused) {
+			return nil, s.revokeReused(ctx, stored, now)
+		}
+		return nil, err
+	}
+
+	existingUser, err := s.UserRepository.FindById(ctx, stored.UserID)
+	if err != nil {
+		if errors.Is(err, user.ErrUserNotFound) {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+	slog.Info("Rotating refresh token", "user_id", stored.UserID, "family_id", stored.FamilyID)
+	return s.issueTokens(ctx, existingUser, stored.FamilyID, now)
+}
+
+// Logout отзывает текущий токен доступа (jti) и, если передан, семейство токена обновления пользователя
+func (s *AuthService) Logout(ctx context.Context, userID, jti string, expiresAt time.Time, refreshToken string, now time.Time) error {
+	slog.Info("Logging out", "user_id", userID)
+	if err := s.TokenDenylist.Revoke(ctx, jti, userID, expiresAt); err != nil {
+		return err
+	}
+	if refreshToken == "" {
+		return nil
+	}
+	stored, err := s.RefreshTokenRepository.GetByHash(ctx, hashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, ErrRefreshTokenNotFound) {
+			return nil
+		}
+		return err
+	}
+	if stored.UserID != userID { // Чужой токен обновления не отзываем
+		return nil
+	}
+	return s.RefreshTokenRepository.RevokeFamily(ctx, stored.FamilyID, now)
+}
+
+// LogoutAll завершает все сессии пользователя: отзывает токены обновления и все выпущенные токены доступа
+func (s *AuthService) LogoutAll(ctx context.Context, userID string, now time.Time) error {
+	slog.Info("Logging out everywhere", "user_id", userID)
+	if err := s.RefreshTokenRepository.RevokeUser(ctx, userID, now); err != nil {
+		return err
+	}
+	return s.TokenDenylist.RevokeAll(ctx, userID, now, now.Add(s.Config.Auth.TokenLifetime))
+}
+
+// RequestPasswordReset отправляет на email ссылку сброса пароля. Для неизвестного адреса ничего не делает
+// и не возвращает ошибку, чтобы по ответу нельзя было узнать, зарегистрирован ли адрес
+func (s *AuthService) RequestPasswordReset(ctx context.Context, email string, now time.Time) error {
+	existingUser, err := s.UserRepository.FindByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, user.ErrUserNotFound) {
+			slog.Info("Password reset requested for unknown email")
+			return nil
+		}
+		return err
+	}
+
+	// Действует только последняя отправленная ссылка
+	if err := s.ResetTokenRepository.InvalidateUser(ctx, existingUser.ID, now); err != nil {
+		return err
+	}
+	resetToken, err := newOpaqueToken()
+	if err != nil {
+		return err
+	}
+	stored, err := s.ResetTokenRepository.Create(ctx, &models.PasswordResetToken{
+		UserID:    existingUser.ID,
+		TokenHash: hashToken(resetToken),
+		ExpiresAt: now.Add(s.Config.Auth.ResetTokenLifetime),
+	})
+	if err != nil {
+		return err
+	}
+
+	slog.Info("Sending password reset email", "user_id", existingUser.ID)
+	link := strings.TrimRight(s.Config.Mail.AppURL, "/") + "/reset-password?token=" + url.QueryEscape(resetToken)
+	body := fmt.Sprintf("Hello, %s!\n\n"+
+		"To reset your password, open the link below:\n%s\n\n"+
+		"The link is valid until %s and can be used once.\n"+
+		"If you did not request a password reset, ignore this email.\n",
+		existingUser.Name, link, stored.ExpiresAt.UTC().Format("2006-01-02 15:04 MST"))
+	return s.Mailer.Send(ctx, existingUser.Email, "Password reset", body)
+}
+
+// ResetPassword устанавливает новый пароль по токену из письма. Токен гасится, а все сессии пользователя
+// завершаются: токены обновления отзываются, выпущенные токены доступа попадают в denylist
+func (s *AuthService) ResetPassword(ctx context.Context, resetToken, password string, now time.Time) error {
+	stored, err := s.ResetTokenRepository.GetByHash(ctx, hashToken(resetToken))
+	if err != nil {
+		return err
+	}
+	if stored.UsedAt != nil || !now.Before(stored.ExpiresAt) {
+		return ErrInvalidResetToken
+	}
+	if err := s.ResetTokenRepository.MarkUsed(ctx, stored.ID, now); err != nil {
+		return err
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("hash password: %w", err)
+	}
+	slog.Info("Resetting password", "user_id", stored.UserID)
+	if err := s.UserRepository.UpdatePassword(ctx, stored.UserID, string(hashedPassword)); err != nil {
+		return err
+	}
+	if err := s.ResetTokenRepository.InvalidateUser(ctx, stored.UserID, now); err != nil {
+		return err
+	}
+	return s.LogoutAll(ctx, stored.UserID, now)
+}
+
+// SendVerificationEmail отправляет ссылку подтверждения email. Действует только последняя отправленная ссылка
+func (s *AuthService) SendVerificationEmail(ctx context.Context, userID string, now time.Time) error {
+	existingUser, err := s.UserRepository.FindById(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if existingUser.EmailVerifiedAt != nil {
+		return ErrEmailAlreadyVerified
+	}
+
+	if err := s.VerifyTokenRepository.InvalidateUser(ctx, existingUser.ID, now); err != nil {
+		return err
+	}
+	verifyToken, err := newOpaqueToken()
+	if err != nil {
+		return err
+	}
+	stored, err := s.VerifyTokenRepository.Create(ctx, &models.EmailVerificationToken{
+		UserID:    existingUser.ID,
+		TokenHash: hashToken(verifyToken),
+		ExpiresAt: now.Add(s.Config.Auth.VerifyTokenLifetime),
+	})
+	if err != nil {
+		return err
+	}
+
+	slog.Info("Sending verification email", "user_id", existingUser.ID)
+	link := strings.TrimRight(s.Config.Mail.AppURL, "/") + "/verify-email?token=" + url.QueryEscape(verifyToken)
+	body := fmt.Sprintf("Hello, %s!\n\n"+
+		"To confirm your email address, open the link below:\n%s\n\n"+
+		"The link is valid until %s and can be used once.\n"+
+		"If you did not create an account, ignore this email.\n",
+		existingUser.Name, link, stored.ExpiresAt.UTC().Format("2006-01-02 15:04 MST"))
+	return s.Mailer.Send(ctx, existingUser.Email, "Confirm your email", body)
+}
+
+// VerifyEmail подтверждает адрес по токену из письма. Новое значение email_verified попадает в токены доступа,
+// выпущенные после подтверждения, поэтому клиенту стоит обновить пару через POST /auth/refresh
+func (s *AuthService) VerifyEmail(ctx context.Context, verifyToken string, now time.Time) error {
+	stored, err := s.VerifyTokenRepository.GetByHash(ctx, hashToken(verifyToken))
+	if err != nil {
+		return err
+	}
+	if stored.UsedAt != nil || !now.Before(stored.ExpiresAt) {
+		return ErrInvalidVerifyToken
+	}
+	if err := s.VerifyTokenRepository.MarkUsed(ctx, stored.ID, now); err != nil {
+		return err
+	}
+
+	slog.Info("Verifying email", "user_id", stored.UserID)
+	if err := s.UserRepository.MarkEmailVerified(ctx, stored.UserID, now); err != nil {
+		return err
+	}
+	return s.VerifyTokenRepository.InvalidateUser(ctx, stored.UserID, now)
+}
+
+// PurgeExpiredTokens стирает истекшие токены обновления, сброса пароля и подтверждения email и записи denylist истекших токенов доступа
+func (s *AuthService) PurgeExpiredTokens(ctx context.Context, now time.Time) (int64, error) {
+	purged, err := s.RefreshTokenRepository.DeleteExpired(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+	resets, err := s.ResetTokenRepository.DeleteExpired(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+	verifications, err := s.VerifyTokenRepository.DeleteExpired(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+	revoked, err := s.TokenDenylist.DeleteExpired(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+	total := purged + resets + verifications + revoked
+	if total > 0 {
+		slog.Info("Purged expired tokens", "refresh_tokens", purged, "reset_tokens", resets, "verify_tokens", verifications, "revoked_tokens", revoked)
+	}
+	return total, nil
+}
+
+func (s *AuthService) revokeReused(ctx context.Context, stored *models.RefreshToken, now time.Time) error {
+	slog.Warn("Refresh token reuse detected, revoking family", "user_id", stored.UserID, "family_id", stored.FamilyID)
+	if err := s.RefreshTokenRepository.RevokeFamily(ctx, stored.FamilyID, now); err != nil {
+		return err
+	}
+	return ErrRefreshTokenReused
+}
+
+func (s *AuthService) issueTokens(ctx context.Context, existingUser *models.User, familyID string, now time.Time) (*models.TokenPair, error) {
+	accessExpiresAt := now.Add(s.Config.Auth.TokenLifetime)
+	accessToken, err := token.NewJWT(s.Config.Auth.Secret).GenerateToken(token.JwtDate{
+		UserId:        existingUser.ID,
+		Email:         existingUser.Email,
+		EmailVerified: existingUser.EmailVerifiedAt != nil,
+	}, now, s.Config.Auth.TokenLifetime)
+	if err != nil {
+		return nil, fmt.Errorf("sign access token: %w", err)
+	}
+
+	refreshToken, err := newOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	stored, err := s.RefreshTokenRepository.Create(ctx, &models.RefreshToken{
+		UserID:    existingUser.ID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(refreshToken),
+		ExpiresAt: now.Add(s.Config.Auth.RefreshTokenLifetime),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &models.TokenPair{
+		AccessToken:      accessToken,
+		AccessExpiresAt:  accessExpiresAt,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: stored.ExpiresAt,
+	}, nil
+}
+
+// newOpaqueToken генерирует случайный токен, который хранится только в виде хеша
+func newOpaqueToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generate token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func hashToken(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
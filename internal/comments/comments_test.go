@@ -0,0 +1,172 @@
+package comments
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"ToDo/internal/models"
+	"ToDo/internal/user"
+	"ToDo/pkg/di"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockCommentRepository — мок для ICommentRepository
+type MockCommentRepository struct {
+	mock.Mock
+}
+
+func (m *MockCommentRepository) Create(ctx context.Context, comment *models.Comment) (*models.Comment, error) {
+	args := m.Called(ctx, comment)
+	result, _ := args.Get(0).(*models.Comment)
+	return result, args.Error(1)
+}
+
+func (m *MockCommentRepository) Get(ctx context.Context, commentID string) (*models.Comment, error) {
+	args := m.Called(ctx, commentID)
+	result, _ := args.Get(0).(*models.Comment)
+	return result, args.Error(1)
+}
+
+func (m *MockCommentRepository) GetByNote(ctx context.Context, noteID string, limit int, after *models.CommentCursor) ([]models.Comment, error) {
+	args := m.Called(ctx, noteID, limit, after)
+	result, _ := args.Get(0).([]models.Comment)
+	return result, args.Error(1)
+}
+
+func (m *MockCommentRepository) Update(ctx context.Context, comment *models.Comment) (*models.Comment, error) {
+	args := m.Called(ctx, comment)
+	result, _ := args.Get(0).(*models.Comment)
+	return result, args.Error(1)
+}
+
+func (m *MockCommentRepository) Delete(ctx context.Context, commentID string) error {
+	args := m.Called(ctx, commentID)
+	return args.Error(0)
+}
+
+func (m *MockCommentRepository) GetMentions(ctx context.Context, userID string, limit int, before *models.CommentCursor) ([]models.Comment, error) {
+	args := m.Called(ctx, userID, limit, before)
+	result, _ := args.Get(0).([]models.Comment)
+	return result, args.Error(1)
+}
+
+// MockUserRepository — мок для IUserRepository
+type MockUserRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserRepository) Create(ctx context.Context, user *models.User) (*models.User, error) {
+	args := m.Called(ctx, user)
+	result, _ := args.Get(0).(*models.User)
+	return result, args.Error(1)
+}
+
+func (m *MockUserRepository) FindById(ctx context.Context, id string) (*models.User, error) {
+	args := m.Called(ctx, id)
+	result, _ := args.Get(0).(*models.User)
+	return result, args.Error(1)
+}
+
+func (m *MockUserRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	args := m.Called(ctx, email)
+	result, _ := args.Get(0).(*models.User)
+	return result, args.Error(1)
+}
+
+func (m *MockUserRepository) UpdatePassword(ctx context.Context, userID, passwordHash string) error {
+	args := m.Called(ctx, userID, passwordHash)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) MarkEmailVerified(ctx context.Context, userID string, at time.Time) error {
+	args := m.Called(ctx, userID, at)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) Update(ctx context.Context, user *models.User) (*models.User, error) {
+	args := m.Called(ctx, user)
+	result, _ := args.Get(0).(*models.User)
+	return result, args.Error(1)
+}
+
+func (m *MockUserRepository) Delete(ctx context.Context, userID string) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+// WithTransaction не открывает транзакцию, а сразу вызывает fn с самим моком
+func (m *MockUserRepository) WithTransaction(ctx context.Context, fn func(repo di.IUserRepository) error) error {
+	return fn(m)
+}
+
+// stubNoteService открывает доступ к заметке только перечисленным пользователям
+type stubNoteService struct {
+	di.INoteService
+	permissions map[string]string
+}
+
+func (s stubNoteService) GetPermission(ctx context.Context, note *models.Note, userID string) (string, error) {
+	return s.permissions[userID], nil
+}
+
+func TestParseMentions(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{name: "Single mention", content: "@bob@example.com please check", want: []string{"bob@example.com"}},
+		{name: "Several mentions with punctuation", content: "cc @bob@example.com, @ann.lee@mail.example.org.", want: []string{"bob@example.com", "ann.lee@mail.example.org"}},
+		{name: "Duplicates are dropped case-insensitively", content: "@bob@example.com and @Bob@Example.com", want: []string{"bob@example.com"}},
+		{name: "Plain email is not a mention", content: "write to bob@example.com"},
+		{name: "Mention without domain", content: "@bob hello"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ParseMentions(tt.content), "mentions mismatch")
+		})
+	}
+}
+
+// TestCommentService_CreateComment — упоминания сохраняются только для пользователей с доступом к заметке
+func TestCommentService_CreateComment(t *testing.T) {
+	note := &models.Note{ID: "note123", UserID: "owner"}
+
+	t.Run("Mentions are resolved and filtered", func(t *testing.T) {
+		commentRepo := new(MockCommentRepository)
+		userRepo := new(MockUserRepository)
+		userRepo.On("FindByEmail", mock.Anything, "bob@example.com").Return(&models.User{ID: "bob"}, nil)
+		userRepo.On("FindByEmail", mock.Anything, "eve@example.com").Return(&models.User{ID: "eve"}, nil)
+		userRepo.On("FindByEmail", mock.Anything, "ghost@example.com").Return(nil, user.ErrUserNotFound)
+		userRepo.On("FindByEmail", mock.Anything, "owner@example.com").Return(&models.User{ID: "owner"}, nil)
+		commentRepo.On("Create", mock.Anything, mock.MatchedBy(func(comment *models.Comment) bool {
+			return comment.NoteID == "note123" && comment.Content == "@bob@example.com @eve@example.com @ghost@example.com @owner@example.com" &&
+				len(comment.Mentions) == 1 && comment.Mentions[0].UserID == "bob"
+		})).Return(&models.Comment{ID: "c1"}, nil)
+		service := NewCommentService(commentRepo, userRepo, stubNoteService{permissions: map[string]string{
+			"owner": models.PermissionOwner,
+			"bob":   models.PermissionViewer,
+		}})
+
+		comment, err := service.CreateComment(context.Background(), note, &models.Comment{
+			UserID:  "owner",
+			Content: "  @bob@example.com @eve@example.com @ghost@example.com @owner@example.com ",
+		})
+		assert.NoError(t, err, "unexpected error")
+		assert.Equal(t, "c1", comment.ID, "comment mismatch")
+		commentRepo.AssertExpectations(t)
+	})
+
+	t.Run("Empty comment is rejected", func(t *testing.T) {
+		commentRepo := new(MockCommentRepository)
+		service := NewCommentService(commentRepo, new(MockUserRepository), stubNoteService{})
+
+		_, err := service.CreateComment(context.Background(), note, &models.Comment{UserID: "owner", Content: "   "})
+		assert.ErrorIs(t, err, ErrEmptyComment, "expected empty comment error")
+		commentRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,11 @@
+package comments
+
+import "errors"
+
+var (
+	ErrCommentNotFound  = errors.New("comment not found")
+	ErrCreateComment    = errors.New("failed to create comment")
+	ErrEmptyComment     = errors.New("comment content is empty")
+	ErrInvalidCursor    = errors.New("invalid pagination cursor")
+	ErrNotCommentAuthor = errors.New("only the author can change a comment")
+)
@@ -0,0 +1,42 @@
+package comments
+
+import (
+	"ToDo/configs"
+	"ToDo/pkg/di"
+	"ToDo/pkg/middleware"
+	"net/http"
+)
+
+type CommentHandlerDeps struct {
+	Config         *configs.Config
+	TokenDenylist  di.ITokenDenylist
+	CommentService di.ICommentService
+	NoteService    di.INoteService
+}
+
+type CommentHandler struct {
+	Config         *configs.Config
+	CommentService di.ICommentService
+	NoteService    di.INoteService
+}
+
+func NewCommentHandler(router *http.ServeMux, deps *CommentHandlerDeps) {
+	handler := &CommentHandler{
+		Config:         deps.Config,
+		CommentService: deps.CommentService,
+		NoteService:    deps.NoteService,
+	}
+	middlewares := middleware.Chain(
+		middleware.CORS,
+		middleware.Logging,
+		middleware.RateLimiter(deps.Config.RateLimit.MaxRequests, deps.Config.RateLimit.Burst, deps.Config.RateLimit.TTL),
+		middleware.IsAuthenticated(deps.Config, deps.TokenDenylist),
+		middleware.RequireVerifiedEmail(deps.Config),
+	)
+
+	router.Handle("GET /notes/{id}/comments", middlewares(handler.GetComments()))
+	router.Handle("POST /notes/{id}/comments", middlewares(handler.CreateComment()))
+	router.Handle("PATCH /comments/{id}", middlewares(handler.UpdateComment()))
+	router.Handle("DELETE /comments/{id}", middlewares(handler.DeleteComment()))
+	router.Handle("GET /me/mentions", middlewares(handler.GetMentions()))
+}
@@ -0,0 +1,29 @@
+package comments
+
+import "time"
+
+type CreateCommentRequest struct {
+	Content string `json:"content" validate:"required,max=5000"`
+}
+
+type UpdateCommentRequest struct {
+	Content string `json:"content" validate:"required,max=5000"`
+}
+
+type CommentResponse struct {
+	ID         string    `json:"id"`
+	NoteID     string    `json:"note_id"`
+	UserID     string    `json:"user_id"`
+	AuthorName string    `json:"author_name"`
+	Content    string    `json:"content"`
+	Mentions   []string  `json:"mentions"` // ID упомянутых пользователей
+	Edited     bool      `json:"edited"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+type GetCommentsResponse struct {
+	Comments   []CommentResponse `json:"comments"`
+	Limit      int               `json:"limit"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+}
@@ -0,0 +1,131 @@
+package comments
+
+import (
+	"ToDo/internal/models"
+	"ToDo/pkg/idgen"
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CommentRepository struct {
+	db *gorm.DB
+}
+
+func NewCommentRepository(dataBase *gorm.DB) *CommentRepository {
+	return &CommentRepository{
+		db: dataBase,
+	}
+}
+
+// assignMentionIds выдает id упоминаниям, которые еще не сохранены
+func assignMentionIds(comment *models.Comment) error {
+	for i := range comment.Mentions {
+		comment.Mentions[i].ID = idgen.GenerateNanoID()
+		if comment.Mentions[i].ID == "" {
+			return fmt.Errorf("generate id: %w", ErrCreateComment)
+		}
+		comment.Mentions[i].CommentID = comment.ID
+	}
+	return nil
+}
+
+func (r *CommentRepository) Create(ctx context.Context, comment *models.Comment) (*models.Comment, error) {
+	comment.ID = idgen.GenerateNanoID()
+	if comment.ID == "" {
+		return nil, fmt.Errorf("generate id: %w", ErrCreateComment)
+	}
+	if err := assignMentionIds(comment); err != nil {
+		return nil, err
+	}
+
+	if err := r.db.WithContext(ctx).Omit("User").Create(comment).Error; err != nil {
+		return nil, fmt.Errorf("create comment on note %s: %w", comment.NoteID, err)
+	}
+	return r.Get(ctx, comment.ID)
+}
+
+func (r *CommentRepository) Get(ctx context.Context, commentId string) (*models.Comment, error) {
+	var comment models.Comment
+	result := r.db.WithContext(ctx).Preload("User").Preload("Mentions").First(&comment, "id = ?", commentId)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("get comment %s: %w", commentId, ErrCommentNotFound)
+		}
+		return nil, fmt.Errorf("get comment %s: %w", commentId, result.Error)
+	}
+	return &comment, nil
+}
+
+// GetByNote возвращает комментарии заметки от старых к новым, строго после курсора (если он задан)
+func (r *CommentRepository) GetByNote(ctx context.Context, noteId string, limit int, after *models.CommentCursor) ([]models.Comment, error) {
+	query := r.db.WithContext(ctx).Preload("User").Preload("Mentions").Where("note_id = ?", noteId)
+	if after != nil {
+		query = query.Where("(created_at, id) > (?, ?)", after.CreatedAt, after.ID)
+	}
+
+	var comments []models.Comment
+	if err := query.Order("created_at asc, id asc").Limit(limit).Find(&comments).Error; err != nil {
+		return nil, fmt.Errorf("get comments of note %s: %w", noteId, err)
+	}
+	return comments, nil
+}
+
+// Update сохраняет новый текст комментария и заменяет список упоминаний
+func (r *CommentRepository) Update(ctx context.Context, comment *models.Comment) (*models.Comment, error) {
+	if err := assignMentionIds(comment); err != nil {
+		return nil, err
+	}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(comment).Select("content", "updated_at").Omit(clause.Associations).Updates(comment)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrCommentNotFound
+		}
+		if err := tx.Where("comment_id = ?", comment.ID).Delete(&models.CommentMention{}).Error; err != nil {
+			return err
+		}
+		if len(comment.Mentions) == 0 {
+			return nil
+		}
+		return tx.Omit("User").Create(&comment.Mentions).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("update comment %s: %w", comment.ID, err)
+	}
+	return r.Get(ctx, comment.ID)
+}
+
+func (r *CommentRepository) Delete(ctx context.Context, commentId string) error {
+	result := r.db.WithContext(ctx).Delete(&models.Comment{}, "id = ?", commentId)
+	if result.Error != nil {
+		return fmt.Errorf("delete comment %s: %w", commentId, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("delete comment %s: %w", commentId, ErrCommentNotFound)
+	}
+	return nil
+}
+
+// GetMentions возвращает комментарии с упоминанием пользователя от новых к старым, строго после курсора.
+// Комментарии к заметкам в корзине и к заметкам, доступ к которым закрыт, не попадают в выдачу
+func (r *CommentRepository) GetMentions(ctx context.Context, userId string, limit int, before *models.CommentCursor) ([]models.Comment, error) {
+	query := r.db.WithContext(ctx).Preload("User").Preload("Mentions").
+		Joins("JOIN comment_mentions ON comment_mentions.comment_id = comments.id AND comment_mentions.user_id = ?", userId).
+		Joins("JOIN notes ON notes.id = comments.note_id AND notes.deleted_at IS NULL").
+		Where("notes.user_id = ? OR EXISTS (SELECT 1 FROM note_shares WHERE note_shares.note_id = notes.id AND note_shares.user_id = ?)", userId, userId)
+	if before != nil {
+		query = query.Where("(comments.created_at, comments.id) < (?, ?)", before.CreatedAt, before.ID)
+	}
+
+	var comments []models.Comment
+	if err := query.Order("comments.created_at desc, comments.id desc").Limit(limit).Find(&comments).Error; err != nil {
+		return nil, fmt.Errorf("get mentions of user %s: %w", userId, err)
+	}
+	return comments, nil
+}
@@ -0,0 +1,279 @@
+package comments
+
+import (
+	"ToDo/internal/models"
+	"ToDo/internal/notes"
+	"ToDo/pkg/middleware"
+	"ToDo/pkg/req"
+	"ToDo/pkg/res"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+func getUserId(r *http.Request) string {
+	userId, _ := r.Context().Value(middleware.ContextUserIDKey).(string)
+	return userId
+}
+
+// parseLimit читает размер страницы из запроса
+func parseLimit(r *http.Request) int {
+	const (
+		defaultLimit = 20
+		maxLimit     = 100
+	)
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit <= 0 {
+		limit = defaultLimit
+	} else if limit > maxLimit {
+		limit = maxLimit
+	}
+	return limit
+}
+
+type cursorPayload struct {
+	CreatedAt time.Time `json:"t"`
+	ID        string    `json:"id"`
+}
+
+// encodeCursor формирует курсор, указывающий на позицию сразу после comment
+func encodeCursor(comment *models.Comment) string {
+	data, _ := json.Marshal(cursorPayload{CreatedAt: comment.CreatedAt, ID: comment.ID})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// parseCursor читает необязательный параметр cursor
+func parseCursor(r *http.Request) (*models.CommentCursor, error) {
+	raw := r.URL.Query().Get("cursor")
+	if raw == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var payload cursorPayload
+	if err := json.Unmarshal(data, &payload); err != nil || payload.ID == "" || payload.CreatedAt.IsZero() {
+		return nil, ErrInvalidCursor
+	}
+	return &models.CommentCursor{CreatedAt: payload.CreatedAt, ID: payload.ID}, nil
+}
+
+// loadNote загружает заметку и проверяет, что у пользователя есть к ней доступ (владелец или получатель шаринга).
+// При ошибке ответ уже записан и возвращается nil
+func (h *CommentHandler) loadNote(w http.ResponseWriter, r *http.Request, noteId string) *models.Note {
+	userId := getUserId(r)
+	if userId == "" {
+		res.JsonResponse(w, res.ErrorResponse{Error: "unauthorized"}, http.StatusUnauthorized)
+		return nil
+	}
+
+	note, err := h.NoteService.GetNote(r.Context(), noteId)
+	if err != nil {
+		if errors.Is(err, notes.ErrNoteNotFound) {
+			res.JsonResponse(w, res.ErrorResponse{Error: "note not found"}, http.StatusNotFound)
+		} else {
+			res.JsonResponse(w, res.ErrorResponse{Error: "failed to get note by id"}, http.StatusInternalServerError)
+		}
+		return nil
+	}
+	permission, err := h.NoteService.GetPermission(r.Context(), note, userId)
+	if err != nil {
+		res.JsonResponse(w, res.ErrorResponse{Error: "failed to get note by id"}, http.StatusInternalServerError)
+		return nil
+	}
+	if permission == "" {
+		res.JsonResponse(w, res.ErrorResponse{Error: "unauthorized"}, http.StatusUnauthorized)
+		return nil
+	}
+	return note
+}
+
+// loadAuthoredComment загружает комментарий из пути запроса вместе с заметкой и проверяет,
+// что пользователь — автор комментария и не потерял доступ к заметке. При ошибке ответ уже записан
+func (h *CommentHandler) loadAuthoredComment(w http.ResponseWriter, r *http.Request) (*models.Comment, *models.Note) {
+	commentId := r.PathValue("id")
+	if commentId == "" {
+		res.JsonResponse(w, res.ErrorResponse{Error: "comment id is required"}, http.StatusBadRequest)
+		return nil, nil
+	}
+
+	comment, err := h.CommentService.GetComment(r.Context(), commentId)
+	if err != nil {
+		if errors.Is(err, ErrCommentNotFound) {
+			res.JsonResponse(w, res.ErrorResponse{Error: "comment not found"}, http.StatusNotFound)
+		} else {
+			res.JsonResponse(w, res.ErrorResponse{Error: "failed to get comment by id"}, http.StatusInternalServerError)
+		}
+		return nil, nil
+	}
+	note := h.loadNote(w, r, comment.NoteID)
+	if note == nil {
+		return nil, nil
+	}
+	if comment.UserID != getUserId(r) {
+		res.JsonResponse(w, res.ErrorResponse{Error: ErrNotCommentAuthor.Error()}, http.StatusForbidden)
+		return nil, nil
+	}
+	return comment, note
+}
+
+func newCommentResponse(comment *models.Comment) CommentResponse {
+	response := CommentResponse{
+		ID:        comment.ID,
+		NoteID:    comment.NoteID,
+		UserID:    comment.UserID,
+		Content:   comment.Content,
+		Mentions:  make([]string, 0, len(comment.Mentions)),
+		Edited:    comment.UpdatedAt.After(comment.CreatedAt),
+		CreatedAt: comment.CreatedAt,
+		UpdatedAt: comment.UpdatedAt,
+	}
+	if comment.User != nil {
+		response.AuthorName = comment.User.Name
+	}
+	for _, mention := range comment.Mentions {
+		response.Mentions = append(response.Mentions, mention.UserID)
+	}
+	return response
+}
+
+func newGetCommentsResponse(comments []models.Comment, limit int) GetCommentsResponse {
+	response := GetCommentsResponse{
+		Comments: make([]CommentResponse, 0, len(comments)),
+		Limit:    limit,
+	}
+	for i := range comments {
+		response.Comments = append(response.Comments, newCommentResponse(&comments[i]))
+	}
+	// Полная страница — возможно, есть следующая
+	if len(comments) == limit {
+		response.NextCursor = encodeCursor(&comments[len(comments)-1])
+	}
+	return response
+}
+
+func (h *CommentHandler) GetComments() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		note := h.loadNote(w, r, r.PathValue("id"))
+		if note == nil {
+			return
+		}
+		after, err := parseCursor(r)
+		if err != nil {
+			res.JsonResponse(w, res.ErrorResponse{Error: "invalid cursor"}, http.StatusBadRequest)
+			return
+		}
+
+		limit := parseLimit(r)
+		comments, err := h.CommentService.GetComments(r.Context(), note.ID, limit, after)
+		if err != nil {
+			res.JsonResponse(w, res.ErrorResponse{Error: "failed to get comments"}, http.StatusInternalServerError)
+			return
+		}
+		res.JsonResponse(w, newGetCommentsResponse(comments, limit), http.StatusOK)
+	}
+}
+
+// CreateComment добавляет комментарий к заметке; комментировать может любой, у кого есть доступ к заметке
+func (h *CommentHandler) CreateComment() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := req.HandleBody[CreateCommentRequest](&w, r)
+		if err != nil {
+			return
+		}
+		note := h.loadNote(w, r, r.PathValue("id"))
+		if note == nil {
+			return
+		}
+
+		comment, err := h.CommentService.CreateComment(r.Context(), note, &models.Comment{
+			UserID:  getUserId(r),
+			Content: body.Content,
+		})
+		if err != nil {
+			if errors.Is(err, ErrEmptyComment) {
+				res.JsonResponse(w, res.ErrorResponse{Error: ErrEmptyComment.Error()}, http.StatusBadRequest)
+			} else {
+				res.JsonResponse(w, res.ErrorResponse{Error: "failed to create comment"}, http.StatusInternalServerError)
+			}
+			return
+		}
+		res.JsonResponse(w, newCommentResponse(comment), http.StatusCreated)
+	}
+}
+
+func (h *CommentHandler) UpdateComment() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := req.HandleBody[UpdateCommentRequest](&w, r)
+		if err != nil {
+			return
+		}
+		comment, note := h.loadAuthoredComment(w, r)
+		if comment == nil {
+			return
+		}
+		comment.Content = body.Content
+
+		updatedComment, err := h.CommentService.UpdateComment(r.Context(), note, comment)
+		if err != nil {
+			switch {
+			case errors.Is(err, ErrEmptyComment):
+				res.JsonResponse(w, res.ErrorResponse{Error: ErrEmptyComment.Error()}, http.StatusBadRequest)
+			case errors.Is(err, ErrCommentNotFound):
+				res.JsonResponse(w, res.ErrorResponse{Error: "comment not found"}, http.StatusNotFound)
+			default:
+				res.JsonResponse(w, res.ErrorResponse{Error: "failed to update comment"}, http.StatusInternalServerError)
+			}
+			return
+		}
+		res.JsonResponse(w, newCommentResponse(updatedComment), http.StatusOK)
+	}
+}
+
+func (h *CommentHandler) DeleteComment() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		comment, _ := h.loadAuthoredComment(w, r)
+		if comment == nil {
+			return
+		}
+
+		if err := h.CommentService.DeleteComment(r.Context(), comment.ID); err != nil {
+			if errors.Is(err, ErrCommentNotFound) {
+				res.JsonResponse(w, res.ErrorResponse{Error: "comment not found"}, http.StatusNotFound)
+			} else {
+				res.JsonResponse(w, res.ErrorResponse{Error: "failed to delete comment"}, http.StatusInternalServerError)
+			}
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// GetMentions возвращает комментарии, в которых упомянут текущий пользователь, от новых к старым
+func (h *CommentHandler) GetMentions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userId := getUserId(r)
+		if userId == "" {
+			res.JsonResponse(w, res.ErrorResponse{Error: "unauthorized"}, http.StatusUnauthorized)
+			return
+		}
+		before, err := parseCursor(r)
+		if err != nil {
+			res.JsonResponse(w, res.ErrorResponse{Error: "invalid cursor"}, http.StatusBadRequest)
+			return
+		}
+
+		limit := parseLimit(r)
+		comments, err := h.CommentService.GetMentions(r.Context(), userId, limit, before)
+		if err != nil {
+			res.JsonResponse(w, res.ErrorResponse{Error: "failed to get mentions"}, http.StatusInternalServerError)
+			return
+		}
+		res.JsonResponse(w, newGetCommentsResponse(comments, limit), http.StatusOK)
+	}
+}
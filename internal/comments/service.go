@@ -0,0 +1,126 @@
+package comments
+
+import (
+	"ToDo/internal/models"
+	"ToDo/internal/user"
+	"ToDo/pkg/di"
+	"context"
+	"errors"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// maxMentions ограничивает число упоминаний, которые сохраняются из одного комментария
+const maxMentions = 20
+
+// mentionPattern находит упоминания вида @email, не являющиеся частью другого слова или адреса
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w.@])@([\w.%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,})`)
+
+type CommentService struct {
+	commentRepository di.ICommentRepository
+	userRepository    di.IUserRepository
+	noteService       di.INoteService
+}
+
+func NewCommentService(commentRepo di.ICommentRepository, userRepo di.IUserRepository, noteService di.INoteService) *CommentService {
+	return &CommentService{commentRepository: commentRepo, userRepository: userRepo, noteService: noteService}
+}
+
+// ParseMentions возвращает адреса, упомянутые в тексте через @, без повторов и в порядке появления
+func ParseMentions(content string) []string {
+	var emails []string
+	seen := make(map[string]bool)
+	for _, match := range mentionPattern.FindAllStringSubmatch(content, -1) {
+		email := match[1]
+		if key := strings.ToLower(email); !seen[key] {
+			seen[key] = true
+			emails = append(emails, email)
+		}
+	}
+	return emails
+}
+
+// resolveMentions превращает упоминания в тексте в записи о пользователях.
+// Неизвестные адреса, сам автор и пользователи без доступа к заметке пропускаются
+func (s *CommentService) resolveMentions(ctx context.Context, note *models.Note, comment *models.Comment) ([]models.CommentMention, error) {
+	mentions := []models.CommentMention{}
+	seen := make(map[string]bool)
+	for _, email := range ParseMentions(comment.Content) {
+		if len(mentions) == maxMentions {
+			break
+		}
+		mentioned, err := s.userRepository.FindByEmail(ctx, email)
+		if err != nil {
+			if errors.Is(err, user.ErrUserNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		if mentioned.ID == comment.UserID || seen[mentioned.ID] {
+			continue
+		}
+		permission, err := s.noteService.GetPermission(ctx, note, mentioned.ID)
+		if err != nil {
+			return nil, err
+		}
+		if permission == "" {
+			continue
+		}
+		seen[mentioned.ID] = true
+		mentions = append(mentions, models.CommentMention{UserID: mentioned.ID})
+	}
+	return mentions, nil
+}
+
+func (s *CommentService) CreateComment(ctx context.Context, note *models.Note, comment *models.Comment) (*models.Comment, error) {
+	comment.NoteID = note.ID
+	comment.Content = strings.TrimSpace(comment.Content)
+	if comment.Content == "" {
+		return nil, ErrEmptyComment
+	}
+	mentions, err := s.resolveMentions(ctx, note, comment)
+	if err != nil {
+		return nil, err
+	}
+	comment.Mentions = mentions
+
+	slog.Info("Creating comment", "note_id", note.ID, "user_id", comment.UserID, "mentions", len(mentions))
+	return s.commentRepository.Create(ctx, comment)
+}
+
+func (s *CommentService) GetComment(ctx context.Context, commentID string) (*models.Comment, error) {
+	slog.Info("Fetching comment", "comment_id", commentID)
+	return s.commentRepository.Get(ctx, commentID)
+}
+
+func (s *CommentService) GetComments(ctx context.Context, noteID string, limit int, after *models.CommentCursor) ([]models.Comment, error) {
+	slog.Info("Fetching comments", "note_id", noteID, "limit", limit)
+	return s.commentRepository.GetByNote(ctx, noteID, limit, after)
+}
+
+// UpdateComment меняет текст комментария; упоминания пересчитываются по новому тексту
+func (s *CommentService) UpdateComment(ctx context.Context, note *models.Note, comment *models.Comment) (*models.Comment, error) {
+	comment.Content = strings.TrimSpace(comment.Content)
+	if comment.Content == "" {
+		return nil, ErrEmptyComment
+	}
+	mentions, err := s.resolveMentions(ctx, note, comment)
+	if err != nil {
+		return nil, err
+	}
+	comment.Mentions = mentions
+
+	slog.Info("Updating comment", "comment_id", comment.ID, "mentions", len(mentions))
+	return s.commentRepository.Update(ctx, comment)
+}
+
+func (s *CommentService) DeleteComment(ctx context.Context, commentID string) error {
+	slog.Info("Deleting comment", "comment_id", commentID)
+	return s.commentRepository.Delete(ctx, commentID)
+}
+
+func (s *CommentService) GetMentions(ctx context.Context, userID string, limit int, before *models.CommentCursor) ([]models.Comment, error) {
+	slog.Info("Fetching mentions", "user_id", userID, "limit", limit)
+	return s.commentRepository.GetMentions(ctx, userID, limit, before)
+}
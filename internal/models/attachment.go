@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// Attachment — файл, прикрепленный к заметке. Содержимое лежит в хранилище под ключом StorageKey
+type Attachment struct {
+	ID          string    `gorm:"primaryKey" json:"id"`
+	NoteID      string    `gorm:"not null;index" json:"note_id"` // Строки без заметки удаляются фоновой очисткой вместе с содержимым
+	UserID      string    `gorm:"not null;index" json:"user_id"` // Загрузивший пользователь; размер учитывается в его квоте
+	FileName    string    `gorm:"not null;size:255" json:"file_name"`
+	ContentType string    `gorm:"not null;size:100" json:"content_type"` // Определен по содержимому файла
+	Size        int64     `gorm:"not null" json:"size"`
+	StorageKey  string    `gorm:"not null;size:200" json:"-"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
@@ -0,0 +1,23 @@
+package models
+
+const (
+	BatchOpCreate = "create"
+	BatchOpUpdate = "update"
+	BatchOpDelete = "delete"
+)
+
+// NoteBatchOperation — одна операция пакетного запроса над заметками
+type NoteBatchOperation struct {
+	Op      string      // BatchOpCreate, BatchOpUpdate или BatchOpDelete
+	NoteID  string      // Для update и delete
+	Note    *Note       // Новая заметка для create
+	Version int         // Ожидаемая версия заметки для update и delete, 0 — без проверки
+	Apply   func(*Note) // Изменения, которые update вносит в текущую заметку
+}
+
+// NoteBatchResult — результат операции пакетного запроса
+type NoteBatchResult struct {
+	Op   string
+	Note *Note // Созданная или обновленная заметка
+	Err  error
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+type ChecklistItem struct {
+	ID        string    `gorm:"primaryKey" json:"id"`
+	NoteID    string    `gorm:"not null;index" json:"note_id"` // Внешний ключ
+	Text      string    `gorm:"not null;size:500" json:"text"`
+	Done      bool      `gorm:"not null;default:false" json:"done"`
+	Position  int       `gorm:"not null;default:0" json:"position"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
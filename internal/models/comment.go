@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// Comment — комментарий к заметке в обсуждении между ее участниками
+type Comment struct {
+	ID        string           `gorm:"primaryKey" json:"id"`
+	NoteID    string           `gorm:"not null;index:idx_comments_note_created,priority:1" json:"note_id"` // Внешний ключ
+	UserID    string           `gorm:"not null;index" json:"user_id"`                                      // Автор
+	User      *User            `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+	Content   string           `gorm:"type:text;not null" json:"content"`
+	Mentions  []CommentMention `gorm:"foreignKey:CommentID;constraint:OnDelete:CASCADE" json:"-"`
+	CreatedAt time.Time        `gorm:"autoCreateTime;index:idx_comments_note_created,priority:2" json:"created_at"`
+	UpdatedAt time.Time        `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// CommentMention — упоминание пользователя (@email) в комментарии
+type CommentMention struct {
+	ID        string    `gorm:"primaryKey" json:"id"`
+	CommentID string    `gorm:"not null;uniqueIndex:idx_comment_mentions_comment_user" json:"comment_id"`
+	UserID    string    `gorm:"not null;uniqueIndex:idx_comment_mentions_comment_user;index" json:"user_id"`
+	User      *User     `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// CommentCursor — позиция в ленте комментариев: время создания и id последнего комментария страницы
+type CommentCursor struct {
+	CreatedAt time.Time
+	ID        string
+}
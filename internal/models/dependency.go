@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// Dependency — связь «заметка NoteID заблокирована заметкой BlockerID»
+type Dependency struct {
+	NoteID    string    `gorm:"primaryKey" json:"note_id"`
+	BlockerID string    `gorm:"primaryKey;index" json:"blocker_id"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// DependencyGraph — транзитивный граф зависимостей заметки: все блокирующие ее заметки и все, что блокирует она
+type DependencyGraph struct {
+	Nodes []Note
+	Edges []Dependency
+	// Permissions — уровень доступа запросившего граф пользователя к каждой заметке графа; недоступных заметок здесь нет
+	Permissions map[string]string
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// EmailVerificationToken — одноразовый токен подтверждения email из письма. В базе хранится только SHA-256 от токена
+type EmailVerificationToken struct {
+	ID        string     `gorm:"primaryKey" json:"id"`
+	UserID    string     `gorm:"not null;index" json:"user_id"` // Внешний ключ
+	User      *User      `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+	TokenHash string     `gorm:"not null;size:64;uniqueIndex" json:"-"`
+	ExpiresAt time.Time  `gorm:"not null;index" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"` // Токен использован или заменен более новым
+	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
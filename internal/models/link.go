@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// NoteLink — публичная ссылка только для чтения, открывающая заметку без аккаунта
+type NoteLink struct {
+	ID             string     `gorm:"primaryKey" json:"id"`
+	NoteID         string     `gorm:"not null;index" json:"note_id"` // Внешний ключ
+	Token          string     `gorm:"not null;uniqueIndex;size:64" json:"token"`
+	PasswordHash   string     `gorm:"size:200" json:"-"`    // bcrypt; пусто — ссылка без пароля
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"` // nil — бессрочная ссылка
+	AccessCount    int64      `gorm:"not null;default:0" json:"access_count"`
+	LastAccessedAt *time.Time `json:"last_accessed_at,omitempty"`
+	CreatedAt      time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
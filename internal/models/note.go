@@ -1,13 +1,93 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type Note struct {
-	ID        string    `gorm:"primaryKey" json:"id"`
-	Title     string    `gorm:"default:Untitled;size:100" json:"title"`
-	Content   string    `gorm:"type:text;size:10000" json:"content"`
-	Status    string    `gorm:"default:'created';size:20" json:"status"`
-	UserID    string    `gorm:"not null" json:"user_id"` // Внешний ключ
-	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	ID          string          `gorm:"primaryKey" json:"id"`
+	Title       string          `gorm:"default:Untitled;size:100" json:"title"`
+	Content     string          `gorm:"type:text;size:10000" json:"content"`
+	Status      string          `gorm:"default:'created';size:20" json:"status"`
+	Priority    string          `gorm:"default:'normal';size:10;index" json:"priority"`
+	UserID      string          `gorm:"not null" json:"user_id"` // Внешний ключ
+	ProjectID   *string         `gorm:"index" json:"project_id"` // nil — заметка во входящих
+	Tags        []Tag           `gorm:"many2many:note_tags;constraint:OnDelete:CASCADE" json:"tags"`
+	DueAt       *time.Time      `gorm:"index" json:"due_at"` // Срок выполнения (хранится в UTC)
+	Items       []ChecklistItem `gorm:"foreignKey:NoteID;constraint:OnDelete:CASCADE" json:"items,omitempty"`
+	Revisions   []NoteRevision  `gorm:"foreignKey:NoteID;constraint:OnDelete:CASCADE" json:"-"`
+	Shares      []NoteShare     `gorm:"foreignKey:NoteID;constraint:OnDelete:CASCADE" json:"-"`
+	Comments    []Comment       `gorm:"foreignKey:NoteID;constraint:OnDelete:CASCADE" json:"-"`
+	Links       []NoteLink      `gorm:"foreignKey:NoteID;constraint:OnDelete:CASCADE" json:"-"`
+	BlockedBy   []Dependency    `gorm:"foreignKey:NoteID;constraint:OnDelete:CASCADE" json:"-"`
+	Blocks      []Dependency    `gorm:"foreignKey:BlockerID;constraint:OnDelete:CASCADE" json:"-"`
+	Version     int             `gorm:"not null;default:1" json:"version"`                  // Растет при каждом изменении, отдается как ETag
+	RRule       string          `gorm:"size:500" json:"rrule,omitempty"`                    // Правило повторения RFC 5545
+	RRuleTZ     string          `gorm:"size:64" json:"rrule_tz,omitempty"`                  // IANA-зона, в которой разворачивается правило; пусто — UTC
+	SeriesID    *string         `gorm:"index" json:"series_id,omitempty"`                   // ID первой заметки серии повторений
+	SeriesStart *time.Time      `json:"series_start,omitempty"`                             // DTSTART правила повторения (UTC)
+	Position    string          `gorm:"not null;default:'';size:100;index" json:"position"` // Ключ порядка в колонке доски (сравнивается побайтно)
+	CreatedAt   time.Time       `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time       `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt   gorm.DeletedAt  `gorm:"index" json:"deleted_at"` // Мягкое удаление: заметка в корзине
+}
+
+// BoardColumn — колонка доски: заметки одного статуса в ручном порядке
+type BoardColumn struct {
+	Status string `json:"status"`
+	Notes  []Note `json:"notes"`
+}
+
+// NoteFilter описывает параметры выборки заметок пользователя
+type NoteFilter struct {
+	Limit    int
+	Offset   int
+	Tags     []string // Имена тегов для фильтрации
+	TagMatch string   // "any" — хотя бы один тег, "all" — все теги
+
+	DueBefore *time.Time
+	DueAfter  *time.Time
+	Overdue   bool // Только просроченные незавершенные заметки
+
+	ProjectID string // ID проекта, InboxProjectID — только заметки вне проектов
+
+	Sort []SortField // Порядок сортировки, по умолчанию created_at
+
+	After     *NoteCursor // Keyset-пагинация: выдать заметки строго после курсора (Offset игнорируется)
+	SkipTotal bool        // Не считать общее количество заметок
 }
+
+// NoteCursor — позиция в выдаче: значения полей сортировки и id последней заметки страницы
+type NoteCursor struct {
+	Values []string
+	ID     string
+}
+
+// NoteSearchResult — заметка, найденная полнотекстовым поиском
+type NoteSearchResult struct {
+	Note    Note    `json:"note"`
+	Rank    float64 `json:"rank"`
+	Title   string  `json:"title_highlight"` // Заголовок с подсветкой совпадений
+	Snippet string  `json:"snippet"`         // Фрагменты содержимого с подсветкой совпадений
+}
+
+// SortField — поле сортировки списка заметок
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// UpcomingNotes — незавершенные заметки, сгруппированные по сроку выполнения
+type UpcomingNotes struct {
+	Overdue  []Note `json:"overdue"`
+	Today    []Note `json:"today"`
+	Tomorrow []Note `json:"tomorrow"`
+	ThisWeek []Note `json:"this_week"`
+}
+
+const (
+	TagMatchAny = "any"
+	TagMatchAll = "all"
+)
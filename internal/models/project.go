@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+type Project struct {
+	ID        string    `gorm:"primaryKey" json:"id"`
+	Name      string    `gorm:"not null;size:100" json:"name"`
+	Color     string    `gorm:"size:7" json:"color"` // #RRGGBB
+	Archived  bool      `gorm:"not null;default:false" json:"archived"`
+	Position  int       `gorm:"not null;default:0" json:"position"`
+	UserID    string    `gorm:"not null;index" json:"user_id"` // Внешний ключ
+	Notes     []Note    `gorm:"foreignKey:ProjectID;constraint:OnDelete:SET NULL" json:"-"`
+	Workflow  *Workflow `gorm:"foreignKey:ProjectID;constraint:OnDelete:CASCADE" json:"-"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// InboxProjectID — значение фильтра project_id для заметок вне проектов
+const InboxProjectID = "inbox"
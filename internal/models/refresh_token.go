@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// RefreshToken — долгоживущий токен обновления. В базе хранится только SHA-256 от токена.
+// Токены, выпущенные друг за другом при ротации, составляют одно семейство (FamilyID)
+type RefreshToken struct {
+	ID        string     `gorm:"primaryKey" json:"id"`
+	UserID    string     `gorm:"not null;index" json:"user_id"` // Внешний ключ
+	User      *User      `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+	FamilyID  string     `gorm:"not null;index" json:"family_id"` // ID первого токена семейства (входа)
+	TokenHash string     `gorm:"not null;size:64;uniqueIndex" json:"-"`
+	ExpiresAt time.Time  `gorm:"not null;index" json:"expires_at"`
+	RotatedAt *time.Time `json:"rotated_at"` // Токен обменян на новый; повторное предъявление — признак кражи
+	RevokedAt *time.Time `json:"revoked_at"` // Семейство отозвано
+	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TokenPair — выданные клиенту токены доступа и обновления
+type TokenPair struct {
+	AccessToken      string
+	AccessExpiresAt  time.Time
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+}
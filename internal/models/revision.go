@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// NoteRevision — снимок заметки до очередного изменения
+type NoteRevision struct {
+	ID        string    `gorm:"primaryKey" json:"id"`
+	NoteID    string    `gorm:"not null;uniqueIndex:idx_note_revisions_note_revision" json:"note_id"` // Внешний ключ
+	Revision  int       `gorm:"not null;uniqueIndex:idx_note_revisions_note_revision" json:"revision"`
+	Title     string    `gorm:"size:100" json:"title"`
+	Content   string    `gorm:"type:text" json:"content"`
+	Status    string    `gorm:"size:20" json:"status"`
+	Priority  string    `gorm:"size:10" json:"priority"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// RevokedToken — запись denylist отозванных токенов доступа. Хранится, пока не истечет сам токен
+type RevokedToken struct {
+	ID           string     `gorm:"primaryKey" json:"id"`          // jti токена или "user:<id>" для отзыва всех токенов пользователя
+	UserID       string     `gorm:"not null;index" json:"user_id"` // Внешний ключ
+	User         *User      `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+	IssuedBefore *time.Time `json:"issued_before"` // Для "user:<id>": отозваны токены, выпущенные раньше этой секунды
+	ExpiresAt    time.Time  `gorm:"not null;index" json:"expires_at"`
+	CreatedAt    time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+const (
+	PermissionViewer = "viewer" // Чтение заметки
+	PermissionEditor = "editor" // Чтение и изменение содержимого
+	PermissionOwner  = "owner"  // Владелец: удаление, перенос, управление доступом
+)
+
+// NoteShare — доступ другого пользователя к заметке
+type NoteShare struct {
+	ID         string    `gorm:"primaryKey" json:"id"`
+	NoteID     string    `gorm:"not null;uniqueIndex:idx_note_shares_note_user" json:"note_id"` // Внешний ключ
+	UserID     string    `gorm:"not null;uniqueIndex:idx_note_shares_note_user;index" json:"user_id"`
+	User       *User     `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+	Permission string    `gorm:"not null;size:10" json:"permission"` // PermissionViewer или PermissionEditor
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// SharedNote — заметка, к которой пользователю открыт доступ
+type SharedNote struct {
+	Note       Note   `json:"note"`
+	Permission string `json:"permission"`
+}
+
+// permissionRanks упорядочивает уровни доступа
+var permissionRanks = map[string]int{PermissionViewer: 1, PermissionEditor: 2, PermissionOwner: 3}
+
+// PermissionAllows сообщает, достаточно ли уровня доступа granted для действия, требующего required
+func PermissionAllows(granted, required string) bool {
+	return granted != "" && permissionRanks[granted] >= permissionRanks[required]
+}
@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+type Tag struct {
+	ID        string    `gorm:"primaryKey" json:"id"`
+	Name      string    `gorm:"not null;size:50;uniqueIndex:idx_tags_user_name" json:"name"`
+	UserID    string    `gorm:"not null;uniqueIndex:idx_tags_user_name" json:"user_id"` // Внешний ключ
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// Template — заготовка заметки. Заголовок, содержимое и пункты чек-листа могут содержать
+// подстановки вида {{date}}, которые заполняются при создании заметки из шаблона
+type Template struct {
+	ID        string    `gorm:"primaryKey" json:"id"`
+	UserID    string    `gorm:"not null;index" json:"user_id"` // Внешний ключ
+	Name      string    `gorm:"not null;size:100" json:"name"`
+	Title     string    `gorm:"not null;size:100" json:"title"`
+	Content   string    `gorm:"type:text;size:10000" json:"content"`
+	Status    string    `gorm:"size:20" json:"status"`   // Пусто — начальный статус workflow
+	Priority  string    `gorm:"size:10" json:"priority"` // Пусто — normal
+	Tags      []string  `gorm:"type:jsonb;serializer:json" json:"tags"`
+	Items     []string  `gorm:"type:jsonb;serializer:json" json:"items"` // Тексты пунктов чек-листа
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
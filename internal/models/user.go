@@ -3,10 +3,23 @@ package models
 import "time"
 
 type User struct {
-	ID        string    `gorm:"primaryKey" json:"id"`
-	Name      string    `gorm:"not null;size:200" json:"name"`
-	Email     string    `gorm:"unique;not null" json:"email"`
-	Password  string    `gorm:"not null;size:200" json:"password"`
-	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
-	Notes     []Note    `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"notes"`
+	ID              string     `gorm:"primaryKey" json:"id"`
+	Name            string     `gorm:"not null;size:200" json:"name"`
+	Email           string     `gorm:"unique;not null" json:"email"`
+	Password        string     `gorm:"not null;size:200" json:"-"` // bcrypt-хеш, наружу не отдается
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`          // nil, пока адрес не подтвержден по ссылке из письма
+	CreatedAt       time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	Notes           []Note     `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"notes"`
+	Tags            []Tag      `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"tags"`
+	Projects        []Project  `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"projects"`
+	Workflows       []Workflow `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+	Templates       []Template `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// ProfileUpdate — изменения профиля; nil-поля не меняются. Смена email или пароля требует текущего пароля
+type ProfileUpdate struct {
+	Name            *string
+	Email           *string
+	Password        *string
+	CurrentPassword string
 }
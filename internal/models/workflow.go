@@ -0,0 +1,101 @@
+package models
+
+import "time"
+
+// Workflow — набор статусов заметок пользователя или проекта с разрешенными переходами
+type Workflow struct {
+	ID        string           `gorm:"primaryKey" json:"id"`
+	UserID    string           `gorm:"not null;index" json:"user_id"` // Внешний ключ
+	ProjectID *string          `gorm:"uniqueIndex" json:"project_id"` // nil — workflow пользователя по умолчанию
+	Statuses  []WorkflowStatus `gorm:"foreignKey:WorkflowID;constraint:OnDelete:CASCADE" json:"statuses"`
+	CreatedAt time.Time        `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time        `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// WorkflowStatus — статус workflow и статусы, в которые из него можно перейти
+type WorkflowStatus struct {
+	ID         string   `gorm:"primaryKey" json:"-"`
+	WorkflowID string   `gorm:"not null;uniqueIndex:idx_workflow_statuses_name" json:"-"`
+	Name       string   `gorm:"not null;size:20;uniqueIndex:idx_workflow_statuses_name" json:"name"`
+	Position   int      `gorm:"not null;default:0" json:"position"`
+	Next       []string `gorm:"type:jsonb;serializer:json" json:"next"`
+	Terminal   bool     `gorm:"not null;default:false" json:"terminal"`    // Заметка в этом статусе выполнена: не просрочена, не блокирует, запускает повторение
+	InProgress bool     `gorm:"not null;default:false" json:"in_progress"` // Статус начатой заметки: в него переводит отметка пункта чек-листа
+}
+
+// Initial возвращает статус новых заметок — первый статус workflow
+func (w *Workflow) Initial() string {
+	if len(w.Statuses) == 0 {
+		return ""
+	}
+	return w.Statuses[0].Name
+}
+
+// IsTerminal сообщает, считается ли заметка в статусе name выполненной
+func (w *Workflow) IsTerminal(name string) bool {
+	status := w.Status(name)
+	return status != nil && status.Terminal
+}
+
+// Terminal возвращает первый завершающий статус — в него переводит заметку отмеченный чек-лист
+func (w *Workflow) Terminal() string {
+	for _, status := range w.Statuses {
+		if status.Terminal {
+			return status.Name
+		}
+	}
+	return ""
+}
+
+// InProgress возвращает статус начатой заметки; "" — в workflow такого статуса нет
+func (w *Workflow) InProgress() string {
+	for _, status := range w.Statuses {
+		if status.InProgress {
+			return status.Name
+		}
+	}
+	return ""
+}
+
+// Status ищет статус по имени
+func (w *Workflow) Status(name string) *WorkflowStatus {
+	for i := range w.Statuses {
+		if w.Statuses[i].Name == name {
+			return &w.Statuses[i]
+		}
+	}
+	return nil
+}
+
+// CanTransition сообщает, можно ли перевести заметку из статуса from в статус to.
+// Из статуса, которого нет в workflow (например, после смены workflow), можно перейти в любой статус workflow
+func (w *Workflow) CanTransition(from, to string) bool {
+	if from == to {
+		return w.Status(to) != nil
+	}
+	target := w.Status(to)
+	if target == nil {
+		return false
+	}
+	source := w.Status(from)
+	if source == nil {
+		return true
+	}
+	for _, next := range source.Next {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowedNext возвращает статусы, в которые можно перейти из статуса from
+func (w *Workflow) AllowedNext(from string) []string {
+	result := []string{}
+	for _, status := range w.Statuses {
+		if status.Name != from && w.CanTransition(from, status.Name) {
+			result = append(result, status.Name)
+		}
+	}
+	return result
+}
@@ -1,9 +1,74 @@
 package notes
 
-import "errors"
+import (
+	"ToDo/internal/models"
+	"errors"
+	"fmt"
+	"strings"
+)
 
 var (
-	ErrNoteNotFound      = errors.New("note not found")
-	ErrCreateNote        = errors.New("failed to create note") // и другие
-	ErrInvalidNoteStatus = errors.New("invalid note status")
+	ErrNoteNotFound           = errors.New("note not found")
+	ErrCreateNote             = errors.New("failed to create note") // и другие
+	ErrInvalidNoteStatus      = errors.New("invalid note status")
+	ErrInvalidTagMatch        = errors.New("invalid tag match mode")
+	ErrInvalidPriority        = errors.New("invalid note priority")
+	ErrInvalidSort            = errors.New("invalid sort parameter")
+	ErrInvalidCursor          = errors.New("invalid pagination cursor")
+	ErrEmptySearchQuery       = errors.New("empty search query")
+	ErrItemNotFound           = errors.New("checklist item not found")
+	ErrRevisionNotFound       = errors.New("revision not found")
+	ErrVersionConflict        = errors.New("note was modified concurrently")
+	ErrInvalidBatchOp         = errors.New("invalid batch operation")
+	ErrBatchAborted           = errors.New("batch aborted, all operations rolled back")
+	ErrInvalidRRule           = errors.New("invalid recurrence rule")
+	ErrInvalidTransition      = errors.New("status transition is not allowed")
+	ErrInvalidPosition        = errors.New("invalid board position")
+	ErrInvalidNeighbour       = errors.New("invalid neighbour note")
+	ErrShareNotFound          = errors.New("share not found")
+	ErrInvalidPermission      = errors.New("invalid share permission")
+	ErrShareWithOwner         = errors.New("note cannot be shared with its owner")
+	ErrPermissionDenied       = errors.New("insufficient permissions")
+	ErrLinkNotFound           = errors.New("link not found")
+	ErrLinkExpired            = errors.New("link has expired")
+	ErrLinkPasswordRequired   = errors.New("link password required")
+	ErrLinkPasswordInvalid    = errors.New("invalid link password")
+	ErrInvalidLinkExpiry      = errors.New("link expiry must be in the future")
+	ErrDependencyCycle        = errors.New("dependency would create a cycle")
+	ErrDependencyNotFound     = errors.New("dependency not found")
+	ErrInvalidDependency      = errors.New("dependency must link notes of the same owner")
+	ErrNoteBlocked            = errors.New("note is blocked by unfinished notes")
+	ErrRecurrenceWithoutDueAt = errors.New("recurring note requires due date")
 )
+
+// TransitionError — запрещенная workflow смена статуса; Allowed — статусы, допустимые из From
+type TransitionError struct {
+	From    string
+	To      string
+	Allowed []string
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("%s: %s -> %s (allowed: %s)", ErrInvalidTransition, e.From, e.To, strings.Join(e.Allowed, ", "))
+}
+
+func (e *TransitionError) Unwrap() error {
+	return ErrInvalidTransition
+}
+
+// BlockedError — попытка завершить заметку, у которой есть незавершенные блокирующие заметки
+type BlockedError struct {
+	Blockers []models.Note
+}
+
+func (e *BlockedError) Error() string {
+	ids := make([]string, 0, len(e.Blockers))
+	for _, blocker := range e.Blockers {
+		ids = append(ids, blocker.ID)
+	}
+	return fmt.Sprintf("%s: %s", ErrNoteBlocked, strings.Join(ids, ", "))
+}
+
+func (e *BlockedError) Unwrap() error {
+	return ErrNoteBlocked
+}
@@ -0,0 +1,51 @@
+package notes
+
+import (
+	"ToDo/internal/models"
+	"ToDo/pkg/res"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// noteETag — сильный ETag заметки, построенный по ее версии
+func noteETag(note *models.Note) string {
+	return `"` + strconv.Itoa(note.Version) + `"`
+}
+
+// etagMatches проверяет, есть ли etag в списке из заголовка If-Match / If-None-Match.
+// "*" совпадает с любым значением. При слабом сравнении (If-None-Match) W/"..." сравнивается по значению,
+// при сильном (If-Match, RFC 9110) слабый ETag не совпадает ни с чем
+func etagMatches(header, etag string, weak bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if weak {
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+		if candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// checkIfMatch сверяет If-Match с текущей версией заметки. При ошибке ответ уже записан
+func (h *NoteHandler) checkIfMatch(w http.ResponseWriter, r *http.Request, note *models.Note) bool {
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" {
+		if h.Config.Notes.RequireIfMatch {
+			res.JsonResponse(w, res.ErrorResponse{Error: "If-Match header is required"}, http.StatusPreconditionRequired)
+			return false
+		}
+		return true
+	}
+	if !etagMatches(ifMatch, noteETag(note), false) {
+		w.Header().Set("ETag", noteETag(note))
+		res.JsonResponse(w, res.ErrorResponse{Error: ErrVersionConflict.Error()}, http.StatusPreconditionFailed)
+		return false
+	}
+	return true
+}
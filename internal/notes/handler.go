@@ -8,29 +8,75 @@ import (
 )
 
 type NoteHandlerDeps struct {
-	Config      *configs.Config
-	NoteService di.INoteService
+	Config         *configs.Config
+	TokenDenylist  di.ITokenDenylist
+	NoteService    di.INoteService
+	ProjectService di.IProjectService
 }
 type NoteHandler struct {
-	Config      *configs.Config
-	NoteService di.INoteService
+	Config         *configs.Config
+	NoteService    di.INoteService
+	ProjectService di.IProjectService
 }
 
 func NewNoteHandler(router *http.ServeMux, deps *NoteHandlerDeps) {
 	handler := &NoteHandler{
-		Config:      deps.Config,
-		NoteService: deps.NoteService,
+		Config:         deps.Config,
+		NoteService:    deps.NoteService,
+		ProjectService: deps.ProjectService,
 	}
 	middlewares := middleware.Chain(
 		middleware.CORS,
 		middleware.Logging,
 		middleware.RateLimiter(deps.Config.RateLimit.MaxRequests, deps.Config.RateLimit.Burst, deps.Config.RateLimit.TTL),
-		middleware.IsAuthenticated(deps.Config),
+		middleware.IsAuthenticated(deps.Config, deps.TokenDenylist),
+		middleware.RequireVerifiedEmail(deps.Config),
 	)
 
+	// Публичные ссылки открываются без аутентификации
+	publicMiddlewares := middleware.Chain(
+		middleware.CORS,
+		middleware.Logging,
+		middleware.RateLimiter(deps.Config.RateLimit.MaxRequests, deps.Config.RateLimit.Burst, deps.Config.RateLimit.TTL),
+	)
+	router.Handle("GET /public/notes/{token}", publicMiddlewares(handler.GetPublicNote()))
+
 	router.Handle("POST /notes", middlewares(handler.CreateNote()))
 	router.Handle("GET /notes", middlewares(handler.GetAllNotes()))
+	router.Handle("POST /notes/batch", middlewares(handler.BatchNotes()))
+	router.Handle("GET /notes/trash", middlewares(handler.GetTrash()))
+	router.Handle("GET /notes/search", middlewares(handler.SearchNotes()))
+	router.Handle("GET /notes/upcoming", middlewares(handler.GetUpcomingNotes()))
+	router.Handle("GET /notes/shared-with-me", middlewares(handler.GetSharedNotes()))
 	router.Handle("GET /notes/{id}", middlewares(handler.GetNote()))
 	router.Handle("PATCH /notes/{id}", middlewares(handler.UpdateNote()))
 	router.Handle("DELETE /notes/{id}", middlewares(handler.DeleteNote()))
+	router.Handle("POST /notes/{id}/restore", middlewares(handler.RestoreNote()))
+	router.Handle("PUT /notes/{id}/project", middlewares(handler.MoveNoteToProject()))
+	router.Handle("POST /notes/{id}/move", middlewares(handler.MoveNote()))
+	router.Handle("GET /board", middlewares(handler.GetBoard()))
+	router.Handle("GET /projects/{id}/notes", middlewares(handler.GetProjectNotes()))
+
+	router.Handle("GET /notes/{id}/occurrences", middlewares(handler.GetOccurrences()))
+
+	router.Handle("GET /notes/{id}/revisions", middlewares(handler.GetRevisions()))
+	router.Handle("GET /notes/{id}/revisions/{rev}", middlewares(handler.GetRevision()))
+	router.Handle("POST /notes/{id}/revisions/{rev}/restore", middlewares(handler.RestoreRevision()))
+
+	router.Handle("GET /notes/{id}/shares", middlewares(handler.GetNoteShares()))
+	router.Handle("POST /notes/{id}/shares", middlewares(handler.ShareNote()))
+	router.Handle("DELETE /notes/{id}/shares/{userId}", middlewares(handler.RevokeShare()))
+
+	router.Handle("GET /notes/{id}/links", middlewares(handler.GetLinks()))
+	router.Handle("POST /notes/{id}/links", middlewares(handler.CreateLink()))
+	router.Handle("DELETE /notes/{id}/links/{linkId}", middlewares(handler.RevokeLink()))
+
+	router.Handle("POST /notes/{id}/dependencies", middlewares(handler.AddDependency()))
+	router.Handle("DELETE /notes/{id}/dependencies/{blockerId}", middlewares(handler.RemoveDependency()))
+	router.Handle("GET /notes/{id}/graph", middlewares(handler.GetDependencyGraph()))
+
+	router.Handle("GET /notes/{id}/items", middlewares(handler.GetChecklistItems()))
+	router.Handle("POST /notes/{id}/items", middlewares(handler.CreateChecklistItem()))
+	router.Handle("PATCH /notes/{id}/items/{itemId}", middlewares(handler.UpdateChecklistItem()))
+	router.Handle("DELETE /notes/{id}/items/{itemId}", middlewares(handler.DeleteChecklistItem()))
 }
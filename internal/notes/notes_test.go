@@ -2,15 +2,43 @@ package notes
 
 import (
 	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
+	"ToDo/configs"
 	"ToDo/internal/models"
+	"ToDo/internal/user"
+	"ToDo/internal/workflows"
+	"ToDo/pkg/db"
+	"ToDo/pkg/di"
+	"ToDo/pkg/middleware"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
+// stubWorkflowService отдает заданный workflow, по умолчанию — стандартный
+type stubWorkflowService struct {
+	di.IWorkflowService
+	workflow *models.Workflow
+}
+
+func (s stubWorkflowService) GetWorkflow(ctx context.Context, userID string, projectID *string) (*models.Workflow, error) {
+	if s.workflow != nil {
+		return s.workflow, nil
+	}
+	return workflows.Default(userID), nil
+}
+
 // MockNoteRepository — мок для INoteRepository
 type MockNoteRepository struct {
 	mock.Mock
@@ -18,22 +46,48 @@ type MockNoteRepository struct {
 
 func (m *MockNoteRepository) Create(ctx context.Context, note *models.Note) (*models.Note, error) {
 	args := m.Called(ctx, note)
-	return args.Get(0).(*models.Note), args.Error(1)
+	result, _ := args.Get(0).(*models.Note)
+	return result, args.Error(1)
 }
 
-func (m *MockNoteRepository) GetAll(ctx context.Context, userID string, limit, offset int) ([]models.Note, int64, error) {
-	args := m.Called(ctx, userID, limit, offset)
-	return args.Get(0).([]models.Note), args.Get(1).(int64), args.Error(2)
+func (m *MockNoteRepository) GetAll(ctx context.Context, userID string, filter models.NoteFilter) ([]models.Note, int64, error) {
+	args := m.Called(ctx, userID, filter)
+	result, _ := args.Get(0).([]models.Note)
+	return result, args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockNoteRepository) Search(ctx context.Context, userID string, tsQuery string, filter models.NoteFilter) ([]models.NoteSearchResult, int64, error) {
+	args := m.Called(ctx, userID, tsQuery, filter)
+	result, _ := args.Get(0).([]models.NoteSearchResult)
+	return result, args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockNoteRepository) GetDue(ctx context.Context, userID string, before time.Time) ([]models.Note, error) {
+	args := m.Called(ctx, userID, before)
+	result, _ := args.Get(0).([]models.Note)
+	return result, args.Error(1)
 }
 
 func (m *MockNoteRepository) Get(ctx context.Context, noteID string) (*models.Note, error) {
 	args := m.Called(ctx, noteID)
-	return args.Get(0).(*models.Note), args.Error(1)
+	result, _ := args.Get(0).(*models.Note)
+	return result, args.Error(1)
 }
 
 func (m *MockNoteRepository) Update(ctx context.Context, note *models.Note) (*models.Note, error) {
 	args := m.Called(ctx, note)
-	return args.Get(0).(*models.Note), args.Error(1)
+	result, _ := args.Get(0).(*models.Note)
+	return result, args.Error(1)
+}
+
+func (m *MockNoteRepository) UpdateStatus(ctx context.Context, noteID string, status string) error {
+	args := m.Called(ctx, noteID, status)
+	return args.Error(0)
+}
+
+func (m *MockNoteRepository) UpdateProject(ctx context.Context, noteID string, projectID *string) error {
+	args := m.Called(ctx, noteID, projectID)
+	return args.Error(0)
 }
 
 func (m *MockNoteRepository) Delete(ctx context.Context, noteID string) error {
@@ -41,6 +95,223 @@ func (m *MockNoteRepository) Delete(ctx context.Context, noteID string) error {
 	return args.Error(0)
 }
 
+func (m *MockNoteRepository) DeletePermanently(ctx context.Context, noteID string) error {
+	args := m.Called(ctx, noteID)
+	return args.Error(0)
+}
+
+func (m *MockNoteRepository) GetTrash(ctx context.Context, userID string, limit, offset int) ([]models.Note, int64, error) {
+	args := m.Called(ctx, userID, limit, offset)
+	result, _ := args.Get(0).([]models.Note)
+	return result, args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockNoteRepository) GetTrashed(ctx context.Context, noteID string) (*models.Note, error) {
+	args := m.Called(ctx, noteID)
+	result, _ := args.Get(0).(*models.Note)
+	return result, args.Error(1)
+}
+
+func (m *MockNoteRepository) Restore(ctx context.Context, noteID string) error {
+	args := m.Called(ctx, noteID)
+	return args.Error(0)
+}
+
+func (m *MockNoteRepository) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockNoteRepository) GetItems(ctx context.Context, noteID string) ([]models.ChecklistItem, error) {
+	args := m.Called(ctx, noteID)
+	result, _ := args.Get(0).([]models.ChecklistItem)
+	return result, args.Error(1)
+}
+
+func (m *MockNoteRepository) GetItem(ctx context.Context, itemID string) (*models.ChecklistItem, error) {
+	args := m.Called(ctx, itemID)
+	result, _ := args.Get(0).(*models.ChecklistItem)
+	return result, args.Error(1)
+}
+
+func (m *MockNoteRepository) CreateRevision(ctx context.Context, revision *models.NoteRevision, keep int) error {
+	args := m.Called(ctx, revision, keep)
+	return args.Error(0)
+}
+
+func (m *MockNoteRepository) GetRevisions(ctx context.Context, noteID string) ([]models.NoteRevision, error) {
+	args := m.Called(ctx, noteID)
+	result, _ := args.Get(0).([]models.NoteRevision)
+	return result, args.Error(1)
+}
+
+func (m *MockNoteRepository) GetRevision(ctx context.Context, noteID string, revision int) (*models.NoteRevision, error) {
+	args := m.Called(ctx, noteID, revision)
+	result, _ := args.Get(0).(*models.NoteRevision)
+	return result, args.Error(1)
+}
+
+func (m *MockNoteRepository) GetBoard(ctx context.Context, userID string, filter models.NoteFilter) ([]models.Note, error) {
+	args := m.Called(ctx, userID, filter)
+	result, _ := args.Get(0).([]models.Note)
+	return result, args.Error(1)
+}
+
+func (m *MockNoteRepository) NeighbourPosition(ctx context.Context, userID, status, position string, next bool, excludeID string) (string, bool, error) {
+	args := m.Called(ctx, userID, status, position, next, excludeID)
+	return args.String(0), args.Bool(1), args.Error(2)
+}
+
+func (m *MockNoteRepository) SaveShare(ctx context.Context, share *models.NoteShare) (*models.NoteShare, error) {
+	args := m.Called(ctx, share)
+	result, _ := args.Get(0).(*models.NoteShare)
+	return result, args.Error(1)
+}
+
+func (m *MockNoteRepository) GetShares(ctx context.Context, noteID string) ([]models.NoteShare, error) {
+	args := m.Called(ctx, noteID)
+	result, _ := args.Get(0).([]models.NoteShare)
+	return result, args.Error(1)
+}
+
+func (m *MockNoteRepository) GetShare(ctx context.Context, noteID, userID string) (*models.NoteShare, error) {
+	args := m.Called(ctx, noteID, userID)
+	result, _ := args.Get(0).(*models.NoteShare)
+	return result, args.Error(1)
+}
+
+func (m *MockNoteRepository) DeleteShare(ctx context.Context, noteID, userID string) error {
+	args := m.Called(ctx, noteID, userID)
+	return args.Error(0)
+}
+
+func (m *MockNoteRepository) GetSharedWith(ctx context.Context, userID string, limit, offset int) ([]models.SharedNote, int64, error) {
+	args := m.Called(ctx, userID, limit, offset)
+	result, _ := args.Get(0).([]models.SharedNote)
+	return result, args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockNoteRepository) CreateLink(ctx context.Context, link *models.NoteLink) (*models.NoteLink, error) {
+	args := m.Called(ctx, link)
+	result, _ := args.Get(0).(*models.NoteLink)
+	return result, args.Error(1)
+}
+
+func (m *MockNoteRepository) GetLinks(ctx context.Context, noteID string) ([]models.NoteLink, error) {
+	args := m.Called(ctx, noteID)
+	result, _ := args.Get(0).([]models.NoteLink)
+	return result, args.Error(1)
+}
+
+func (m *MockNoteRepository) GetLinkByToken(ctx context.Context, token string) (*models.NoteLink, error) {
+	args := m.Called(ctx, token)
+	result, _ := args.Get(0).(*models.NoteLink)
+	return result, args.Error(1)
+}
+
+func (m *MockNoteRepository) DeleteLink(ctx context.Context, noteID, linkID string) error {
+	args := m.Called(ctx, noteID, linkID)
+	return args.Error(0)
+}
+
+func (m *MockNoteRepository) RecordLinkAccess(ctx context.Context, linkID string, at time.Time) error {
+	args := m.Called(ctx, linkID, at)
+	return args.Error(0)
+}
+
+func (m *MockNoteRepository) AddDependency(ctx context.Context, userID, noteID, blockerID string) error {
+	args := m.Called(ctx, userID, noteID, blockerID)
+	return args.Error(0)
+}
+
+func (m *MockNoteRepository) DeleteDependency(ctx context.Context, noteID, blockerID string) error {
+	args := m.Called(ctx, noteID, blockerID)
+	return args.Error(0)
+}
+
+func (m *MockNoteRepository) GetOpenBlockers(ctx context.Context, noteID string) ([]models.Note, error) {
+	args := m.Called(ctx, noteID)
+	result, _ := args.Get(0).([]models.Note)
+	return result, args.Error(1)
+}
+
+func (m *MockNoteRepository) GetDependencyGraph(ctx context.Context, noteID, userID string) (*models.DependencyGraph, error) {
+	args := m.Called(ctx, noteID, userID)
+	result, _ := args.Get(0).(*models.DependencyGraph)
+	return result, args.Error(1)
+}
+
+// MockUserRepository - мок для di.IUserRepository
+type MockUserRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserRepository) Create(ctx context.Context, user *models.User) (*models.User, error) {
+	args := m.Called(ctx, user)
+	result, _ := args.Get(0).(*models.User)
+	return result, args.Error(1)
+}
+
+func (m *MockUserRepository) FindById(ctx context.Context, id string) (*models.User, error) {
+	args := m.Called(ctx, id)
+	result, _ := args.Get(0).(*models.User)
+	return result, args.Error(1)
+}
+
+func (m *MockUserRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	args := m.Called(ctx, email)
+	result, _ := args.Get(0).(*models.User)
+	return result, args.Error(1)
+}
+
+func (m *MockUserRepository) UpdatePassword(ctx context.Context, userID, passwordHash string) error {
+	args := m.Called(ctx, userID, passwordHash)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) MarkEmailVerified(ctx context.Context, userID string, at time.Time) error {
+	args := m.Called(ctx, userID, at)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) Update(ctx context.Context, user *models.User) (*models.User, error) {
+	args := m.Called(ctx, user)
+	result, _ := args.Get(0).(*models.User)
+	return result, args.Error(1)
+}
+
+func (m *MockUserRepository) Delete(ctx context.Context, userID string) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+// WithTransaction не открывает транзакцию, а сразу вызывает fn с самим моком
+func (m *MockUserRepository) WithTransaction(ctx context.Context, fn func(repo di.IUserRepository) error) error {
+	return fn(m)
+}
+
+// WithTransaction не открывает транзакцию, а сразу вызывает fn с самим моком
+func (m *MockNoteRepository) WithTransaction(ctx context.Context, fn func(repo di.INoteRepository) error) error {
+	return fn(m)
+}
+
+func (m *MockNoteRepository) CreateItem(ctx context.Context, item *models.ChecklistItem) (*models.ChecklistItem, error) {
+	args := m.Called(ctx, item)
+	result, _ := args.Get(0).(*models.ChecklistItem)
+	return result, args.Error(1)
+}
+
+func (m *MockNoteRepository) UpdateItem(ctx context.Context, item *models.ChecklistItem) (*models.ChecklistItem, error) {
+	args := m.Called(ctx, item)
+	result, _ := args.Get(0).(*models.ChecklistItem)
+	return result, args.Error(1)
+}
+
+func (m *MockNoteRepository) DeleteItem(ctx context.Context, itemID string) error {
+	args := m.Called(ctx, itemID)
+	return args.Error(0)
+}
+
 func TestNoteService_CreateNote(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -97,6 +368,27 @@ func TestNoteService_CreateNote(t *testing.T) {
 			wantErr:  false,
 			wantNote: &models.Note{ID: "note123", Title: "Test Note", Content: "Test content", Status: "in_progress", UserID: "user123"},
 		},
+		{
+			name: "Tags are normalized and deduplicated",
+			note: &models.Note{
+				Title:  "Test Note",
+				UserID: "user123",
+				Tags:   []models.Tag{{Name: " Backend "}, {Name: "backend"}, {Name: ""}, {Name: "URGENT"}},
+			},
+			mockSetup: func(m *MockNoteRepository) {
+				m.On("Create", mock.Anything, mock.MatchedBy(func(note *models.Note) bool {
+					return len(note.Tags) == 2 && note.Tags[0].Name == "backend" && note.Tags[1].Name == "urgent"
+				})).Return(&models.Note{
+					ID:     "note123",
+					Title:  "Test Note",
+					Status: "created",
+					UserID: "user123",
+					Tags:   []models.Tag{{ID: "tag1", Name: "backend"}, {ID: "tag2", Name: "urgent"}},
+				}, nil)
+			},
+			wantErr:  false,
+			wantNote: &models.Note{ID: "note123", Title: "Test Note", Status: "created", UserID: "user123"},
+		},
 		{
 			name: "Invalid status returns error",
 			note: &models.Note{
@@ -109,6 +401,17 @@ func TestNoteService_CreateNote(t *testing.T) {
 			wantErr:   true,
 			err:       ErrInvalidNoteStatus,
 		},
+		{
+			name: "Invalid priority returns error",
+			note: &models.Note{
+				Title:    "Test Note",
+				Priority: "someday",
+				UserID:   "user123",
+			},
+			mockSetup: func(m *MockNoteRepository) {},
+			wantErr:   true,
+			err:       ErrInvalidPriority,
+		},
 		{
 			name: "Repository error on create",
 			note: &models.Note{
@@ -131,7 +434,7 @@ func TestNoteService_CreateNote(t *testing.T) {
 			tt.mockSetup(mockRepo)
 
 			// Создаем сервис с мок-репозиторием
-			service := NewNoteService(mockRepo)
+			service := NewNoteService(mockRepo, nil, stubWorkflowService{}, &configs.Config{})
 
 			// Вызываем метод CreateNote
 			ctx := context.Background()
@@ -162,8 +465,7 @@ func TestNoteService_GetAllNotes(t *testing.T) {
 	tests := []struct {
 		name      string
 		userID    string
-		limit     int
-		offset    int
+		filter    models.NoteFilter
 		mockSetup func(m *MockNoteRepository)
 		wantNotes []models.Note
 		wantCount int64
@@ -173,10 +475,9 @@ func TestNoteService_GetAllNotes(t *testing.T) {
 		{
 			name:   "Successful fetch of notes",
 			userID: "user123",
-			limit:  10,
-			offset: 0,
+			filter: models.NoteFilter{Limit: 10, Offset: 0},
 			mockSetup: func(m *MockNoteRepository) {
-				m.On("GetAll", mock.Anything, "user123", 10, 0).Return([]models.Note{
+				m.On("GetAll", mock.Anything, "user123", models.NoteFilter{Limit: 10, Offset: 0, Tags: []string{}, TagMatch: models.TagMatchAny}).Return([]models.Note{
 					{ID: "note1", UserID: "user123", Title: "Note 1"},
 					{ID: "note2", UserID: "user123", Title: "Note 2"},
 				}, int64(2), nil)
@@ -188,13 +489,37 @@ func TestNoteService_GetAllNotes(t *testing.T) {
 			wantCount: 2,
 			wantErr:   false,
 		},
+		{
+			name:   "Filter by tags with all semantics",
+			userID: "user123",
+			filter: models.NoteFilter{Limit: 10, Tags: []string{"Backend", "urgent", "backend"}, TagMatch: models.TagMatchAll},
+			mockSetup: func(m *MockNoteRepository) {
+				m.On("GetAll", mock.Anything, "user123", models.NoteFilter{Limit: 10, Tags: []string{"backend", "urgent"}, TagMatch: models.TagMatchAll}).Return([]models.Note{
+					{ID: "note1", UserID: "user123", Title: "Note 1"},
+				}, int64(1), nil)
+			},
+			wantNotes: []models.Note{
+				{ID: "note1", UserID: "user123", Title: "Note 1"},
+			},
+			wantCount: 1,
+			wantErr:   false,
+		},
+		{
+			name:      "Invalid tag match mode",
+			userID:    "user123",
+			filter:    models.NoteFilter{Limit: 10, Tags: []string{"backend"}, TagMatch: "some"},
+			mockSetup: func(m *MockNoteRepository) {},
+			wantNotes: nil,
+			wantCount: 0,
+			wantErr:   true,
+			err:       ErrInvalidTagMatch,
+		},
 		{
 			name:   "Repository error on fetch",
 			userID: "user123",
-			limit:  10,
-			offset: 0,
+			filter: models.NoteFilter{Limit: 10, Offset: 0},
 			mockSetup: func(m *MockNoteRepository) {
-				m.On("GetAll", mock.Anything, "user123", 10, 0).Return(nil, int64(0), assert.AnError)
+				m.On("GetAll", mock.Anything, "user123", mock.Anything).Return(nil, int64(0), assert.AnError)
 			},
 			wantNotes: nil,
 			wantCount: 0,
@@ -210,11 +535,11 @@ func TestNoteService_GetAllNotes(t *testing.T) {
 			tt.mockSetup(mockRepo)
 
 			// Создаем сервис с мок-репозиторием
-			service := NewNoteService(mockRepo)
+			service := NewNoteService(mockRepo, nil, stubWorkflowService{}, &configs.Config{})
 
 			// Вызываем метод GetAllNotes
 			ctx := context.Background()
-			notes, count, err := service.GetAllNotes(ctx, tt.userID, tt.limit, tt.offset)
+			notes, count, err := service.GetAllNotes(ctx, tt.userID, tt.filter)
 
 			// Проверяем ошибку
 			if tt.wantErr {
@@ -234,6 +559,146 @@ func TestNoteService_GetAllNotes(t *testing.T) {
 	}
 }
 
+// TestParseSort — проверка белого списка полей сортировки
+func TestParseSort(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    []models.SortField
+		wantErr bool
+	}{
+		{name: "Empty uses default", raw: "", want: []models.SortField{{Field: "created_at"}}},
+		{
+			name: "Multiple fields with directions",
+			raw:  "-priority, due_at,title",
+			want: []models.SortField{{Field: "priority", Desc: true}, {Field: "due_at"}, {Field: "title"}},
+		},
+		{name: "Unknown column", raw: "password", wantErr: true},
+		{name: "SQL injection attempt", raw: "title; DROP TABLE notes", wantErr: true},
+		{name: "Duplicate field", raw: "title,-title", wantErr: true},
+		{name: "Empty segment", raw: "title,", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSort(tt.raw)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidSort, "expected error")
+				return
+			}
+			assert.NoError(t, err, "unexpected error")
+			assert.Equal(t, tt.want, got, "sort fields mismatch")
+		})
+	}
+
+	assert.Equal(t, "notes.created_at asc, notes.id asc", orderClause(nil), "default order mismatch")
+}
+
+// TestBuildTsQuery — разбор поисковых запросов в синтаксис tsquery
+func TestBuildTsQuery(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{name: "Single word", raw: "Release", want: "release"},
+		{name: "All words required", raw: "deploy backend", want: "deploy & backend"},
+		{name: "Phrase", raw: `"release checklist" today`, want: "(release <-> checklist) & today"},
+		{name: "Prefix", raw: "deplo*", want: "deplo:*"},
+		{name: "Negation", raw: "backend -frontend", want: "backend & !frontend"},
+		{name: "Operators are stripped", raw: "a&b | !c:* (d)", want: "(a <-> b) & c:* & d"},
+		{name: "Cyrillic", raw: "Задача*", want: "задача:*"},
+		{name: "Only punctuation", raw: `&| "" !`, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, buildTsQuery(tt.raw), "tsquery mismatch")
+		})
+	}
+}
+
+// TestNoteService_SearchNotes — тесты для SearchNotes
+func TestNoteService_SearchNotes(t *testing.T) {
+	mockRepo := new(MockNoteRepository)
+	mockRepo.On("Search", mock.Anything, "user123", "release & check:*", models.NoteFilter{Limit: 10, Tags: []string{}}).
+		Return([]models.NoteSearchResult{{Note: models.Note{ID: "note1"}, Rank: 0.5}}, int64(1), nil)
+	service := NewNoteService(mockRepo, nil, stubWorkflowService{}, &configs.Config{})
+
+	results, count, err := service.SearchNotes(context.Background(), "user123", "release check*", models.NoteFilter{Limit: 10})
+	assert.NoError(t, err, "unexpected error")
+	assert.Equal(t, int64(1), count, "count mismatch")
+	assert.Len(t, results, 1, "results mismatch")
+
+	_, _, err = service.SearchNotes(context.Background(), "user123", "  ", models.NoteFilter{Limit: 10})
+	assert.ErrorIs(t, err, ErrEmptySearchQuery, "expected error")
+	mockRepo.AssertExpectations(t)
+}
+
+// TestCursor — курсор кодирует позицию и привязан к сортировке
+func TestCursor(t *testing.T) {
+	fields := []models.SortField{{Field: "priority", Desc: true}, {Field: "due_at"}}
+	note := &models.Note{ID: "note123", Priority: "high"}
+
+	raw := encodeCursor(fields, note)
+	cursor, err := decodeCursor(raw, fields)
+	assert.NoError(t, err, "unexpected error")
+	assert.Equal(t, &models.NoteCursor{Values: []string{"2", "infinity"}, ID: "note123"}, cursor, "cursor mismatch")
+
+	_, err = decodeCursor(raw, []models.SortField{{Field: "title"}})
+	assert.ErrorIs(t, err, ErrInvalidCursor, "cursor must be bound to sort")
+	_, err = decodeCursor("not-a-cursor", fields)
+	assert.ErrorIs(t, err, ErrInvalidCursor, "garbage must be rejected")
+
+	condition, args := keysetCondition(fields, cursor)
+	priority := sortableColumns["priority"].expr
+	dueAt := sortableColumns["due_at"].expr
+	assert.Equal(t, "(("+priority+" < ?::integer) OR ("+priority+" = ?::integer AND "+dueAt+" > ?::timestamptz) OR ("+
+		priority+" = ?::integer AND "+dueAt+" = ?::timestamptz AND notes.id > ?))", condition, "condition mismatch")
+	assert.Equal(t, []any{"2", "2", "infinity", "2", "infinity", "note123"}, args, "args mismatch")
+}
+
+// TestNoteService_GetUpcomingNotes — тесты группировки заметок по срокам
+func TestNoteService_GetUpcomingNotes(t *testing.T) {
+	location := time.FixedZone("UTC+3", 3*60*60)
+	// Среда, 15 января 2025, 10:00 по UTC+3
+	now := time.Date(2025, time.January, 15, 10, 0, 0, 0, location)
+	due := func(day, hour int) *time.Time {
+		d := time.Date(2025, time.January, day, hour, 0, 0, 0, location).UTC()
+		return &d
+	}
+
+	mockRepo := new(MockNoteRepository)
+	// Конец недели — начало понедельника 20 января
+	weekEnd := time.Date(2025, time.January, 20, 0, 0, 0, 0, location)
+	mockRepo.On("GetDue", mock.Anything, "user123", mock.MatchedBy(func(before time.Time) bool {
+		return before.Equal(weekEnd)
+	})).Return([]models.Note{
+		{ID: "overdue", DueAt: due(14, 23)},
+		{ID: "today-early", DueAt: due(15, 0)},
+		{ID: "today-late", DueAt: due(15, 23)},
+		{ID: "tomorrow", DueAt: due(16, 12)},
+		{ID: "sunday", DueAt: due(19, 23)},
+	}, nil)
+
+	service := NewNoteService(mockRepo, nil, stubWorkflowService{}, &configs.Config{})
+	upcoming, err := service.GetUpcomingNotes(context.Background(), "user123", now)
+
+	assert.NoError(t, err, "unexpected error")
+	ids := func(notes []models.Note) []string {
+		result := []string{}
+		for _, note := range notes {
+			result = append(result, note.ID)
+		}
+		return result
+	}
+	assert.Equal(t, []string{"overdue"}, ids(upcoming.Overdue), "overdue bucket mismatch")
+	assert.Equal(t, []string{"today-early", "today-late"}, ids(upcoming.Today), "today bucket mismatch")
+	assert.Equal(t, []string{"tomorrow"}, ids(upcoming.Tomorrow), "tomorrow bucket mismatch")
+	assert.Equal(t, []string{"sunday"}, ids(upcoming.ThisWeek), "this week bucket mismatch")
+	mockRepo.AssertExpectations(t)
+}
+
 // TestNoteService_GetNote — тесты для GetNote
 func TestNoteService_GetNote(t *testing.T) {
 	tests := []struct {
@@ -276,7 +741,7 @@ func TestNoteService_GetNote(t *testing.T) {
 			tt.mockSetup(mockRepo)
 
 			// Создаем сервис с мок-репозиторием
-			service := NewNoteService(mockRepo)
+			service := NewNoteService(mockRepo, nil, stubWorkflowService{}, &configs.Config{})
 
 			// Вызываем метод GetNote
 			ctx := context.Background()
@@ -317,6 +782,16 @@ func TestNoteService_UpdateNote(t *testing.T) {
 				UserID: "user123",
 			},
 			mockSetup: func(m *MockNoteRepository) {
+				m.On("Get", mock.Anything, "note123").Return(&models.Note{
+					ID:     "note123",
+					Title:  "Old Note",
+					Status: "created",
+					UserID: "user123",
+				}, nil)
+				m.On("GetOpenBlockers", mock.Anything, "note123").Return([]models.Note{}, nil)
+				m.On("CreateRevision", mock.Anything, mock.MatchedBy(func(rev *models.NoteRevision) bool {
+					return rev.NoteID == "note123" && rev.Title == "Old Note" && rev.Status == "created"
+				}), 0).Return(nil)
 				m.On("Update", mock.Anything, mock.MatchedBy(func(note *models.Note) bool {
 					return note.ID == "note123" && note.Status == "done"
 				})).Return(&models.Note{
@@ -339,9 +814,11 @@ func TestNoteService_UpdateNote(t *testing.T) {
 				Status: "invalid",
 				UserID: "user123",
 			},
-			mockSetup: func(m *MockNoteRepository) {},
-			wantErr:   true,
-			err:       ErrInvalidNoteStatus,
+			mockSetup: func(m *MockNoteRepository) {
+				m.On("Get", mock.Anything, "note123").Return(&models.Note{ID: "note123", Status: "created", UserID: "user123"}, nil)
+			},
+			wantErr: true,
+			err:     ErrInvalidNoteStatus,
 		},
 		{
 			name: "Repository error on update",
@@ -351,11 +828,43 @@ func TestNoteService_UpdateNote(t *testing.T) {
 				UserID: "user123",
 			},
 			mockSetup: func(m *MockNoteRepository) {
+				m.On("Get", mock.Anything, "note123").Return(&models.Note{ID: "note123", UserID: "user123"}, nil)
 				m.On("Update", mock.Anything, mock.Anything).Return(nil, assert.AnError)
 			},
 			wantErr: true,
 			err:     assert.AnError,
 		},
+		{
+			name: "Concurrent modification returns version conflict",
+			note: &models.Note{
+				ID:      "note123",
+				Title:   "Updated Note",
+				UserID:  "user123",
+				Version: 3,
+			},
+			mockSetup: func(m *MockNoteRepository) {
+				m.On("Get", mock.Anything, "note123").Return(&models.Note{ID: "note123", UserID: "user123", Version: 4}, nil)
+				m.On("Update", mock.Anything, mock.MatchedBy(func(note *models.Note) bool {
+					return note.Version == 3
+				})).Return(nil, ErrVersionConflict)
+			},
+			wantErr: true,
+			err:     ErrVersionConflict,
+		},
+		{
+			name: "Completing a blocked note returns blocked error",
+			note: &models.Note{
+				ID:     "note123",
+				Status: "done",
+				UserID: "user123",
+			},
+			mockSetup: func(m *MockNoteRepository) {
+				m.On("Get", mock.Anything, "note123").Return(&models.Note{ID: "note123", Status: "in_progress", UserID: "user123"}, nil)
+				m.On("GetOpenBlockers", mock.Anything, "note123").Return([]models.Note{{ID: "blocker", Status: "created"}}, nil)
+			},
+			wantErr: true,
+			err:     ErrNoteBlocked,
+		},
 	}
 
 	for _, tt := range tests {
@@ -365,11 +874,11 @@ func TestNoteService_UpdateNote(t *testing.T) {
 			tt.mockSetup(mockRepo)
 
 			// Создаем сервис с мок-репозиторием
-			service := NewNoteService(mockRepo)
+			service := NewNoteService(mockRepo, nil, stubWorkflowService{}, &configs.Config{})
 
 			// Вызываем метод UpdateNote
 			ctx := context.Background()
-			gotNote, err := service.UpdateNote(ctx, tt.note)
+			gotNote, err := service.UpdateNote(ctx, tt.note, false)
 
 			// Проверяем ошибку
 			if tt.wantErr {
@@ -425,7 +934,7 @@ func TestNoteService_DeleteNote(t *testing.T) {
 			tt.mockSetup(mockRepo)
 
 			// Создаем сервис с мок-репозиторием
-			service := NewNoteService(mockRepo)
+			service := NewNoteService(mockRepo, nil, stubWorkflowService{}, &configs.Config{})
 
 			// Вызываем метод DeleteNote
 			ctx := context.Background()
@@ -443,3 +952,867 @@ func TestNoteService_DeleteNote(t *testing.T) {
 		})
 	}
 }
+
+// TestChecklistStatus — пересчет статуса заметки по чек-листу
+func TestChecklistStatus(t *testing.T) {
+	none := []models.ChecklistItem{{Done: false}, {Done: false}}
+	some := []models.ChecklistItem{{Done: true}, {Done: false}}
+	all := []models.ChecklistItem{{Done: true}, {Done: true}}
+
+	custom := &models.Workflow{Statuses: []models.WorkflowStatus{
+		{Name: "todo", Position: 0},
+		{Name: "doing", Position: 1, InProgress: true},
+		{Name: "shipped", Position: 2, Terminal: true},
+	}}
+
+	tests := []struct {
+		name     string
+		workflow *models.Workflow
+		current  string
+		items    []models.ChecklistItem
+		autoDone bool
+		want     string
+	}{
+		{name: "No items keeps status", current: "created", items: nil, autoDone: true, want: "created"},
+		{name: "Nothing checked keeps status", current: "created", items: none, autoDone: true, want: "created"},
+		{name: "First checked item starts work", current: "created", items: some, autoDone: false, want: "in_progress"},
+		{name: "All checked without auto done", current: "in_progress", items: all, autoDone: false, want: "in_progress"},
+		{name: "All checked with auto done", current: "in_progress", items: all, autoDone: true, want: "done"},
+		{name: "Unchecked item reopens done note", current: "done", items: some, autoDone: true, want: "in_progress"},
+		{name: "Manual done kept without auto done", current: "done", items: some, autoDone: false, want: "done"},
+		{name: "Custom workflow uses markers", workflow: custom, current: "todo", items: some, autoDone: true, want: "doing"},
+		{name: "Custom workflow completes to terminal", workflow: custom, current: "doing", items: all, autoDone: true, want: "shipped"},
+		{name: "Custom workflow reopens terminal note", workflow: custom, current: "shipped", items: some, autoDone: true, want: "doing"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workflow := tt.workflow
+			if workflow == nil {
+				workflow = workflows.Default("user123")
+			}
+			assert.Equal(t, tt.want, checklistStatus(workflow, tt.current, tt.items, tt.autoDone), "status mismatch")
+		})
+	}
+}
+
+// TestNoteService_UpdateChecklistItem — отметка последнего пункта завершает заметку
+func TestNoteService_UpdateChecklistItem(t *testing.T) {
+	note := &models.Note{ID: "note123", Status: "in_progress", UserID: "user123"}
+	item := &models.ChecklistItem{ID: "item2", NoteID: "note123", Done: true}
+
+	mockRepo := new(MockNoteRepository)
+	mockRepo.On("UpdateItem", mock.Anything, item).Return(item, nil)
+	mockRepo.On("GetItems", mock.Anything, "note123").Return([]models.ChecklistItem{
+		{ID: "item1", NoteID: "note123", Done: true},
+		*item,
+	}, nil)
+	mockRepo.On("GetOpenBlockers", mock.Anything, "note123").Return([]models.Note{}, nil)
+	mockRepo.On("UpdateStatus", mock.Anything, "note123", "done").Return(nil)
+
+	cfg := &configs.Config{}
+	cfg.Notes.ChecklistAutoDone = true
+	service := NewNoteService(mockRepo, nil, stubWorkflowService{}, cfg)
+
+	updatedItem, status, err := service.UpdateChecklistItem(context.Background(), note, item)
+	assert.NoError(t, err, "unexpected error")
+	assert.Equal(t, item, updatedItem, "item mismatch")
+	assert.Equal(t, "done", status, "status mismatch")
+	assert.Equal(t, "done", note.Status, "note status should be updated")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestETagMatches(t *testing.T) {
+	etag := noteETag(&models.Note{Version: 7})
+	assert.Equal(t, `"7"`, etag, "etag mismatch")
+	assert.True(t, etagMatches(`"7"`, etag, false), "exact etag should match")
+	assert.True(t, etagMatches(`"5", W/"7"`, etag, true), "weak etag in list should match weakly")
+	assert.False(t, etagMatches(`"5", W/"7"`, etag, false), "weak etag should not match strongly")
+	assert.True(t, etagMatches("*", etag, false), "wildcard should match")
+	assert.False(t, etagMatches(`"6"`, etag, true), "stale etag should not match")
+	assert.False(t, etagMatches(`7`, etag, true), "unquoted etag should not match")
+}
+
+// TestNoteService_RestoreNote — восстановление заметки из корзины
+func TestNoteService_RestoreNote(t *testing.T) {
+	mockRepo := new(MockNoteRepository)
+	mockRepo.On("Restore", mock.Anything, "note123").Return(nil)
+	mockRepo.On("Get", mock.Anything, "note123").Return(&models.Note{ID: "note123", UserID: "user123"}, nil)
+	service := NewNoteService(mockRepo, nil, stubWorkflowService{}, &configs.Config{})
+
+	note, err := service.RestoreNote(context.Background(), "note123")
+	assert.NoError(t, err, "unexpected error")
+	assert.Equal(t, "note123", note.ID, "note ID mismatch")
+
+	mockRepo.On("Restore", mock.Anything, "missing").Return(ErrNoteNotFound)
+	_, err = service.RestoreNote(context.Background(), "missing")
+	assert.ErrorIs(t, err, ErrNoteNotFound, "expected error")
+	mockRepo.AssertExpectations(t)
+}
+
+// TestNoteService_PurgeTrash — очистка корзины учитывает срок хранения
+func TestNoteService_PurgeTrash(t *testing.T) {
+	now := time.Date(2025, time.March, 31, 12, 0, 0, 0, time.UTC)
+	cfg := &configs.Config{}
+	cfg.Notes.TrashRetention = 30 * 24 * time.Hour
+
+	mockRepo := new(MockNoteRepository)
+	mockRepo.On("PurgeDeleted", mock.Anything, time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC)).Return(int64(3), nil)
+	service := NewNoteService(mockRepo, nil, stubWorkflowService{}, cfg)
+
+	purged, err := service.PurgeTrash(context.Background(), now)
+	assert.NoError(t, err, "unexpected error")
+	assert.Equal(t, int64(3), purged, "purged count mismatch")
+	mockRepo.AssertExpectations(t)
+}
+
+// TestNoteService_RestoreRevision — откат применяет ревизию и сохраняет текущее состояние новой ревизией
+func TestNoteService_RestoreRevision(t *testing.T) {
+	cfg := &configs.Config{}
+	cfg.Notes.MaxRevisions = 10
+
+	current := &models.Note{ID: "note123", Title: "New", Content: "new text", Status: "done", Priority: "high", UserID: "user123"}
+	mockRepo := new(MockNoteRepository)
+	mockRepo.On("GetRevision", mock.Anything, "note123", 2).Return(&models.NoteRevision{
+		NoteID: "note123", Revision: 2, Title: "Old", Content: "old text", Status: "created", Priority: "normal",
+	}, nil)
+	mockRepo.On("Get", mock.Anything, "note123").Return(&models.Note{
+		ID: "note123", Title: "New", Content: "new text", Status: "done", Priority: "high", UserID: "user123",
+	}, nil)
+	mockRepo.On("Update", mock.Anything, mock.MatchedBy(func(note *models.Note) bool {
+		return note.Title == "Old" && note.Content == "old text"
+	})).Return(&models.Note{
+		ID: "note123", Title: "Old", Content: "old text", Status: "created", Priority: "normal", UserID: "user123",
+	}, nil)
+	mockRepo.On("CreateRevision", mock.Anything, mock.MatchedBy(func(rev *models.NoteRevision) bool {
+		return rev.Title == "New" && rev.Content == "new text" && rev.Status == "done"
+	}), 10).Return(nil)
+	service := NewNoteService(mockRepo, nil, stubWorkflowService{}, cfg)
+
+	note, err := service.RestoreRevision(context.Background(), current, 2)
+	assert.NoError(t, err, "unexpected error")
+	assert.Equal(t, "Old", note.Title, "note title mismatch")
+	assert.Equal(t, "old text", note.Content, "note content mismatch")
+	assert.Equal(t, "created", note.Status, "note status mismatch")
+
+	mockRepo.On("GetRevision", mock.Anything, "note123", 99).Return(nil, ErrRevisionNotFound)
+	_, err = service.RestoreRevision(context.Background(), current, 99)
+	assert.ErrorIs(t, err, ErrRevisionNotFound, "expected error")
+	mockRepo.AssertExpectations(t)
+}
+
+// TestNoteHandler_RestoreRevision — ошибки завершения и статуса при откате отдаются как при обычном обновлении
+func TestNoteHandler_RestoreRevision(t *testing.T) {
+	tests := []struct {
+		name       string
+		revStatus  string
+		mockSetup  func(m *MockNoteRepository)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:      "Restoring done revision of a blocked note",
+			revStatus: "done",
+			mockSetup: func(m *MockNoteRepository) {
+				m.On("GetOpenBlockers", mock.Anything, "note123").Return([]models.Note{{ID: "blocker", Title: "Blocker", Status: "created"}}, nil)
+			},
+			wantStatus: http.StatusConflict,
+			wantBody:   `"blocker"`,
+		},
+		{
+			name:       "Revision status outside workflow",
+			revStatus:  "archived",
+			mockSetup:  func(m *MockNoteRepository) {},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "invalid note status",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockNoteRepository)
+			// Обработчик и UpdateNote читают заметку по отдельности
+			for range 2 {
+				mockRepo.On("Get", mock.Anything, "note123").Return(&models.Note{ID: "note123", Title: "New", Status: "in_progress", Priority: "normal", UserID: "user123"}, nil).Once()
+			}
+			mockRepo.On("GetRevision", mock.Anything, "note123", 2).Return(&models.NoteRevision{
+				NoteID: "note123", Revision: 2, Title: "Old", Status: tt.revStatus, Priority: "normal",
+			}, nil)
+			tt.mockSetup(mockRepo)
+			handler := &NoteHandler{Config: &configs.Config{}, NoteService: NewNoteService(mockRepo, nil, stubWorkflowService{}, &configs.Config{})}
+
+			req := httptest.NewRequest("POST", "/notes/note123/revisions/2/restore", nil)
+			req.SetPathValue("id", "note123")
+			req.SetPathValue("rev", "2")
+			req = req.WithContext(context.WithValue(req.Context(), middleware.ContextUserIDKey, "user123"))
+			rr := httptest.NewRecorder()
+			handler.RestoreRevision()(rr, req)
+
+			assert.Equal(t, tt.wantStatus, rr.Code, "unexpected status code")
+			assert.Contains(t, rr.Body.String(), tt.wantBody, "response body mismatch")
+			mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+// TestNoteHandler_GetDependencyGraph — права на узлы графа берутся из графа, без запроса доступа на каждый узел
+func TestNoteHandler_GetDependencyGraph(t *testing.T) {
+	mockRepo := new(MockNoteRepository)
+	mockRepo.On("Get", mock.Anything, "note123").Return(&models.Note{ID: "note123", Title: "Mine", Status: "created", UserID: "user123"}, nil)
+	mockRepo.On("GetDependencyGraph", mock.Anything, "note123", "user123").Return(&models.DependencyGraph{
+		Nodes: []models.Note{
+			{ID: "note123", Title: "Mine", Status: "created", UserID: "user123"},
+			{ID: "shared", Title: "Shared", Status: "in_progress", UserID: "other"},
+			{ID: "private", Title: "Private", Status: "created", UserID: "other"},
+		},
+		Edges:       []models.Dependency{{NoteID: "note123", BlockerID: "shared"}, {NoteID: "shared", BlockerID: "private"}},
+		Permissions: map[string]string{"note123": models.PermissionOwner, "shared": models.PermissionViewer},
+	}, nil)
+	handler := &NoteHandler{Config: &configs.Config{}, NoteService: NewNoteService(mockRepo, nil, stubWorkflowService{}, &configs.Config{})}
+
+	req := httptest.NewRequest("GET", "/notes/note123/graph", nil)
+	req.SetPathValue("id", "note123")
+	req = req.WithContext(context.WithValue(req.Context(), middleware.ContextUserIDKey, "user123"))
+	rr := httptest.NewRecorder()
+	handler.GetDependencyGraph()(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code, "unexpected status code")
+	var response DependencyGraphResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response), "decode response")
+	assert.Equal(t, []DependencyNode{
+		{ID: "note123", Title: "Mine", Status: "created", Accessible: true},
+		{ID: "shared", Title: "Shared", Status: "in_progress", Accessible: true},
+		{ID: "private", Status: "created"},
+	}, response.Nodes, "nodes mismatch")
+	assert.Len(t, response.Edges, 2, "edges mismatch")
+	mockRepo.AssertNotCalled(t, "GetShare", mock.Anything, mock.Anything, mock.Anything)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestNoteService_BatchNotes(t *testing.T) {
+	newOps := func() []models.NoteBatchOperation {
+		return []models.NoteBatchOperation{
+			{Op: models.BatchOpCreate, Note: &models.Note{Title: "New"}},
+			{Op: models.BatchOpUpdate, NoteID: "foreign", Apply: func(note *models.Note) { note.Status = "done" }},
+			{Op: models.BatchOpDelete, NoteID: "note123", Version: 2},
+		}
+	}
+	setup := func() *MockNoteRepository {
+		mockRepo := new(MockNoteRepository)
+		mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(note *models.Note) bool {
+			return note.UserID == "user123" && note.Status == "created"
+		})).Return(&models.Note{ID: "new1", Title: "New", UserID: "user123"}, nil)
+		mockRepo.On("Get", mock.Anything, "foreign").Return(&models.Note{ID: "foreign", UserID: "other"}, nil)
+		mockRepo.On("GetShare", mock.Anything, "foreign", "user123").Return(nil, ErrShareNotFound)
+		mockRepo.On("Get", mock.Anything, "note123").Return(&models.Note{ID: "note123", UserID: "user123", Version: 2}, nil)
+		mockRepo.On("Delete", mock.Anything, "note123").Return(nil)
+		return mockRepo
+	}
+
+	t.Run("Best effort keeps successful operations", func(t *testing.T) {
+		mockRepo := setup()
+		service := NewNoteService(mockRepo, nil, stubWorkflowService{}, &configs.Config{})
+
+		results, err := service.BatchNotes(context.Background(), "user123", newOps(), false)
+		assert.NoError(t, err, "unexpected error")
+		assert.Len(t, results, 3, "results count mismatch")
+		assert.NoError(t, results[0].Err, "create should succeed")
+		assert.Equal(t, "new1", results[0].Note.ID, "created note mismatch")
+		assert.ErrorIs(t, results[1].Err, ErrNoteNotFound, "foreign note should not be found")
+		assert.NoError(t, results[2].Err, "delete should succeed")
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Atomic batch aborts on first error", func(t *testing.T) {
+		mockRepo := setup()
+		service := NewNoteService(mockRepo, nil, stubWorkflowService{}, &configs.Config{})
+
+		results, err := service.BatchNotes(context.Background(), "user123", newOps(), true)
+		assert.ErrorIs(t, err, ErrBatchAborted, "expected batch abort")
+		assert.ErrorIs(t, results[0].Err, ErrBatchAborted, "create should be rolled back")
+		assert.Nil(t, results[0].Note, "rolled back note should be dropped")
+		assert.ErrorIs(t, results[1].Err, ErrNoteNotFound, "failed operation keeps its error")
+		assert.ErrorIs(t, results[2].Err, ErrBatchAborted, "remaining operations are not run")
+		mockRepo.AssertNotCalled(t, "Delete", mock.Anything, "note123")
+	})
+
+	t.Run("Version mismatch is reported per operation", func(t *testing.T) {
+		mockRepo := setup()
+		service := NewNoteService(mockRepo, nil, stubWorkflowService{}, &configs.Config{})
+
+		ops := []models.NoteBatchOperation{{Op: models.BatchOpDelete, NoteID: "note123", Version: 1}}
+		results, err := service.BatchNotes(context.Background(), "user123", ops, false)
+		assert.NoError(t, err, "unexpected error")
+		assert.ErrorIs(t, results[0].Err, ErrVersionConflict, "expected version conflict")
+	})
+}
+
+func TestNextOccurrence(t *testing.T) {
+	monday := time.Date(2025, time.March, 3, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		rule  string
+		tz    string
+		dueAt time.Time
+		start *time.Time // Начало серии, nil — совпадает со сроком
+		want  *time.Time
+	}{
+		{
+			name:  "Weekly on Monday and Wednesday",
+			rule:  "FREQ=WEEKLY;BYDAY=MO,WE",
+			dueAt: monday,
+			want:  ptrTime(time.Date(2025, time.March, 5, 9, 0, 0, 0, time.UTC)),
+		},
+		{
+			name:  "Monthly on the last Friday",
+			rule:  "RRULE:FREQ=MONTHLY;BYDAY=-1FR",
+			dueAt: time.Date(2025, time.March, 28, 9, 0, 0, 0, time.UTC),
+			want:  ptrTime(time.Date(2025, time.April, 25, 9, 0, 0, 0, time.UTC)),
+		},
+		{
+			// Пятница 20:00 в Лос-Анджелесе — уже суббота в UTC; между сроками переход на летнее время
+			name:  "Weekly on Friday evening west of UTC",
+			rule:  "FREQ=WEEKLY;BYDAY=FR",
+			tz:    "America/Los_Angeles",
+			dueAt: time.Date(2025, time.March, 8, 4, 0, 0, 0, time.UTC),
+			want:  ptrTime(time.Date(2025, time.March, 15, 3, 0, 0, 0, time.UTC)),
+		},
+		{
+			// Пятница 08:00 в Токио — еще четверг в UTC
+			name:  "Monthly on the last Friday east of UTC",
+			rule:  "FREQ=MONTHLY;BYDAY=-1FR",
+			tz:    "Asia/Tokyo",
+			dueAt: time.Date(2025, time.March, 27, 23, 0, 0, 0, time.UTC),
+			want:  ptrTime(time.Date(2025, time.April, 24, 23, 0, 0, 0, time.UTC)),
+		},
+		{
+			name:  "Series exhausted by COUNT",
+			rule:  "FREQ=DAILY;COUNT=2",
+			dueAt: monday.AddDate(0, 0, 1),
+			start: &monday,
+			want:  nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			note := &models.Note{RRule: tt.rule, RRuleTZ: tt.tz, DueAt: &tt.dueAt, SeriesStart: tt.start}
+			assert.NoError(t, normalizeRecurrence(note), "unexpected error")
+			got, err := nextOccurrence(note)
+			assert.NoError(t, err, "unexpected error")
+			assert.Equal(t, tt.want, got, "next occurrence mismatch")
+		})
+	}
+
+	note := &models.Note{RRule: "FREQ=SOMETIMES"}
+	assert.ErrorIs(t, normalizeRecurrence(note), ErrRecurrenceWithoutDueAt, "due date is required")
+	note.DueAt = &monday
+	assert.ErrorIs(t, normalizeRecurrence(note), ErrInvalidRRule, "expected invalid rule")
+	note = &models.Note{RRule: "FREQ=DAILY", RRuleTZ: "Mars/Olympus", DueAt: &monday}
+	assert.ErrorIs(t, normalizeRecurrence(note), ErrInvalidRRule, "unknown timezone should be rejected")
+
+	for _, rule := range []string{"FREQ=HOURLY", "FREQ=MINUTELY;INTERVAL=5", "FREQ=SECONDLY"} {
+		note := &models.Note{RRule: rule, DueAt: &monday}
+		assert.ErrorIs(t, normalizeRecurrence(note), ErrInvalidRRule, "sub-daily rule %s should be rejected", rule)
+	}
+}
+
+// TestOccurrencesBetween — предпросмотр старой серии и плотного правила ограничен по числу шагов
+func TestOccurrencesBetween(t *testing.T) {
+	seriesStart := time.Date(1990, time.January, 1, 9, 0, 0, 0, time.UTC)
+	from := time.Date(2025, time.March, 3, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 7)
+
+	old := &models.Note{RRule: "FREQ=DAILY", SeriesStart: &seriesStart}
+	got, err := occurrencesBetween(old, from, to)
+	assert.NoError(t, err, "unexpected error")
+	assert.Len(t, got, 7, "daily series should still be previewed decades later")
+
+	// 1440 повторений в день с 1990 года: обход обрывается на maxRuleIterations, не доходя до окна
+	dense := &models.Note{RRule: "FREQ=DAILY;BYHOUR=" + numberList(24) + ";BYMINUTE=" + numberList(60), SeriesStart: &seriesStart}
+	started := time.Now()
+	got, err = occurrencesBetween(dense, from, to)
+	assert.NoError(t, err, "unexpected error")
+	assert.Empty(t, got, "walk should stop before reaching the window")
+	assert.Less(t, time.Since(started), 5*time.Second, "walk should be bounded")
+}
+
+func numberList(n int) string {
+	values := make([]string, n)
+	for i := range values {
+		values[i] = strconv.Itoa(i)
+	}
+	return strings.Join(values, ",")
+}
+
+func ptrTime(value time.Time) *time.Time {
+	return &value
+}
+
+// TestNoteService_UpdateNote_Recurring — выполнение повторяющейся заметки создает следующее повторение в серии
+func TestNoteService_UpdateNote_Recurring(t *testing.T) {
+	dueAt := time.Date(2025, time.March, 3, 9, 0, 0, 0, time.UTC)
+	previous := &models.Note{
+		ID: "note123", Title: "Chores", Status: "created", UserID: "user123",
+		DueAt: &dueAt, RRule: "FREQ=WEEKLY;BYDAY=MO,WE", SeriesStart: &dueAt,
+	}
+	note := *previous
+	note.Status = "done"
+
+	mockRepo := new(MockNoteRepository)
+	mockRepo.On("Get", mock.Anything, "note123").Return(previous, nil)
+	mockRepo.On("Update", mock.Anything, mock.MatchedBy(func(note *models.Note) bool {
+		return note.Status == "done" && note.RRule == "" && *note.SeriesID == "note123"
+	})).Return(&models.Note{ID: "note123", Title: "Chores", Status: "done", UserID: "user123"}, nil)
+	mockRepo.On("GetOpenBlockers", mock.Anything, "note123").Return([]models.Note{}, nil)
+	mockRepo.On("CreateRevision", mock.Anything, mock.Anything, 0).Return(nil)
+	mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(next *models.Note) bool {
+		return next.Status == "created" &&
+			next.DueAt.Equal(time.Date(2025, time.March, 5, 9, 0, 0, 0, time.UTC)) &&
+			next.RRule == "FREQ=WEEKLY;BYDAY=MO,WE" &&
+			*next.SeriesID == "note123"
+	})).Return(&models.Note{ID: "note124"}, nil)
+	service := NewNoteService(mockRepo, nil, stubWorkflowService{}, &configs.Config{})
+
+	_, err := service.UpdateNote(context.Background(), &note, false)
+	assert.NoError(t, err, "unexpected error")
+	mockRepo.AssertExpectations(t)
+}
+
+// TestNoteService_UpdateNote_Workflow — смена статуса ограничена переходами workflow пользователя
+func TestNoteService_UpdateNote_Workflow(t *testing.T) {
+	workflow := &models.Workflow{
+		UserID: "user123",
+		Statuses: []models.WorkflowStatus{
+			{Name: "todo", Next: []string{"review"}},
+			{Name: "review", Next: []string{"todo", "done"}},
+			{Name: "done", Next: []string{"todo"}},
+		},
+	}
+	mockRepo := new(MockNoteRepository)
+	mockRepo.On("Get", mock.Anything, "note123").Return(&models.Note{ID: "note123", Status: "todo", UserID: "user123"}, nil)
+	service := NewNoteService(mockRepo, nil, stubWorkflowService{workflow: workflow}, &configs.Config{})
+
+	_, err := service.UpdateNote(context.Background(), &models.Note{ID: "note123", Status: "done", UserID: "user123"}, false)
+	var transitionErr *TransitionError
+	assert.ErrorAs(t, err, &transitionErr, "expected transition error")
+	assert.ErrorIs(t, err, ErrInvalidTransition, "expected transition error")
+	assert.Equal(t, []string{"review"}, transitionErr.Allowed, "allowed statuses mismatch")
+
+	_, err = service.UpdateNote(context.Background(), &models.Note{ID: "note123", Status: "in_progress", UserID: "user123"}, false)
+	assert.ErrorIs(t, err, ErrInvalidNoteStatus, "status outside workflow should be rejected")
+
+	mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(note *models.Note) bool {
+		return note.Status == "todo"
+	})).Return(&models.Note{ID: "note124", Status: "todo"}, nil)
+	_, err = service.CreateNote(context.Background(), &models.Note{Title: "New", UserID: "user123"})
+	assert.NoError(t, err, "new note should get the initial status")
+	mockRepo.AssertExpectations(t)
+}
+
+// TestNoteService_TerminalMarkers — завершение и автопересчет статуса определяются маркерами workflow
+func TestNoteService_TerminalMarkers(t *testing.T) {
+	workflow := &models.Workflow{
+		UserID: "user123",
+		Statuses: []models.WorkflowStatus{
+			{Name: "todo", Next: []string{"doing"}},
+			{Name: "doing", Next: []string{"todo", "review"}, InProgress: true},
+			{Name: "review", Next: []string{"shipped"}},
+			{Name: "shipped", Next: []string{"doing"}, Terminal: true},
+		},
+	}
+
+	t.Run("Custom terminal status checks blockers", func(t *testing.T) {
+		mockRepo := new(MockNoteRepository)
+		mockRepo.On("Get", mock.Anything, "note123").Return(&models.Note{ID: "note123", Status: "review", UserID: "user123"}, nil)
+		mockRepo.On("GetOpenBlockers", mock.Anything, "note123").Return([]models.Note{{ID: "blocker", Status: "todo"}}, nil)
+		service := NewNoteService(mockRepo, nil, stubWorkflowService{workflow: workflow}, &configs.Config{})
+
+		_, err := service.UpdateNote(context.Background(), &models.Note{ID: "note123", Status: "shipped", UserID: "user123"}, false)
+		assert.ErrorIs(t, err, ErrNoteBlocked, "expected blocked error")
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Roll-up skips forbidden transition", func(t *testing.T) {
+		note := &models.Note{ID: "note123", Status: "doing", UserID: "user123"}
+		item := &models.ChecklistItem{ID: "item1", NoteID: "note123", Done: true}
+
+		mockRepo := new(MockNoteRepository)
+		mockRepo.On("UpdateItem", mock.Anything, item).Return(item, nil)
+		mockRepo.On("GetItems", mock.Anything, "note123").Return([]models.ChecklistItem{*item}, nil)
+		cfg := &configs.Config{}
+		cfg.Notes.ChecklistAutoDone = true
+		service := NewNoteService(mockRepo, nil, stubWorkflowService{workflow: workflow}, cfg)
+
+		_, status, err := service.UpdateChecklistItem(context.Background(), note, item)
+		assert.NoError(t, err, "unexpected error")
+		assert.Equal(t, "doing", status, "doing -> shipped is not allowed by the workflow")
+		mockRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestPositionBetween(t *testing.T) {
+	first, err := positionBetween("", "")
+	assert.NoError(t, err, "unexpected error")
+	assert.Equal(t, "V", first, "first key mismatch")
+
+	tests := []struct {
+		lower, upper string
+	}{
+		{"", "V"},
+		{"V", ""},
+		{"V", "W"},
+		{"a01", "a1"},
+		{"Vz", "W"},
+		{"z", ""},
+		{"", "01"},
+	}
+	for _, tt := range tests {
+		key, err := positionBetween(tt.lower, tt.upper)
+		assert.NoError(t, err, "unexpected error for %q..%q", tt.lower, tt.upper)
+		assert.Greater(t, key, tt.lower, "key must be after lower bound")
+		if tt.upper != "" {
+			assert.Less(t, key, tt.upper, "key must be before upper bound")
+		}
+	}
+
+	_, err = positionBetween("W", "V")
+	assert.ErrorIs(t, err, ErrInvalidPosition, "bounds out of order")
+	_, err = positionBetween("V0", "")
+	assert.ErrorIs(t, err, ErrInvalidPosition, "trailing zero digit is not allowed")
+}
+
+// TestNoteService_MoveNote — перенос в другую колонку между соседями меняет статус и ключ позиции
+func TestNoteService_MoveNote(t *testing.T) {
+	mockRepo := new(MockNoteRepository)
+	mockRepo.On("Get", mock.Anything, "above").Return(&models.Note{ID: "above", UserID: "user123", Status: "in_progress", Position: "V"}, nil)
+	mockRepo.On("Get", mock.Anything, "below").Return(&models.Note{ID: "below", UserID: "user123", Status: "in_progress", Position: "X"}, nil)
+	mockRepo.On("Get", mock.Anything, "foreign").Return(&models.Note{ID: "foreign", UserID: "other", Status: "in_progress", Position: "Z"}, nil)
+	mockRepo.On("Get", mock.Anything, "note123").Return(&models.Note{ID: "note123", UserID: "user123", Status: "created", Position: "a"}, nil)
+	mockRepo.On("Update", mock.Anything, mock.MatchedBy(func(note *models.Note) bool {
+		return note.Status == "in_progress" && note.Position == "W"
+	})).Return(&models.Note{ID: "note123", UserID: "user123", Status: "in_progress", Position: "W"}, nil)
+	mockRepo.On("CreateRevision", mock.Anything, mock.Anything, 0).Return(nil)
+	service := NewNoteService(mockRepo, nil, stubWorkflowService{}, &configs.Config{})
+
+	note := &models.Note{ID: "note123", UserID: "user123", Status: "created", Position: "a"}
+	moved, err := service.MoveNote(context.Background(), note, "in_progress", "above", "below")
+	assert.NoError(t, err, "unexpected error")
+	assert.Equal(t, "W", moved.Position, "position mismatch")
+
+	note = &models.Note{ID: "note123", UserID: "user123", Status: "created", Position: "a"}
+	_, err = service.MoveNote(context.Background(), note, "in_progress", "foreign", "")
+	assert.ErrorIs(t, err, ErrInvalidNeighbour, "foreign neighbour should be rejected")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestNoteService_ShareNote(t *testing.T) {
+	note := &models.Note{ID: "note123", UserID: "user123"}
+	tests := []struct {
+		name       string
+		email      string
+		permission string
+		setupMock  func(*MockNoteRepository, *MockUserRepository)
+		wantErr    error
+	}{
+		{
+			name:       "Share with another user",
+			email:      " bob@example.com ",
+			permission: models.PermissionEditor,
+			setupMock: func(noteRepo *MockNoteRepository, userRepo *MockUserRepository) {
+				userRepo.On("FindByEmail", mock.Anything, "bob@example.com").Return(&models.User{ID: "bob"}, nil)
+				noteRepo.On("SaveShare", mock.Anything, mock.MatchedBy(func(share *models.NoteShare) bool {
+					return share.NoteID == "note123" && share.UserID == "bob" && share.Permission == models.PermissionEditor
+				})).Return(&models.NoteShare{NoteID: "note123", UserID: "bob", Permission: models.PermissionEditor}, nil)
+			},
+		},
+		{
+			name:       "Owner permission cannot be granted",
+			email:      "bob@example.com",
+			permission: models.PermissionOwner,
+			setupMock:  func(*MockNoteRepository, *MockUserRepository) {},
+			wantErr:    ErrInvalidPermission,
+		},
+		{
+			name:       "Unknown email",
+			email:      "nobody@example.com",
+			permission: models.PermissionViewer,
+			setupMock: func(noteRepo *MockNoteRepository, userRepo *MockUserRepository) {
+				userRepo.On("FindByEmail", mock.Anything, "nobody@example.com").Return(nil, user.ErrUserNotFound)
+			},
+			wantErr: user.ErrUserNotFound,
+		},
+		{
+			name:       "Owner cannot share with themselves",
+			email:      "owner@example.com",
+			permission: models.PermissionViewer,
+			setupMock: func(noteRepo *MockNoteRepository, userRepo *MockUserRepository) {
+				userRepo.On("FindByEmail", mock.Anything, "owner@example.com").Return(&models.User{ID: "user123"}, nil)
+			},
+			wantErr: ErrShareWithOwner,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			noteRepo := new(MockNoteRepository)
+			userRepo := new(MockUserRepository)
+			tt.setupMock(noteRepo, userRepo)
+			service := NewNoteService(noteRepo, userRepo, stubWorkflowService{}, &configs.Config{})
+
+			share, err := service.ShareNote(context.Background(), note, tt.email, tt.permission)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr, "error mismatch")
+				noteRepo.AssertNotCalled(t, "SaveShare", mock.Anything, mock.Anything)
+				return
+			}
+			assert.NoError(t, err, "unexpected error")
+			assert.Equal(t, models.PermissionEditor, share.Permission, "permission mismatch")
+			noteRepo.AssertExpectations(t)
+			userRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestNoteService_GetPermission(t *testing.T) {
+	note := &models.Note{ID: "note123", UserID: "user123"}
+	mockRepo := new(MockNoteRepository)
+	mockRepo.On("GetShare", mock.Anything, "note123", "viewer").Return(&models.NoteShare{Permission: models.PermissionViewer}, nil)
+	mockRepo.On("GetShare", mock.Anything, "note123", "stranger").Return(nil, ErrShareNotFound)
+	service := NewNoteService(mockRepo, nil, stubWorkflowService{}, &configs.Config{})
+
+	tests := []struct {
+		userID string
+		want   string
+	}{
+		{userID: "user123", want: models.PermissionOwner},
+		{userID: "viewer", want: models.PermissionViewer},
+		{userID: "stranger", want: ""},
+	}
+	for _, tt := range tests {
+		permission, err := service.GetPermission(context.Background(), note, tt.userID)
+		assert.NoError(t, err, "unexpected error for %s", tt.userID)
+		assert.Equal(t, tt.want, permission, "permission mismatch for %s", tt.userID)
+	}
+
+	assert.True(t, models.PermissionAllows(models.PermissionEditor, models.PermissionViewer), "editor can view")
+	assert.False(t, models.PermissionAllows(models.PermissionViewer, models.PermissionEditor), "viewer cannot edit")
+	assert.False(t, models.PermissionAllows(models.PermissionEditor, models.PermissionOwner), "editor is not owner")
+	assert.False(t, models.PermissionAllows("", models.PermissionViewer), "no access")
+}
+
+func TestNoteService_OpenLink(t *testing.T) {
+	now := time.Date(2025, time.March, 3, 9, 0, 0, 0, time.UTC)
+	expired := now.Add(-time.Minute)
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	assert.NoError(t, err, "hash password")
+
+	tests := []struct {
+		name     string
+		link     *models.NoteLink
+		password string
+		wantErr  error
+	}{
+		{name: "Open link", link: &models.NoteLink{ID: "link1", NoteID: "note123"}},
+		{name: "Expired link", link: &models.NoteLink{ID: "link1", NoteID: "note123", ExpiresAt: &expired}, wantErr: ErrLinkExpired},
+		{name: "Password is required", link: &models.NoteLink{ID: "link1", NoteID: "note123", PasswordHash: string(hash)}, wantErr: ErrLinkPasswordRequired},
+		{name: "Wrong password", link: &models.NoteLink{ID: "link1", NoteID: "note123", PasswordHash: string(hash)}, password: "guess", wantErr: ErrLinkPasswordInvalid},
+		{name: "Correct password", link: &models.NoteLink{ID: "link1", NoteID: "note123", PasswordHash: string(hash)}, password: "secret"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockNoteRepository)
+			mockRepo.On("GetLinkByToken", mock.Anything, "token").Return(tt.link, nil)
+			mockRepo.On("Get", mock.Anything, "note123").Return(&models.Note{ID: "note123", Title: "Shared"}, nil)
+			mockRepo.On("RecordLinkAccess", mock.Anything, "link1", now).Return(nil)
+			service := NewNoteService(mockRepo, nil, stubWorkflowService{}, &configs.Config{})
+
+			note, err := service.OpenLink(context.Background(), "token", tt.password, now)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr, "error mismatch")
+				mockRepo.AssertNotCalled(t, "RecordLinkAccess", mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+			assert.NoError(t, err, "unexpected error")
+			assert.Equal(t, "Shared", note.Title, "note mismatch")
+			mockRepo.AssertCalled(t, "RecordLinkAccess", mock.Anything, "link1", now)
+		})
+	}
+
+	t.Run("Trashed note is not available", func(t *testing.T) {
+		mockRepo := new(MockNoteRepository)
+		mockRepo.On("GetLinkByToken", mock.Anything, "token").Return(&models.NoteLink{ID: "link1", NoteID: "note123"}, nil)
+		mockRepo.On("Get", mock.Anything, "note123").Return(nil, ErrNoteNotFound)
+		service := NewNoteService(mockRepo, nil, stubWorkflowService{}, &configs.Config{})
+
+		_, err := service.OpenLink(context.Background(), "token", "", now)
+		assert.ErrorIs(t, err, ErrLinkNotFound, "expected link not found")
+	})
+}
+
+// TestNoteService_AddDependency — связь только между заметками одного владельца и без петель
+func TestNoteService_AddDependency(t *testing.T) {
+	note := &models.Note{ID: "note123", UserID: "user123"}
+	mockRepo := new(MockNoteRepository)
+	mockRepo.On("Get", mock.Anything, "blocker").Return(&models.Note{ID: "blocker", UserID: "user123"}, nil)
+	mockRepo.On("Get", mock.Anything, "foreign").Return(&models.Note{ID: "foreign", UserID: "other"}, nil)
+	mockRepo.On("Get", mock.Anything, "missing").Return(nil, ErrNoteNotFound)
+	mockRepo.On("AddDependency", mock.Anything, "user123", "note123", "blocker").Return(nil)
+	service := NewNoteService(mockRepo, nil, stubWorkflowService{}, &configs.Config{})
+
+	assert.NoError(t, service.AddDependency(context.Background(), "user123", note, "blocker"), "unexpected error")
+	assert.ErrorIs(t, service.AddDependency(context.Background(), "user123", note, "note123"), ErrDependencyCycle, "self dependency is a cycle")
+	assert.ErrorIs(t, service.AddDependency(context.Background(), "user123", note, "foreign"), ErrInvalidDependency, "foreign blocker should be rejected")
+	assert.ErrorIs(t, service.AddDependency(context.Background(), "user123", note, "missing"), ErrNoteNotFound, "missing blocker")
+	mockRepo.AssertExpectations(t)
+}
+
+// openTestDB подключается к настоящей базе для тестов репозитория; без NOTES_TEST_DSN тест пропускается:
+// NOTES_TEST_DSN="host=localhost user=postgres password=... dbname=todo_test port=5432 sslmode=disable"
+func openTestDB(t *testing.T) (*gorm.DB, *configs.Config) {
+	dsn := os.Getenv("NOTES_TEST_DSN")
+	if dsn == "" {
+		t.Skip("NOTES_TEST_DSN is not set")
+	}
+	cfg := &configs.Config{}
+	cfg.Db.Dsn = dsn
+	gormDB, sqlDB, err := db.NewDb(cfg)
+	require.NoError(t, err, "connect database")
+	t.Cleanup(func() { sqlDB.Close() })
+	require.NoError(t, gormDB.AutoMigrate(&models.User{}, &models.Tag{}, &models.Note{}, &models.ChecklistItem{}), "migrate")
+	return gormDB, cfg
+}
+
+// TestNoteRepository_ItemsChangeETag — правка чек-листа меняет ETag заметки; изменения откатываются после теста
+func TestNoteRepository_ItemsChangeETag(t *testing.T) {
+	gormDB, cfg := openTestDB(t)
+
+	tx := gormDB.Begin()
+	defer tx.Rollback()
+	repo := NewNoteRepository(tx)
+	ctx := context.Background()
+
+	require.NoError(t, tx.Create(&models.User{ID: "etag-test-user", Name: "ETag", Email: "etag-test@example.com", Password: "hash"}).Error)
+	note, err := repo.Create(ctx, &models.Note{Title: "Checklist", Status: "created", UserID: "etag-test-user"})
+	require.NoError(t, err, "create note")
+
+	originalETag := noteETag(note)
+	// assertChanged перечитывает заметку и проверяет, что прежний ETag больше не совпадает
+	staleETag := noteETag(note)
+	assertChanged := func(step string) {
+		stored, err := repo.Get(ctx, note.ID)
+		require.NoError(t, err, "get note")
+		etag := noteETag(stored)
+		assert.False(t, etagMatches(staleETag, etag, true), "%s: old ETag should not match after the change", step)
+		staleETag = etag
+	}
+
+	item, err := repo.CreateItem(ctx, &models.ChecklistItem{NoteID: note.ID, Text: "First"})
+	require.NoError(t, err, "create item")
+	assertChanged("create item")
+
+	item.Done = true
+	_, err = repo.UpdateItem(ctx, item)
+	require.NoError(t, err, "update item")
+	assertChanged("update item")
+
+	require.NoError(t, repo.DeleteItem(ctx, item.ID), "delete item")
+	assertChanged("delete item")
+	assert.ErrorIs(t, repo.DeleteItem(ctx, item.ID), ErrItemNotFound, "second delete should report not found")
+
+	// Клиент, видевший заметку до правки чек-листа, не получает 304 и не может перезаписать заметку
+	handler := &NoteHandler{Config: cfg, NoteService: NewNoteService(repo, nil, stubWorkflowService{}, cfg)}
+	newRequest := func(method, header string) *http.Request {
+		req := httptest.NewRequest(method, "/notes/"+note.ID, nil)
+		req.SetPathValue("id", note.ID)
+		req.Header.Set(header, originalETag)
+		return req.WithContext(context.WithValue(req.Context(), middleware.ContextUserIDKey, "etag-test-user"))
+	}
+	rr := httptest.NewRecorder()
+	handler.GetNote()(rr, newRequest("GET", "If-None-Match"))
+	assert.Equal(t, http.StatusOK, rr.Code, "stale If-None-Match should return the note")
+	assert.Equal(t, staleETag, rr.Header().Get("ETag"), "current ETag should be returned")
+
+	rr = httptest.NewRecorder()
+	handler.DeleteNote()(rr, newRequest("DELETE", "If-Match"))
+	assert.Equal(t, http.StatusPreconditionFailed, rr.Code, "stale If-Match should be rejected")
+}
+
+// TestNoteRepository_ConcurrentNewTag — две транзакции одновременно заводят один и тот же новый тег:
+// вторая дожидается первой и берет созданный ею тег вместо ошибки уникальности
+func TestNoteRepository_ConcurrentNewTag(t *testing.T) {
+	gormDB, _ := openTestDB(t)
+	userID := "tags-race-user"
+	require.NoError(t, gormDB.Create(&models.User{ID: userID, Name: "Race", Email: "tags-race@example.com", Password: "hash"}).Error)
+	t.Cleanup(func() {
+		gormDB.Exec("DELETE FROM note_tags WHERE tag_id IN (SELECT id FROM tags WHERE user_id = ?)", userID)
+		gormDB.Where("user_id = ?", userID).Delete(&models.Tag{})
+		gormDB.Where("id = ?", userID).Delete(&models.User{})
+	})
+	repo := NewNoteRepository(gormDB)
+	wanted := []models.Tag{{Name: "race"}}
+
+	first := gormDB.Begin()
+	firstTags, err := repo.resolveTags(first, userID, wanted)
+	require.NoError(t, err, "first transaction should create the tag")
+
+	type result struct {
+		tags []models.Tag
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		var tags []models.Tag
+		err := gormDB.Transaction(func(tx *gorm.DB) error {
+			var err error
+			tags, err = repo.resolveTags(tx, userID, wanted)
+			return err
+		})
+		done <- result{tags: tags, err: err}
+	}()
+	time.Sleep(200 * time.Millisecond) // Вторая транзакция упирается в незафиксированную вставку первой
+	require.NoError(t, first.Commit().Error, "commit first transaction")
+
+	second := <-done
+	require.NoError(t, second.err, "second transaction should reuse the tag")
+	require.Len(t, second.tags, 1)
+	assert.Equal(t, firstTags[0].ID, second.tags[0].ID, "both transactions should resolve the same tag")
+}
+
+// TestNoteRepository_ConcurrentCreateItem — параллельно добавленные пункты получают разные позиции
+func TestNoteRepository_ConcurrentCreateItem(t *testing.T) {
+	gormDB, _ := openTestDB(t)
+	userID := "items-race-user"
+	require.NoError(t, gormDB.Create(&models.User{ID: userID, Name: "Race", Email: "items-race@example.com", Password: "hash"}).Error)
+	t.Cleanup(func() {
+		gormDB.Exec("DELETE FROM checklist_items WHERE note_id IN (SELECT id FROM notes WHERE user_id = ?)", userID)
+		gormDB.Unscoped().Where("user_id = ?", userID).Delete(&models.Note{})
+		gormDB.Where("id = ?", userID).Delete(&models.User{})
+	})
+	repo := NewNoteRepository(gormDB)
+	note, err := repo.Create(context.Background(), &models.Note{Title: "Race", Status: "created", UserID: userID})
+	require.NoError(t, err, "create note")
+
+	const workers = 8
+	errs := make(chan error, workers)
+	for i := range workers {
+		go func() {
+			_, err := repo.CreateItem(context.Background(), &models.ChecklistItem{NoteID: note.ID, Text: "Item " + strconv.Itoa(i)})
+			errs <- err
+		}()
+	}
+	for range workers {
+		require.NoError(t, <-errs, "create item")
+	}
+
+	items, err := repo.GetItems(context.Background(), note.ID)
+	require.NoError(t, err, "get items")
+	positions := make([]int, 0, len(items))
+	for _, item := range items {
+		positions = append(positions, item.Position)
+	}
+	assert.Equal(t, []int{0, 1, 2, 3, 4, 5, 6, 7}, positions, "positions should be unique and consecutive")
+}
@@ -6,30 +6,251 @@ import (
 )
 
 type CreateNoteRequest struct {
-	Title   string `json:"title" validate:"required"`
-	Content string `json:"content"`
-	Status  string `json:"status"`
+	Title     string     `json:"title" validate:"required"`
+	Content   string     `json:"content"`
+	Status    string     `json:"status"`
+	Priority  string     `json:"priority"`
+	Tags      []string   `json:"tags"`
+	DueAt     *time.Time `json:"due_at"` // RFC 3339 со смещением часового пояса
+	ProjectID *string    `json:"project_id"`
+	RRule     string     `json:"rrule"`    // Правило повторения RFC 5545, требует due_at
+	RRuleTZ   string     `json:"rrule_tz"` // IANA-зона правила (Europe/Moscow); пусто — UTC
 }
 
 type GetAllNotesResponse struct {
 	Notes      []models.Note `json:"notes"`
-	TotalCount int64         `json:"total_count"`
+	TotalCount *int64        `json:"total_count,omitempty"` // Отсутствует при include_total=false
 	Limit      int           `json:"limit"`
 	Offset     int           `json:"offset"`
+	Sort       string        `json:"sort"`
+	NextCursor string        `json:"next_cursor,omitempty"`
 }
 
 type GetNoteResponse struct {
-	ID        string    `json:"id"`
-	Title     string    `json:"title"`
-	Content   string    `json:"content"`
-	Status    string    `json:"status"`
-	UserID    string    `json:"user_id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID        string                 `json:"id"`
+	Title     string                 `json:"title"`
+	Content   string                 `json:"content"`
+	Status    string                 `json:"status"`
+	Priority  string                 `json:"priority"`
+	Tags      []string               `json:"tags"`
+	DueAt     *time.Time             `json:"due_at"`
+	Items     []models.ChecklistItem `json:"items"`
+	ProjectID *string                `json:"project_id"`
+	UserID    string                 `json:"user_id"`
+	Version   int                    `json:"version"`
+	RRule     string                 `json:"rrule,omitempty"`
+	RRuleTZ   string                 `json:"rrule_tz,omitempty"`
+	SeriesID  *string                `json:"series_id,omitempty"`
+	Position  string                 `json:"position"`
+	CreatedAt time.Time              `json:"created_at"`
+	UpdatedAt time.Time              `json:"updated_at"`
 }
 
 type UpdateNoteRequest struct {
-	Title   string `json:"title"`
-	Content string `json:"content"`
-	Status  string `json:"status" validate:"omitempty,oneof=created in_progress done"`
+	Title      string     `json:"title"`
+	Content    string     `json:"content"`
+	Status     string     `json:"status" validate:"omitempty,max=20"` // Допустимые статусы и переходы задает workflow
+	Priority   string     `json:"priority" validate:"omitempty,oneof=low normal high urgent"`
+	Tags       []string   `json:"tags"` // nil — не менять теги, пустой список — удалить все
+	DueAt      *time.Time `json:"due_at"`
+	ClearDueAt bool       `json:"clear_due_at"` // Снять срок выполнения
+	RRule      *string    `json:"rrule"`        // nil — не менять правило, пустая строка — отменить повторение
+	RRuleTZ    *string    `json:"rrule_tz"`     // nil — не менять зону правила, пустая строка — UTC
+}
+
+type SearchNotesResponse struct {
+	Results    []models.NoteSearchResult `json:"results"`
+	Query      string                    `json:"query"`
+	TotalCount *int64                    `json:"total_count,omitempty"`
+	Limit      int                       `json:"limit"`
+	Offset     int                       `json:"offset"`
+}
+
+type GetTrashResponse struct {
+	Notes      []models.Note `json:"notes"`
+	TotalCount int64         `json:"total_count"`
+	Limit      int           `json:"limit"`
+	Offset     int           `json:"offset"`
+	Retention  string        `json:"retention"` // Через сколько после удаления заметка будет стерта
+}
+
+type MoveNoteToProjectRequest struct {
+	ProjectID *string `json:"project_id"` // null — перенести во входящие
+}
+
+type GetUpcomingNotesResponse struct {
+	models.UpcomingNotes
+	Timezone string `json:"timezone"`
+}
+
+type GetChecklistItemsResponse struct {
+	Items []models.ChecklistItem `json:"items"`
+}
+
+type CreateChecklistItemRequest struct {
+	Text string `json:"text" validate:"required,max=500"`
+	Done bool   `json:"done"`
+}
+
+type UpdateChecklistItemRequest struct {
+	Text     string `json:"text" validate:"omitempty,max=500"`
+	Done     *bool  `json:"done"`
+	Position *int   `json:"position" validate:"omitempty,min=0"`
+}
+
+type ChecklistItemResponse struct {
+	Item       *models.ChecklistItem `json:"item"`
+	NoteStatus string                `json:"note_status"` // Статус заметки после пересчета по чек-листу
+}
+
+type DeleteChecklistItemResponse struct {
+	NoteStatus string `json:"note_status"`
+}
+
+type GetRevisionsResponse struct {
+	Revisions []models.NoteRevision `json:"revisions"`
+}
+
+type GetRevisionResponse struct {
+	Revision    *models.NoteRevision `json:"revision"`
+	TitleDiff   string               `json:"title_diff"`   // Unified diff заголовка ревизии против текущего
+	ContentDiff string               `json:"content_diff"` // Unified diff содержимого ревизии против текущего
+}
+
+type BatchNotesRequest struct {
+	Atomic     bool                    `json:"atomic"` // true — все или ничего, false — выполнить все, что получится
+	Operations []BatchOperationRequest `json:"operations" validate:"required,min=1,max=100,dive"`
+}
+
+type BatchOperationRequest struct {
+	Op      string             `json:"op" validate:"required,oneof=create update delete"`
+	ID      string             `json:"id" validate:"required_unless=Op create"`
+	Version int                `json:"version" validate:"min=0"` // Аналог If-Match, 0 — без проверки
+	Create  *CreateNoteRequest `json:"create" validate:"required_if=Op create"`
+	Update  *UpdateNoteRequest `json:"update" validate:"required_if=Op update"`
+}
+
+type BatchOperationResult struct {
+	Index  int              `json:"index"`
+	Op     string           `json:"op"`
+	Status int              `json:"status"` // HTTP-статус, с которым завершилась бы отдельная операция
+	Note   *GetNoteResponse `json:"note,omitempty"`
+	Error  string           `json:"error,omitempty"`
+}
+
+type BatchNotesResponse struct {
+	Atomic    bool                   `json:"atomic"`
+	Committed bool                   `json:"committed"` // false — атомарный пакет откачен целиком
+	Results   []BatchOperationResult `json:"results"`
+}
+
+type GetOccurrencesResponse struct {
+	Occurrences []time.Time `json:"occurrences"`
+	From        time.Time   `json:"from"`
+	To          time.Time   `json:"to"`
+}
+
+// TransitionErrorResponse — ответ 409 на запрещенную смену статуса
+type TransitionErrorResponse struct {
+	Error   string   `json:"error"`
+	From    string   `json:"from"`
+	To      string   `json:"to"`
+	Allowed []string `json:"allowed"` // Статусы, в которые можно перейти из текущего
+}
+
+type GetBoardResponse struct {
+	Columns []models.BoardColumn `json:"columns"`
+}
+
+// MoveNoteRequest — перенос заметки на доске: after_id — заметка выше, before_id — ниже
+type MoveNoteRequest struct {
+	Status   string `json:"status" validate:"omitempty,max=20"` // Пусто — остаться в текущей колонке
+	AfterID  string `json:"after_id"`
+	BeforeID string `json:"before_id"`
+}
+
+type ShareNoteRequest struct {
+	Email      string `json:"email" validate:"required,email"`
+	Permission string `json:"permission" validate:"required,oneof=viewer editor"`
+}
+
+// NoteShareResponse — получатель доступа к заметке (без чувствительных полей пользователя)
+type NoteShareResponse struct {
+	UserID     string    `json:"user_id"`
+	Email      string    `json:"email"`
+	Name       string    `json:"name"`
+	Permission string    `json:"permission"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+type GetNoteSharesResponse struct {
+	Shares []NoteShareResponse `json:"shares"`
+}
+
+type GetSharedNotesResponse struct {
+	Notes      []models.SharedNote `json:"notes"`
+	TotalCount int64               `json:"total_count"`
+	Limit      int                 `json:"limit"`
+	Offset     int                 `json:"offset"`
+}
+
+type CreateLinkRequest struct {
+	Password  string     `json:"password" validate:"omitempty,min=4,max=72"` // Пусто — ссылка без пароля
+	ExpiresAt *time.Time `json:"expires_at"`                                 // null — бессрочная ссылка
+}
+
+type NoteLinkResponse struct {
+	ID             string     `json:"id"`
+	Token          string     `json:"token"`
+	URL            string     `json:"url"` // Путь публичной страницы заметки
+	Protected      bool       `json:"protected"`
+	ExpiresAt      *time.Time `json:"expires_at"`
+	AccessCount    int64      `json:"access_count"`
+	LastAccessedAt *time.Time `json:"last_accessed_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+type GetNoteLinksResponse struct {
+	Links []NoteLinkResponse `json:"links"`
+}
+
+// PublicNoteResponse — заметка, открытая по публичной ссылке: без владельца, версии и служебных полей
+type PublicNoteResponse struct {
+	Title     string       `json:"title"`
+	Content   string       `json:"content"`
+	Status    string       `json:"status"`
+	Priority  string       `json:"priority"`
+	Tags      []string     `json:"tags"`
+	DueAt     *time.Time   `json:"due_at"`
+	Items     []PublicItem `json:"items"`
+	UpdatedAt time.Time    `json:"updated_at"`
+}
+
+type PublicItem struct {
+	Text string `json:"text"`
+	Done bool   `json:"done"`
+}
+
+type AddDependencyRequest struct {
+	BlockerID string `json:"blocker_id" validate:"required"`
+}
+
+// BlockedErrorResponse — ответ 409 на попытку завершить заблокированную заметку
+type BlockedErrorResponse struct {
+	Error    string           `json:"error"`
+	Blockers []DependencyNode `json:"blockers"` // Незавершенные заметки, блокирующие текущую
+}
+
+// DependencyNode — заметка в графе зависимостей. Для заметок, недоступных пользователю, заголовок скрыт
+type DependencyNode struct {
+	ID         string `json:"id"`
+	Title      string `json:"title,omitempty"`
+	Status     string `json:"status"`
+	Accessible bool   `json:"accessible"`
+}
+
+type DependencyGraphResponse struct {
+	Nodes []DependencyNode    `json:"nodes"`
+	Edges []models.Dependency `json:"edges"` // note_id заблокирована blocker_id
 }
@@ -0,0 +1,81 @@
+package notes
+
+import (
+	"fmt"
+	"strings"
+)
+
+// positionDigits — алфавит ключей позиции на доске. Символы идут в порядке ASCII,
+// поэтому ключи сравниваются побайтно (в SQL — с COLLATE "C")
+const positionDigits = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// positionBetween возвращает ключ, лежащий строго между lower и upper.
+// Пустой lower — начало колонки, пустой upper — конец. Вставка между соседями меняет только
+// ключ перемещаемой заметки, перенумеровывать колонку не нужно
+func positionBetween(lower, upper string) (string, error) {
+	if upper != "" && lower >= upper {
+		return "", fmt.Errorf("%w: %q >= %q", ErrInvalidPosition, lower, upper)
+	}
+	if !validPosition(lower) || !validPosition(upper) {
+		return "", fmt.Errorf("%w: malformed key", ErrInvalidPosition)
+	}
+	return midpoint(lower, upper, upper != ""), nil
+}
+
+// validPosition проверяет, что ключ состоит из символов алфавита и не заканчивается нулевой цифрой
+// (иначе между ним и его префиксом не найдется ключа)
+func validPosition(key string) bool {
+	if key == "" {
+		return true
+	}
+	for _, c := range key {
+		if !strings.ContainsRune(positionDigits, c) {
+			return false
+		}
+	}
+	return key[len(key)-1] != positionDigits[0]
+}
+
+func midpoint(lower, upper string, bounded bool) string {
+	if bounded {
+		// Общий префикс переносится в результат как есть
+		n := 0
+		for n < len(upper) && digitAt(lower, n) == upper[n] {
+			n++
+		}
+		if n > 0 {
+			rest := ""
+			if n < len(lower) {
+				rest = lower[n:]
+			}
+			return upper[:n] + midpoint(rest, upper[n:], true)
+		}
+	}
+
+	digitLower := 0
+	if lower != "" {
+		digitLower = strings.IndexByte(positionDigits, lower[0])
+	}
+	digitUpper := len(positionDigits)
+	if bounded {
+		digitUpper = strings.IndexByte(positionDigits, upper[0])
+	}
+	if digitUpper-digitLower > 1 {
+		return string(positionDigits[(digitLower+digitUpper+1)/2])
+	}
+	if bounded && len(upper) > 1 {
+		return upper[:1]
+	}
+	rest := ""
+	if lower != "" {
+		rest = lower[1:]
+	}
+	return string(positionDigits[digitLower]) + midpoint(rest, "", false)
+}
+
+func digitAt(key string, i int) byte {
+	if i < len(key) {
+		return key[i]
+	}
+	return positionDigits[0]
+}
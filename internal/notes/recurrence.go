@@ -0,0 +1,134 @@
+package notes
+
+import (
+	"ToDo/internal/models"
+	"fmt"
+	"github.com/teambition/rrule-go"
+	"strings"
+	"time"
+)
+
+// maxOccurrences ограничивает предпросмотр повторений, чтобы правило без конца не разворачивалось бесконечно
+const maxOccurrences = 100
+
+// maxRuleIterations ограничивает обход правила от начала серии: rrule разворачивает повторения только
+// последовательно, и старая серия или плотное правило (BYHOUR/BYMINUTE) не должны занимать процессор
+// на каждом запросе. Ежедневному правилу этого хватает на сотни лет
+const maxRuleIterations = 100000
+
+// parseRRule разбирает правило RFC 5545 (FREQ=WEEKLY;BYDAY=MO,WE и т.п.) с началом серии start.
+// Правило разворачивается в зоне location: BYDAY и BYMONTHDAY относятся к местной дате, а не к дате в UTC.
+// Префикс "RRULE:" допускается; DTSTART в самом правиле не поддерживается — началом служит срок заметки
+func parseRRule(rule string, start time.Time, location *time.Location) (*rrule.RRule, error) {
+	rule = strings.TrimPrefix(strings.TrimSpace(rule), "RRULE:")
+	if rule == "" || strings.Contains(rule, "\n") {
+		return nil, ErrInvalidRRule
+	}
+	option, err := rrule.StrToROption(rule)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRRule, err)
+	}
+	if option.Freq > rrule.DAILY { // HOURLY, MINUTELY, SECONDLY — заметкам не нужны и дают миллионы повторений
+		return nil, fmt.Errorf("%w: frequency %s is not supported", ErrInvalidRRule, option.Freq)
+	}
+	option.Dtstart = start.In(location)
+	parsed, err := rrule.NewRRule(*option)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRRule, err)
+	}
+	return parsed, nil
+}
+
+// ruleLocation возвращает зону, в которой разворачивается правило повторения заметки
+func ruleLocation(note *models.Note) (*time.Location, error) {
+	if note.RRuleTZ == "" {
+		return time.UTC, nil
+	}
+	location, err := time.LoadLocation(note.RRuleTZ)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unknown timezone %q", ErrInvalidRRule, note.RRuleTZ)
+	}
+	return location, nil
+}
+
+// noteRule разбирает правило повторения заметки от начала ее серии в зоне правила
+func noteRule(note *models.Note) (*rrule.RRule, error) {
+	location, err := ruleLocation(note)
+	if err != nil {
+		return nil, err
+	}
+	return parseRRule(note.RRule, *note.SeriesStart, location)
+}
+
+// normalizeRecurrence проверяет правило повторения заметки и фиксирует начало серии.
+// Повторяющейся заметке нужен срок выполнения: от него отсчитываются следующие повторения
+func normalizeRecurrence(note *models.Note) error {
+	note.RRule = strings.TrimPrefix(strings.TrimSpace(note.RRule), "RRULE:")
+	note.RRuleTZ = strings.TrimSpace(note.RRuleTZ)
+	if note.RRule == "" {
+		note.SeriesStart = nil
+		note.RRuleTZ = ""
+		return nil
+	}
+	if note.DueAt == nil {
+		return ErrRecurrenceWithoutDueAt
+	}
+	if note.SeriesStart == nil {
+		start := note.DueAt.UTC()
+		note.SeriesStart = &start
+	}
+	_, err := noteRule(note)
+	return err
+}
+
+// nextOccurrence возвращает срок следующего повторения после текущего срока заметки; nil — серия закончилась
+func nextOccurrence(note *models.Note) (*time.Time, error) {
+	rule, err := noteRule(note)
+	if err != nil {
+		return nil, err
+	}
+	var next *time.Time
+	walkRule(rule, func(occurrence time.Time) bool {
+		if occurrence.After(*note.DueAt) {
+			occurrence = occurrence.UTC()
+			next = &occurrence
+			return false
+		}
+		return true
+	})
+	return next, nil
+}
+
+// occurrencesBetween возвращает не более maxOccurrences повторений в интервале [from, to]
+func occurrencesBetween(note *models.Note, from, to time.Time) ([]time.Time, error) {
+	result := []time.Time{}
+	if note.RRule == "" || note.SeriesStart == nil {
+		return result, nil
+	}
+	rule, err := noteRule(note)
+	if err != nil {
+		return nil, err
+	}
+	walkRule(rule, func(occurrence time.Time) bool {
+		if occurrence.After(to) {
+			return false
+		}
+		if !occurrence.Before(from) {
+			result = append(result, occurrence.UTC())
+		}
+		return len(result) < maxOccurrences
+	})
+	return result, nil
+}
+
+// walkRule перебирает повторения по порядку, пока visit возвращает true, но не больше maxRuleIterations.
+// Пропущенные повторения тоже считаются: именно они стоят времени у старых серий
+func walkRule(rule *rrule.RRule, visit func(occurrence time.Time) bool) {
+	next := rule.Iterator()
+	for i := 0; i < maxRuleIterations; i++ {
+		occurrence, ok := next()
+		if !ok || !visit(occurrence) {
+			return
+		}
+	}
+}
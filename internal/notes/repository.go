@@ -2,13 +2,29 @@ package notes
 
 import (
 	"ToDo/internal/models"
+	"ToDo/internal/workflows"
+	"ToDo/pkg/di"
 	"ToDo/pkg/idgen"
 	"context"
 	"errors"
 	"fmt"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
 )
 
+// openNoteCondition отбирает незавершенные заметки: статус не помечен завершающим в workflow заметки
+// (проекта, иначе пользователя); без своего workflow действует стандартный, параметр — его завершающий статус
+const openNoteCondition = `NOT COALESCE((
+	SELECT ws.terminal FROM workflow_statuses ws
+	WHERE ws.name = notes.status AND ws.workflow_id = COALESCE(
+		(SELECT w.id FROM workflows w WHERE w.user_id = notes.user_id AND w.project_id = notes.project_id),
+		(SELECT w.id FROM workflows w WHERE w.user_id = notes.user_id AND w.project_id IS NULL)
+	)
+), NOT EXISTS (
+	SELECT 1 FROM workflows w WHERE w.user_id = notes.user_id AND (w.project_id = notes.project_id OR w.project_id IS NULL)
+) AND notes.status = ?)`
+
 type NoteRepository struct {
 	db *gorm.DB
 }
@@ -24,39 +40,191 @@ func (r *NoteRepository) Create(ctx context.Context, note *models.Note) (*models
 	if note.ID == "" {
 		return nil, fmt.Errorf("generate id: %w", ErrCreateNote)
 	}
+	note.Version = 1
 
-	result := r.db.WithContext(ctx).Create(note)
-	if result.Error != nil {
-		return nil, fmt.Errorf("create note with ID %s: %w", note.ID, result.Error)
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		tags, err := r.resolveTags(tx, note.UserID, note.Tags)
+		if err != nil {
+			return err
+		}
+		// Новая заметка встает в конец колонки своего статуса
+		last, _, err := r.neighbourPosition(tx, note.UserID, note.Status, "", false, "")
+		if err != nil {
+			return err
+		}
+		if note.Position, err = positionBetween(last, ""); err != nil {
+			return err
+		}
+		if err := tx.Omit(clause.Associations).Create(note).Error; err != nil {
+			return err
+		}
+		note.Tags = tags
+		if err := tx.Model(note).Association("Tags").Replace(tags); err != nil {
+			return err
+		}
+		// Пункты чек-листа, переданные вместе с заметкой (например, из шаблона), сохраняются в исходном порядке
+		if len(note.Items) == 0 {
+			return nil
+		}
+		for i := range note.Items {
+			note.Items[i].ID = idgen.GenerateNanoID()
+			if note.Items[i].ID == "" {
+				return ErrCreateNote
+			}
+			note.Items[i].NoteID = note.ID
+			note.Items[i].Position = i
+		}
+		return tx.Create(&note.Items).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create note with ID %s: %w", note.ID, err)
 	}
 	return note, nil
 }
 
-func (r *NoteRepository) GetAll(ctx context.Context, userId string, limit, offset int) ([]models.Note, int64, error) {
+func (r *NoteRepository) GetAll(ctx context.Context, userId string, filter models.NoteFilter) ([]models.Note, int64, error) {
 	var notes []models.Note
 	var totalCount int64
 
-	countQuery := r.db.WithContext(ctx).Model(&models.Note{}).Where("user_id = ?", userId).Count(&totalCount)
-	if countQuery.Error != nil {
-		return nil, 0, fmt.Errorf("get total count for user %s: %w", userId, countQuery.Error)
+	if !filter.SkipTotal {
+		countQuery := r.filtered(ctx, userId, filter).Model(&models.Note{}).Count(&totalCount)
+		if countQuery.Error != nil {
+			return nil, 0, fmt.Errorf("get total count for user %s: %w", userId, countQuery.Error)
+		}
 	}
 
-	query := r.db.WithContext(ctx).
-		Where("user_id = ?", userId).
-		Order("created_at asc").
-		Limit(limit).
-		Offset(offset).
-		Find(&notes) // Исправлено ¬es на &notes
+	query := r.filtered(ctx, userId, filter).
+		Preload("Tags").
+		Order(orderClause(filter.Sort)).
+		Limit(filter.Limit)
+	if filter.After != nil {
+		condition, args := keysetCondition(filter.Sort, filter.After)
+		query = query.Where(condition, args...)
+	} else {
+		query = query.Offset(filter.Offset)
+	}
 
-	if query.Error != nil {
-		return nil, 0, fmt.Errorf("get all notes for user %s: %w", userId, query.Error)
+	if err := query.Find(&notes).Error; err != nil { // Исправлено ¬es на &notes
+		return nil, 0, fmt.Errorf("get all notes for user %s: %w", userId, err)
 	}
 	return notes, totalCount, nil
 }
 
+// Search выполняет полнотекстовый поиск по заметкам пользователя; результаты упорядочены по релевантности
+func (r *NoteRepository) Search(ctx context.Context, userId string, tsQuery string, filter models.NoteFilter) ([]models.NoteSearchResult, int64, error) {
+	type hit struct {
+		ID      string
+		Rank    float64
+		Title   string
+		Snippet string
+	}
+	var hits []hit
+	var totalCount int64
+
+	matches := "notes.search_vector @@ to_tsquery('" + searchConfig + "', ?)"
+	if !filter.SkipTotal {
+		countQuery := r.filtered(ctx, userId, filter).Model(&models.Note{}).Where(matches, tsQuery).Count(&totalCount)
+		if countQuery.Error != nil {
+			return nil, 0, fmt.Errorf("count search results for user %s: %w", userId, countQuery.Error)
+		}
+	}
+
+	query := r.filtered(ctx, userId, filter).
+		Model(&models.Note{}).
+		Select("notes.id, "+
+			"ts_rank(notes.search_vector, to_tsquery('"+searchConfig+"', ?)) AS rank, "+
+			"ts_headline('"+searchConfig+"', notes.title, to_tsquery('"+searchConfig+"', ?), '"+headlineOptions+"') AS title, "+
+			"ts_headline('"+searchConfig+"', notes.content, to_tsquery('"+searchConfig+"', ?), '"+headlineOptions+"') AS snippet",
+			tsQuery, tsQuery, tsQuery).
+		Where(matches, tsQuery).
+		Order("rank desc, notes.id asc").
+		Limit(filter.Limit).
+		Offset(filter.Offset).
+		Scan(&hits)
+	if query.Error != nil {
+		return nil, 0, fmt.Errorf("search notes for user %s: %w", userId, query.Error)
+	}
+
+	if len(hits) == 0 {
+		return []models.NoteSearchResult{}, totalCount, nil
+	}
+	ids := make([]string, 0, len(hits))
+	for _, h := range hits {
+		ids = append(ids, h.ID)
+	}
+	var notes []models.Note
+	if err := r.db.WithContext(ctx).Preload("Tags").Where("id IN ?", ids).Find(&notes).Error; err != nil {
+		return nil, 0, fmt.Errorf("load search results for user %s: %w", userId, err)
+	}
+	byID := make(map[string]models.Note, len(notes))
+	for _, note := range notes {
+		byID[note.ID] = note
+	}
+
+	results := make([]models.NoteSearchResult, 0, len(hits))
+	for _, h := range hits {
+		note, ok := byID[h.ID]
+		if !ok {
+			continue // Заметку удалили между запросами
+		}
+		results = append(results, models.NoteSearchResult{Note: note, Rank: h.Rank, Title: h.Title, Snippet: h.Snippet})
+	}
+	return results, totalCount, nil
+}
+
+// filtered строит базовый запрос с учетом фильтров (без сортировки и пагинации)
+func (r *NoteRepository) filtered(ctx context.Context, userId string, filter models.NoteFilter) *gorm.DB {
+	query := r.db.WithContext(ctx).Where("notes.user_id = ?", userId)
+
+	if len(filter.Tags) > 0 {
+		tagged := r.db.Table("note_tags").
+			Select("note_tags.note_id").
+			Joins("JOIN tags ON tags.id = note_tags.tag_id").
+			Where("tags.user_id = ? AND tags.name IN ?", userId, filter.Tags)
+		if filter.TagMatch == models.TagMatchAll {
+			tagged = tagged.Group("note_tags.note_id").Having("COUNT(DISTINCT tags.name) = ?", len(filter.Tags))
+		}
+		query = query.Where("notes.id IN (?)", tagged)
+	}
+	if filter.DueBefore != nil {
+		query = query.Where("notes.due_at < ?", *filter.DueBefore)
+	}
+	if filter.DueAfter != nil {
+		query = query.Where("notes.due_at > ?", *filter.DueAfter)
+	}
+	if filter.Overdue {
+		query = query.Where("notes.due_at < ?", time.Now()).Where(openNoteCondition, workflows.StatusDone)
+	}
+	if filter.ProjectID == models.InboxProjectID {
+		query = query.Where("notes.project_id IS NULL")
+	} else if filter.ProjectID != "" {
+		query = query.Where("notes.project_id = ?", filter.ProjectID)
+	}
+	return query
+}
+
+// GetDue возвращает незавершенные заметки пользователя со сроком раньше before
+func (r *NoteRepository) GetDue(ctx context.Context, userId string, before time.Time) ([]models.Note, error) {
+	var notes []models.Note
+	query := r.db.WithContext(ctx).
+		Preload("Tags").
+		Where("user_id = ? AND due_at IS NOT NULL AND due_at < ?", userId, before).
+		Where(openNoteCondition, workflows.StatusDone).
+		Order("due_at asc").
+		Find(&notes)
+	if query.Error != nil {
+		return nil, fmt.Errorf("get due notes for user %s: %w", userId, query.Error)
+	}
+	return notes, nil
+}
+
 func (r *NoteRepository) Get(ctx context.Context, noteId string) (*models.Note, error) {
 	var note models.Note
-	result := r.db.WithContext(ctx).Where("id = ?", noteId).First(&note) // Исправлено ¬e на &note
+	result := r.db.WithContext(ctx).
+		Preload("Tags").
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("position asc, created_at asc") }).
+		Where("id = ?", noteId).
+		First(&note) // Исправлено ¬e на &note
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 			return nil, fmt.Errorf("get note by id %s: %w", noteId, ErrNoteNotFound)
@@ -67,14 +235,70 @@ func (r *NoteRepository) Get(ctx context.Context, noteId string) (*models.Note,
 }
 
 func (r *NoteRepository) Update(ctx context.Context, note *models.Note) (*models.Note, error) {
-	result := r.db.WithContext(ctx).Save(note)
+	expectedVersion := note.Version
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Обновление проходит, только если с момента чтения заметку никто не изменил
+		note.Version = expectedVersion + 1
+		result := tx.Model(note).
+			Select("*").
+			Omit(clause.Associations).
+			Where("version = ?", expectedVersion).
+			Updates(note)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			var count int64
+			if err := tx.Model(&models.Note{}).Where("id = ?", note.ID).Count(&count).Error; err != nil {
+				return err
+			}
+			if count == 0 {
+				return ErrNoteNotFound
+			}
+			return ErrVersionConflict
+		}
+		tags, err := r.resolveTags(tx, note.UserID, note.Tags)
+		if err != nil {
+			return err
+		}
+		note.Tags = tags
+		return tx.Model(note).Association("Tags").Replace(tags)
+	})
+	if err != nil {
+		note.Version = expectedVersion
+		return nil, fmt.Errorf("update note with ID %s: %w", note.ID, err)
+	}
+	return note, nil
+}
+
+// UpdateStatus меняет только статус заметки, не затрагивая остальные поля и связи
+func (r *NoteRepository) UpdateStatus(ctx context.Context, noteId string, status string) error {
+	result := r.db.WithContext(ctx).Model(&models.Note{}).Where("id = ?", noteId).Updates(map[string]any{
+		"status":  status,
+		"version": gorm.Expr("version + 1"),
+	})
 	if result.Error != nil {
-		return nil, fmt.Errorf("update note with ID %s: %w", note.ID, result.Error)
+		return fmt.Errorf("update status of note with ID %s: %w", noteId, result.Error)
 	}
 	if result.RowsAffected == 0 {
-		return nil, fmt.Errorf("update note with ID %s: %w", note.ID, ErrNoteNotFound)
+		return fmt.Errorf("update status of note with ID %s: %w", noteId, ErrNoteNotFound)
 	}
-	return note, nil
+	return nil
+}
+
+// UpdateProject переносит заметку в проект; nil — во входящие
+func (r *NoteRepository) UpdateProject(ctx context.Context, noteId string, projectId *string) error {
+	result := r.db.WithContext(ctx).Model(&models.Note{}).Where("id = ?", noteId).Updates(map[string]any{
+		"project_id": projectId,
+		"version":    gorm.Expr("version + 1"),
+	})
+	if result.Error != nil {
+		return fmt.Errorf("move note with ID %s: %w", noteId, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("move note with ID %s: %w", noteId, ErrNoteNotFound)
+	}
+	return nil
 }
 
 func (r *NoteRepository) Delete(ctx context.Context, noteId string) error {
@@ -87,3 +311,597 @@ func (r *NoteRepository) Delete(ctx context.Context, noteId string) error {
 	}
 	return nil
 }
+
+// resolveTags находит теги пользователя по имени и создает недостающие. Параллельный запрос может создать
+// тот же тег одновременно: вставка пропускает конфликт по (user_id, name), а теги перечитываются после нее
+func (r *NoteRepository) resolveTags(tx *gorm.DB, userId string, wanted []models.Tag) ([]models.Tag, error) {
+	tags := make([]models.Tag, 0, len(wanted))
+	if len(wanted) == 0 {
+		return tags, nil
+	}
+
+	names := make([]string, 0, len(wanted))
+	for _, tag := range wanted {
+		names = append(names, tag.Name)
+	}
+	if err := tx.Where("user_id = ? AND name IN ?", userId, names).Find(&tags).Error; err != nil {
+		return nil, fmt.Errorf("find tags: %w", err)
+	}
+	if len(tags) == len(names) {
+		return tags, nil
+	}
+
+	existing := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		existing[tag.Name] = true
+	}
+	missing := make([]models.Tag, 0, len(names)-len(tags))
+	for _, name := range names {
+		if existing[name] {
+			continue
+		}
+		tag := models.Tag{ID: idgen.GenerateNanoID(), Name: name, UserID: userId}
+		if tag.ID == "" {
+			return nil, fmt.Errorf("generate tag id: %w", ErrCreateNote)
+		}
+		missing = append(missing, tag)
+	}
+	err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "name"}},
+		DoNothing: true,
+	}).Create(&missing).Error
+	if err != nil {
+		return nil, fmt.Errorf("create tags: %w", err)
+	}
+
+	tags = tags[:0]
+	if err := tx.Where("user_id = ? AND name IN ?", userId, names).Find(&tags).Error; err != nil {
+		return nil, fmt.Errorf("find created tags: %w", err)
+	}
+	return tags, nil
+}
+
+// DeletePermanently удаляет заметку без возможности восстановления, в том числе из корзины
+func (r *NoteRepository) DeletePermanently(ctx context.Context, noteId string) error {
+	result := r.db.WithContext(ctx).Unscoped().Where("id = ?", noteId).Delete(&models.Note{})
+	if result.Error != nil {
+		return fmt.Errorf("permanently delete note with ID %s: %w", noteId, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("permanently delete note with ID %s: %w", noteId, ErrNoteNotFound)
+	}
+	return nil
+}
+
+// GetTrash возвращает заметки пользователя из корзины, недавно удаленные первыми
+func (r *NoteRepository) GetTrash(ctx context.Context, userId string, limit, offset int) ([]models.Note, int64, error) {
+	var notes []models.Note
+	var totalCount int64
+
+	trashed := func() *gorm.DB {
+		return r.db.WithContext(ctx).Unscoped().Model(&models.Note{}).
+			Where("user_id = ? AND deleted_at IS NOT NULL", userId)
+	}
+	if err := trashed().Count(&totalCount).Error; err != nil {
+		return nil, 0, fmt.Errorf("get trash count for user %s: %w", userId, err)
+	}
+	err := trashed().
+		Preload("Tags").
+		Order("deleted_at desc, id asc").
+		Limit(limit).
+		Offset(offset).
+		Find(&notes).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("get trash for user %s: %w", userId, err)
+	}
+	return notes, totalCount, nil
+}
+
+// GetTrashed возвращает заметку, только если она находится в корзине
+func (r *NoteRepository) GetTrashed(ctx context.Context, noteId string) (*models.Note, error) {
+	var note models.Note
+	result := r.db.WithContext(ctx).Unscoped().
+		Preload("Tags").
+		Where("id = ? AND deleted_at IS NOT NULL", noteId).
+		First(&note)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("get trashed note by id %s: %w", noteId, ErrNoteNotFound)
+		}
+		return nil, fmt.Errorf("get trashed note by id %s: %w", noteId, result.Error)
+	}
+	return &note, nil
+}
+
+// Restore возвращает заметку из корзины
+func (r *NoteRepository) Restore(ctx context.Context, noteId string) error {
+	result := r.db.WithContext(ctx).Unscoped().Model(&models.Note{}).
+		Where("id = ? AND deleted_at IS NOT NULL", noteId).
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		return fmt.Errorf("restore note with ID %s: %w", noteId, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("restore note with ID %s: %w", noteId, ErrNoteNotFound)
+	}
+	return nil
+}
+
+// PurgeDeleted окончательно удаляет заметки, попавшие в корзину раньше before
+func (r *NoteRepository) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", before).
+		Delete(&models.Note{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("purge notes deleted before %s: %w", before, result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+func (r *NoteRepository) GetItems(ctx context.Context, noteId string) ([]models.ChecklistItem, error) {
+	var items []models.ChecklistItem
+	result := r.db.WithContext(ctx).Where("note_id = ?", noteId).Order("position asc, created_at asc").Find(&items)
+	if result.Error != nil {
+		return nil, fmt.Errorf("get checklist items for note %s: %w", noteId, result.Error)
+	}
+	return items, nil
+}
+
+func (r *NoteRepository) GetItem(ctx context.Context, itemId string) (*models.ChecklistItem, error) {
+	var item models.ChecklistItem
+	result := r.db.WithContext(ctx).Where("id = ?", itemId).First(&item)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("get checklist item by id %s: %w", itemId, ErrItemNotFound)
+		}
+		return nil, fmt.Errorf("get checklist item by id %s: %w", itemId, result.Error)
+	}
+	return &item, nil
+}
+
+// CreateItem добавляет пункт в конец списка заметки.
+// Позиция вычисляется под advisory-блокировкой заметки, чтобы параллельные добавления не получили одну позицию
+func (r *NoteRepository) CreateItem(ctx context.Context, item *models.ChecklistItem) (*models.ChecklistItem, error) {
+	item.ID = idgen.GenerateNanoID()
+	if item.ID == "" {
+		return nil, fmt.Errorf("generate id: %w", ErrCreateNote)
+	}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "checklist:"+item.NoteID).Error; err != nil {
+			return err
+		}
+		var maxPosition *int
+		if err := tx.Model(&models.ChecklistItem{}).
+			Where("note_id = ?", item.NoteID).
+			Select("MAX(position)").
+			Scan(&maxPosition).Error; err != nil {
+			return err
+		}
+		if maxPosition != nil {
+			item.Position = *maxPosition + 1
+		}
+		if err := tx.Create(item).Error; err != nil {
+			return err
+		}
+		return touchNote(tx, item.NoteID)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create checklist item for note %s: %w", item.NoteID, err)
+	}
+	return item, nil
+}
+
+func (r *NoteRepository) UpdateItem(ctx context.Context, item *models.ChecklistItem) (*models.ChecklistItem, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Save(item)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrItemNotFound
+		}
+		return touchNote(tx, item.NoteID)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("update checklist item with ID %s: %w", item.ID, err)
+	}
+	return item, nil
+}
+
+func (r *NoteRepository) DeleteItem(ctx context.Context, itemId string) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var item models.ChecklistItem
+		result := tx.Clauses(clause.Returning{}).Where("id = ?", itemId).Delete(&item)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrItemNotFound
+		}
+		return touchNote(tx, item.NoteID)
+	})
+	if err != nil {
+		return fmt.Errorf("delete checklist item with ID %s: %w", itemId, err)
+	}
+	return nil
+}
+
+// touchNote увеличивает версию заметки: пункты чек-листа входят в ее представление, а значит и в ETag
+func touchNote(tx *gorm.DB, noteId string) error {
+	return tx.Model(&models.Note{}).Where("id = ?", noteId).Update("version", gorm.Expr("version + 1")).Error
+}
+
+// CreateRevision сохраняет ревизию со следующим номером и удаляет самые старые, оставляя не более keep (0 — без ограничения)
+func (r *NoteRepository) CreateRevision(ctx context.Context, revision *models.NoteRevision, keep int) error {
+	revision.ID = idgen.GenerateNanoID()
+	if revision.ID == "" {
+		return fmt.Errorf("generate id: %w", ErrCreateNote)
+	}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var last *int
+		if err := tx.Model(&models.NoteRevision{}).
+			Where("note_id = ?", revision.NoteID).
+			Select("MAX(revision)").
+			Scan(&last).Error; err != nil {
+			return err
+		}
+		revision.Revision = 1
+		if last != nil {
+			revision.Revision = *last + 1
+		}
+		if err := tx.Create(revision).Error; err != nil {
+			return err
+		}
+		if keep <= 0 {
+			return nil
+		}
+		return tx.Where("note_id = ? AND revision <= ?", revision.NoteID, revision.Revision-keep).
+			Delete(&models.NoteRevision{}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("create revision for note %s: %w", revision.NoteID, err)
+	}
+	return nil
+}
+
+// GetRevisions возвращает ревизии заметки, новые первыми
+func (r *NoteRepository) GetRevisions(ctx context.Context, noteId string) ([]models.NoteRevision, error) {
+	var revisions []models.NoteRevision
+	result := r.db.WithContext(ctx).Where("note_id = ?", noteId).Order("revision desc").Find(&revisions)
+	if result.Error != nil {
+		return nil, fmt.Errorf("get revisions for note %s: %w", noteId, result.Error)
+	}
+	return revisions, nil
+}
+
+func (r *NoteRepository) GetRevision(ctx context.Context, noteId string, revision int) (*models.NoteRevision, error) {
+	var rev models.NoteRevision
+	result := r.db.WithContext(ctx).Where("note_id = ? AND revision = ?", noteId, revision).First(&rev)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("get revision %d of note %s: %w", revision, noteId, ErrRevisionNotFound)
+		}
+		return nil, fmt.Errorf("get revision %d of note %s: %w", revision, noteId, result.Error)
+	}
+	return &rev, nil
+}
+
+// WithTransaction выполняет fn с репозиторием, привязанным к транзакции.
+// Вызов внутри уже открытой транзакции создает точку сохранения
+func (r *NoteRepository) WithTransaction(ctx context.Context, fn func(repo di.INoteRepository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&NoteRepository{db: tx})
+	})
+}
+
+// GetBoard возвращает заметки пользователя для доски в порядке позиций
+func (r *NoteRepository) GetBoard(ctx context.Context, userId string, filter models.NoteFilter) ([]models.Note, error) {
+	var notes []models.Note
+	result := r.filtered(ctx, userId, filter).
+		Preload("Tags").
+		Order(`notes.position COLLATE "C" asc, notes.id asc`).
+		Limit(filter.Limit).
+		Find(&notes)
+	if result.Error != nil {
+		return nil, fmt.Errorf("get board for user %s: %w", userId, result.Error)
+	}
+	return notes, nil
+}
+
+// NeighbourPosition ищет в колонке статуса ближайшую позицию после position (next) или перед ней.
+// Пустая position при next == false означает последнюю заметку колонки; excludeId не учитывается
+func (r *NoteRepository) NeighbourPosition(ctx context.Context, userId, status, position string, next bool, excludeId string) (string, bool, error) {
+	return r.neighbourPosition(r.db.WithContext(ctx), userId, status, position, next, excludeId)
+}
+
+func (r *NoteRepository) neighbourPosition(db *gorm.DB, userId, status, position string, next bool, excludeId string) (string, bool, error) {
+	query := db.Model(&models.Note{}).
+		Where("user_id = ? AND status = ? AND position <> ''", userId, status)
+	if excludeId != "" {
+		query = query.Where("id <> ?", excludeId)
+	}
+	switch {
+	case next:
+		query = query.Where(`position COLLATE "C" > ?`, position).Order(`position COLLATE "C" asc`)
+	case position != "":
+		query = query.Where(`position COLLATE "C" < ?`, position).Order(`position COLLATE "C" desc`)
+	default:
+		query = query.Order(`position COLLATE "C" desc`)
+	}
+
+	var positions []string
+	if err := query.Limit(1).Pluck("position", &positions).Error; err != nil {
+		return "", false, fmt.Errorf("get neighbour position for user %s: %w", userId, err)
+	}
+	if len(positions) == 0 {
+		return "", false, nil
+	}
+	return positions[0], true, nil
+}
+
+// SaveShare выдает пользователю доступ к заметке или меняет уровень уже выданного доступа
+func (r *NoteRepository) SaveShare(ctx context.Context, share *models.NoteShare) (*models.NoteShare, error) {
+	share.ID = idgen.GenerateNanoID()
+	if share.ID == "" {
+		return nil, fmt.Errorf("generate id: %w", ErrCreateNote)
+	}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "note_id"}, {Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"permission", "updated_at"}),
+		}).Create(share).Error; err != nil {
+			return err
+		}
+		return tx.Preload("User").Where("note_id = ? AND user_id = ?", share.NoteID, share.UserID).First(share).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("share note %s with user %s: %w", share.NoteID, share.UserID, err)
+	}
+	return share, nil
+}
+
+func (r *NoteRepository) GetShares(ctx context.Context, noteId string) ([]models.NoteShare, error) {
+	var shares []models.NoteShare
+	result := r.db.WithContext(ctx).Preload("User").Where("note_id = ?", noteId).Order("created_at asc").Find(&shares)
+	if result.Error != nil {
+		return nil, fmt.Errorf("get shares of note %s: %w", noteId, result.Error)
+	}
+	return shares, nil
+}
+
+func (r *NoteRepository) GetShare(ctx context.Context, noteId, userId string) (*models.NoteShare, error) {
+	var share models.NoteShare
+	result := r.db.WithContext(ctx).Where("note_id = ? AND user_id = ?", noteId, userId).First(&share)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("get share of note %s for user %s: %w", noteId, userId, ErrShareNotFound)
+		}
+		return nil, fmt.Errorf("get share of note %s for user %s: %w", noteId, userId, result.Error)
+	}
+	return &share, nil
+}
+
+func (r *NoteRepository) DeleteShare(ctx context.Context, noteId, userId string) error {
+	result := r.db.WithContext(ctx).Where("note_id = ? AND user_id = ?", noteId, userId).Delete(&models.NoteShare{})
+	if result.Error != nil {
+		return fmt.Errorf("delete share of note %s for user %s: %w", noteId, userId, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("delete share of note %s for user %s: %w", noteId, userId, ErrShareNotFound)
+	}
+	return nil
+}
+
+// GetSharedWith возвращает заметки не из корзины, к которым пользователю открыт доступ, новые доступы первыми
+func (r *NoteRepository) GetSharedWith(ctx context.Context, userId string, limit, offset int) ([]models.SharedNote, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.NoteShare{}).
+		Joins("JOIN notes ON notes.id = note_shares.note_id AND notes.deleted_at IS NULL").
+		Where("note_shares.user_id = ?", userId)
+
+	var totalCount int64
+	if err := query.Count(&totalCount).Error; err != nil {
+		return nil, 0, fmt.Errorf("count notes shared with user %s: %w", userId, err)
+	}
+	var shares []models.NoteShare
+	if err := query.Order("note_shares.created_at desc").Limit(limit).Offset(offset).Find(&shares).Error; err != nil {
+		return nil, 0, fmt.Errorf("get notes shared with user %s: %w", userId, err)
+	}
+	if len(shares) == 0 {
+		return []models.SharedNote{}, totalCount, nil
+	}
+
+	ids := make([]string, 0, len(shares))
+	for _, share := range shares {
+		ids = append(ids, share.NoteID)
+	}
+	var notes []models.Note
+	if err := r.db.WithContext(ctx).Preload("Tags").Where("id IN ?", ids).Find(&notes).Error; err != nil {
+		return nil, 0, fmt.Errorf("get notes shared with user %s: %w", userId, err)
+	}
+	byId := make(map[string]models.Note, len(notes))
+	for _, note := range notes {
+		byId[note.ID] = note
+	}
+
+	result := make([]models.SharedNote, 0, len(shares))
+	for _, share := range shares {
+		if note, ok := byId[share.NoteID]; ok {
+			result = append(result, models.SharedNote{Note: note, Permission: share.Permission})
+		}
+	}
+	return result, totalCount, nil
+}
+
+func (r *NoteRepository) CreateLink(ctx context.Context, link *models.NoteLink) (*models.NoteLink, error) {
+	link.ID = idgen.GenerateNanoID()
+	if link.ID == "" {
+		return nil, fmt.Errorf("generate id: %w", ErrCreateNote)
+	}
+	if err := r.db.WithContext(ctx).Create(link).Error; err != nil {
+		return nil, fmt.Errorf("create link for note %s: %w", link.NoteID, err)
+	}
+	return link, nil
+}
+
+func (r *NoteRepository) GetLinks(ctx context.Context, noteId string) ([]models.NoteLink, error) {
+	var links []models.NoteLink
+	if err := r.db.WithContext(ctx).Where("note_id = ?", noteId).Order("created_at asc").Find(&links).Error; err != nil {
+		return nil, fmt.Errorf("get links of note %s: %w", noteId, err)
+	}
+	return links, nil
+}
+
+func (r *NoteRepository) GetLinkByToken(ctx context.Context, token string) (*models.NoteLink, error) {
+	var link models.NoteLink
+	result := r.db.WithContext(ctx).Where("token = ?", token).First(&link)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("get link by token: %w", ErrLinkNotFound)
+		}
+		return nil, fmt.Errorf("get link by token: %w", result.Error)
+	}
+	return &link, nil
+}
+
+func (r *NoteRepository) DeleteLink(ctx context.Context, noteId, linkId string) error {
+	result := r.db.WithContext(ctx).Where("id = ? AND note_id = ?", linkId, noteId).Delete(&models.NoteLink{})
+	if result.Error != nil {
+		return fmt.Errorf("delete link %s: %w", linkId, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("delete link %s: %w", linkId, ErrLinkNotFound)
+	}
+	return nil
+}
+
+// RecordLinkAccess увеличивает счетчик открытий ссылки одним UPDATE, без гонок между запросами
+func (r *NoteRepository) RecordLinkAccess(ctx context.Context, linkId string, at time.Time) error {
+	result := r.db.WithContext(ctx).Model(&models.NoteLink{}).Where("id = ?", linkId).Updates(map[string]any{
+		"access_count":     gorm.Expr("access_count + 1"),
+		"last_accessed_at": at,
+	})
+	if result.Error != nil {
+		return fmt.Errorf("record access to link %s: %w", linkId, result.Error)
+	}
+	return nil
+}
+
+// AddDependency сохраняет связь «noteId заблокирована blockerId», если она не замыкает цикл.
+// Проверка и вставка идут под advisory-блокировкой владельца, чтобы параллельные запросы не обошли проверку
+func (r *NoteRepository) AddDependency(ctx context.Context, userId, noteId, blockerId string) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "dependencies:"+userId).Error; err != nil {
+			return err
+		}
+		// Цикл появится, если blockerId уже (транзитивно) заблокирована заметкой noteId
+		var cycle bool
+		err := tx.Raw(`WITH RECURSIVE chain(id) AS (
+				SELECT blocker_id FROM dependencies WHERE note_id = ?
+				UNION
+				SELECT d.blocker_id FROM dependencies d JOIN chain ON d.note_id = chain.id
+			)
+			SELECT EXISTS (SELECT 1 FROM chain WHERE id = ?)`, blockerId, noteId).Scan(&cycle).Error
+		if err != nil {
+			return err
+		}
+		if cycle {
+			return ErrDependencyCycle
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.Dependency{NoteID: noteId, BlockerID: blockerId}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("add dependency %s -> %s: %w", noteId, blockerId, err)
+	}
+	return nil
+}
+
+func (r *NoteRepository) DeleteDependency(ctx context.Context, noteId, blockerId string) error {
+	result := r.db.WithContext(ctx).Where("note_id = ? AND blocker_id = ?", noteId, blockerId).Delete(&models.Dependency{})
+	if result.Error != nil {
+		return fmt.Errorf("delete dependency %s -> %s: %w", noteId, blockerId, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("delete dependency %s -> %s: %w", noteId, blockerId, ErrDependencyNotFound)
+	}
+	return nil
+}
+
+// GetOpenBlockers возвращает незавершенные заметки, напрямую блокирующие noteId; заметки в корзине не блокируют
+func (r *NoteRepository) GetOpenBlockers(ctx context.Context, noteId string) ([]models.Note, error) {
+	var blockers []models.Note
+	result := r.db.WithContext(ctx).
+		Where("id IN (SELECT blocker_id FROM dependencies WHERE note_id = ?)", noteId).
+		Where(openNoteCondition, workflows.StatusDone).
+		Order("created_at asc").
+		Find(&blockers)
+	if result.Error != nil {
+		return nil, fmt.Errorf("get blockers of note %s: %w", noteId, result.Error)
+	}
+	return blockers, nil
+}
+
+// GetDependencyGraph собирает связи, достижимые из noteId в обе стороны, и заметки на их концах.
+// Права userId на заметки графа загружаются одним запросом к доступам
+func (r *NoteRepository) GetDependencyGraph(ctx context.Context, noteId, userId string) (*models.DependencyGraph, error) {
+	var edges []models.Dependency
+	err := r.db.WithContext(ctx).Raw(`WITH RECURSIVE
+			upstream(note_id, blocker_id, created_at) AS (
+				SELECT note_id, blocker_id, created_at FROM dependencies WHERE note_id = ?
+				UNION
+				SELECT d.note_id, d.blocker_id, d.created_at FROM dependencies d JOIN upstream u ON d.note_id = u.blocker_id
+			),
+			downstream(note_id, blocker_id, created_at) AS (
+				SELECT note_id, blocker_id, created_at FROM dependencies WHERE blocker_id = ?
+				UNION
+				SELECT d.note_id, d.blocker_id, d.created_at FROM dependencies d JOIN downstream u ON d.blocker_id = u.note_id
+			)
+		SELECT * FROM upstream UNION SELECT * FROM downstream
+		ORDER BY created_at, note_id, blocker_id`, noteId, noteId).Scan(&edges).Error
+	if err != nil {
+		return nil, fmt.Errorf("get dependency graph of note %s: %w", noteId, err)
+	}
+
+	ids := []string{noteId}
+	for _, edge := range edges {
+		ids = append(ids, edge.NoteID, edge.BlockerID)
+	}
+	var nodes []models.Note
+	if err := r.db.WithContext(ctx).Where("id IN ?", ids).Order("created_at asc").Find(&nodes).Error; err != nil {
+		return nil, fmt.Errorf("get dependency graph of note %s: %w", noteId, err)
+	}
+
+	// Связи с заметками из корзины в граф не попадают
+	present := make(map[string]bool, len(nodes))
+	for _, node := range nodes {
+		present[node.ID] = true
+	}
+	graph := &models.DependencyGraph{Nodes: nodes, Edges: make([]models.Dependency, 0, len(edges)), Permissions: make(map[string]string, len(nodes))}
+	for _, edge := range edges {
+		if present[edge.NoteID] && present[edge.BlockerID] {
+			graph.Edges = append(graph.Edges, edge)
+		}
+	}
+
+	foreign := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		if node.UserID == userId {
+			graph.Permissions[node.ID] = models.PermissionOwner
+		} else {
+			foreign = append(foreign, node.ID)
+		}
+	}
+	if len(foreign) > 0 {
+		var shares []models.NoteShare
+		if err := r.db.WithContext(ctx).Where("note_id IN ? AND user_id = ?", foreign, userId).Find(&shares).Error; err != nil {
+			return nil, fmt.Errorf("get dependency graph of note %s: %w", noteId, err)
+		}
+		for _, share := range shares {
+			graph.Permissions[share.NoteID] = share.Permission
+		}
+	}
+	return graph, nil
+}
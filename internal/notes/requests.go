@@ -2,12 +2,19 @@ package notes
 
 import (
 	"ToDo/internal/models"
+	"ToDo/internal/projects"
+	"ToDo/internal/tags"
+	"ToDo/internal/user"
 	"ToDo/pkg/middleware"
 	"ToDo/pkg/req"
 	"ToDo/pkg/res"
+	"context"
 	"errors"
+	"fmt"
+	"github.com/pmezard/go-difflib/difflib"
 	"net/http"
 	"strconv"
+	"time"
 )
 
 // Вспомогательные функции
@@ -37,6 +44,274 @@ func parsePagination(r *http.Request) (int, int) {
 	return limit, offset
 }
 
+func parseNoteFilter(r *http.Request) (models.NoteFilter, error) {
+	limit, offset := parsePagination(r)
+	query := r.URL.Query()
+	filter := models.NoteFilter{
+		Limit:     limit,
+		Offset:    offset,
+		Tags:      query["tag"],
+		TagMatch:  query.Get("tag_match"),
+		ProjectID: query.Get("project_id"),
+	}
+
+	var err error
+	if filter.Sort, err = parseSort(query.Get("sort")); err != nil {
+		return filter, errors.New("sort must be a comma-separated list of: created_at, updated_at, due_at, title, status, priority")
+	}
+	if cursor := query.Get("cursor"); cursor != "" {
+		if filter.After, err = decodeCursor(cursor, filter.Sort); err != nil {
+			return filter, errors.New("invalid cursor")
+		}
+		filter.Offset = 0
+	}
+	if includeTotal := query.Get("include_total"); includeTotal != "" {
+		include, err := strconv.ParseBool(includeTotal)
+		if err != nil {
+			return filter, errors.New("include_total must be a boolean")
+		}
+		filter.SkipTotal = !include
+	}
+	if filter.DueBefore, err = parseTimeParam(r, "due_before"); err != nil {
+		return filter, err
+	}
+	if filter.DueAfter, err = parseTimeParam(r, "due_after"); err != nil {
+		return filter, err
+	}
+	if overdue := query.Get("overdue"); overdue != "" {
+		if filter.Overdue, err = strconv.ParseBool(overdue); err != nil {
+			return filter, errors.New("overdue must be a boolean")
+		}
+	}
+	return filter, nil
+}
+
+// parseTimeParam разбирает необязательный параметр запроса в формате RFC 3339
+func parseTimeParam(r *http.Request, name string) (*time.Time, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be an RFC 3339 timestamp", name)
+	}
+	return &parsed, nil
+}
+
+func newGetNoteResponse(note *models.Note) GetNoteResponse {
+	tagNames := make([]string, 0, len(note.Tags))
+	for _, tag := range note.Tags {
+		tagNames = append(tagNames, tag.Name)
+	}
+	items := note.Items
+	if items == nil {
+		items = []models.ChecklistItem{}
+	}
+	return GetNoteResponse{
+		ID:        note.ID,
+		Title:     note.Title,
+		Content:   note.Content,
+		Status:    note.Status,
+		Priority:  note.Priority,
+		Tags:      tagNames,
+		DueAt:     note.DueAt,
+		Items:     items,
+		ProjectID: note.ProjectID,
+		UserID:    note.UserID,
+		Version:   note.Version,
+		RRule:     note.RRule,
+		RRuleTZ:   note.RRuleTZ,
+		SeriesID:  note.SeriesID,
+		Position:  note.Position,
+		CreatedAt: note.CreatedAt,
+		UpdatedAt: note.UpdatedAt,
+	}
+}
+
+// loadNote загружает заметку из пути запроса и проверяет, что у пользователя есть доступ не ниже required
+// (владелец или получатель доступа через шаринг). При ошибке ответ уже записан и возвращается nil
+func (h *NoteHandler) loadNote(w http.ResponseWriter, r *http.Request, required string) *models.Note {
+	noteId := r.PathValue("id")
+	if noteId == "" {
+		res.JsonResponse(w, res.ErrorResponse{Error: "note id is required"}, http.StatusBadRequest)
+		return nil
+	}
+	userId := getUserId(r)
+	if userId == "" {
+		res.JsonResponse(w, res.ErrorResponse{Error: "unauthorized"}, http.StatusUnauthorized)
+		return nil
+	}
+
+	note, err := h.NoteService.GetNote(r.Context(), noteId)
+	if err != nil {
+		if errors.Is(err, ErrNoteNotFound) {
+			res.JsonResponse(w, res.ErrorResponse{Error: "note not found"}, http.StatusNotFound)
+		} else {
+			res.JsonResponse(w, res.ErrorResponse{Error: "failed to get note by id"}, http.StatusInternalServerError)
+		}
+		return nil
+	}
+	permission, err := h.NoteService.GetPermission(r.Context(), note, userId)
+	if err != nil {
+		res.JsonResponse(w, res.ErrorResponse{Error: "failed to get note by id"}, http.StatusInternalServerError)
+		return nil
+	}
+	if permission == "" {
+		res.JsonResponse(w, res.ErrorResponse{Error: "unauthorized"}, http.StatusUnauthorized)
+		return nil
+	}
+	if !models.PermissionAllows(permission, required) {
+		res.JsonResponse(w, res.ErrorResponse{Error: ErrPermissionDenied.Error()}, http.StatusForbidden)
+		return nil
+	}
+	return note
+}
+
+// checkProject проверяет, что проект существует и принадлежит пользователю;
+// forWrite дополнительно запрещает архивные проекты. При ошибке ответ уже записан
+func (h *NoteHandler) checkProject(w http.ResponseWriter, r *http.Request, userId, projectId string, forWrite bool) bool {
+	status, message := h.projectError(r.Context(), userId, projectId, forWrite)
+	if status != 0 {
+		res.JsonResponse(w, res.ErrorResponse{Error: message}, status)
+		return false
+	}
+	return true
+}
+
+// projectError — проверка checkProject без записи ответа: возвращает HTTP-статус и сообщение, 0 — проект подходит
+func (h *NoteHandler) projectError(ctx context.Context, userId, projectId string, forWrite bool) (int, string) {
+	project, err := h.ProjectService.GetProject(ctx, projectId)
+	if err != nil {
+		if errors.Is(err, projects.ErrProjectNotFound) {
+			return http.StatusNotFound, "project not found"
+		}
+		return http.StatusInternalServerError, "failed to get project by id"
+	}
+	if project.UserID != userId {
+		return http.StatusNotFound, "project not found"
+	}
+	if forWrite && project.Archived {
+		return http.StatusConflict, projects.ErrProjectArchived.Error()
+	}
+	return 0, ""
+}
+
+// loadNoteItem загружает пункт чек-листа из пути запроса и проверяет, что он относится к заметке
+func (h *NoteHandler) loadNoteItem(w http.ResponseWriter, r *http.Request, note *models.Note) *models.ChecklistItem {
+	itemId := r.PathValue("itemId")
+	item, err := h.NoteService.GetChecklistItem(r.Context(), itemId)
+	if err != nil {
+		if errors.Is(err, ErrItemNotFound) {
+			res.JsonResponse(w, res.ErrorResponse{Error: "checklist item not found"}, http.StatusNotFound)
+		} else {
+			res.JsonResponse(w, res.ErrorResponse{Error: "failed to get checklist item"}, http.StatusInternalServerError)
+		}
+		return nil
+	}
+	if item.NoteID != note.ID {
+		res.JsonResponse(w, res.ErrorResponse{Error: "checklist item not found"}, http.StatusNotFound)
+		return nil
+	}
+	return item
+}
+
+// loadNoteRevision загружает ревизию заметки по номеру из пути запроса
+func (h *NoteHandler) loadNoteRevision(w http.ResponseWriter, r *http.Request, note *models.Note) *models.NoteRevision {
+	revision, err := strconv.Atoi(r.PathValue("rev"))
+	if err != nil || revision < 1 {
+		res.JsonResponse(w, res.ErrorResponse{Error: "invalid revision number"}, http.StatusBadRequest)
+		return nil
+	}
+	rev, err := h.NoteService.GetRevision(r.Context(), note.ID, revision)
+	if err != nil {
+		if errors.Is(err, ErrRevisionNotFound) {
+			res.JsonResponse(w, res.ErrorResponse{Error: "revision not found"}, http.StatusNotFound)
+		} else {
+			res.JsonResponse(w, res.ErrorResponse{Error: "failed to get revision"}, http.StatusInternalServerError)
+		}
+		return nil
+	}
+	return rev
+}
+
+// unifiedDiff строит unified diff между текстом ревизии и текущим текстом заметки
+func unifiedDiff(name, from, to string) string {
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(from),
+		B:        difflib.SplitLines(to),
+		FromFile: name + " (revision)",
+		ToFile:   name + " (current)",
+		Context:  3,
+	})
+	if err != nil {
+		return ""
+	}
+	return diff
+}
+
+// writeTransitionError отвечает 409 со списком статусов, допустимых из текущего
+func writeTransitionError(w http.ResponseWriter, err *TransitionError) {
+	res.JsonResponse(w, TransitionErrorResponse{
+		Error:   ErrInvalidTransition.Error(),
+		From:    err.From,
+		To:      err.To,
+		Allowed: err.Allowed,
+	}, http.StatusConflict)
+}
+
+// writeBlockedError отвечает 409 со списком незавершенных блокирующих заметок
+func writeBlockedError(w http.ResponseWriter, err *BlockedError) {
+	response := BlockedErrorResponse{Error: ErrNoteBlocked.Error(), Blockers: make([]DependencyNode, 0, len(err.Blockers))}
+	for _, blocker := range err.Blockers {
+		response.Blockers = append(response.Blockers, DependencyNode{
+			ID:         blocker.ID,
+			Title:      blocker.Title,
+			Status:     blocker.Status,
+			Accessible: true,
+		})
+	}
+	res.JsonResponse(w, response, http.StatusConflict)
+}
+
+// applyNoteUpdate переносит в заметку поля запроса на обновление; обновляются только непустые поля
+func applyNoteUpdate(note *models.Note, body *UpdateNoteRequest) {
+	if body.Title != "" {
+		note.Title = body.Title
+	}
+	if body.Content != "" {
+		note.Content = body.Content
+	}
+	if body.Status != "" {
+		note.Status = body.Status
+	}
+	if body.Priority != "" {
+		note.Priority = body.Priority
+	}
+	if body.Tags != nil {
+		note.Tags = tagsFromNames(body.Tags)
+	}
+	if body.ClearDueAt {
+		note.DueAt = nil
+	} else if body.DueAt != nil {
+		note.DueAt = body.DueAt
+	}
+	if body.RRule != nil {
+		note.RRule = *body.RRule
+	}
+	if body.RRuleTZ != nil {
+		note.RRuleTZ = *body.RRuleTZ
+	}
+}
+
+func tagsFromNames(names []string) []models.Tag {
+	result := make([]models.Tag, 0, len(names))
+	for _, name := range names {
+		result = append(result, models.Tag{Name: name})
+	}
+	return result
+}
+
 func (h *NoteHandler) CreateNote() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		body, err := req.HandleBody[CreateNoteRequest](&w, r)
@@ -49,24 +324,41 @@ func (h *NoteHandler) CreateNote() http.HandlerFunc {
 			res.JsonResponse(w, res.ErrorResponse{Error: "unauthorized"}, http.StatusUnauthorized)
 			return
 		}
+		if body.ProjectID != nil && !h.checkProject(w, r, userID, *body.ProjectID, true) {
+			return
+		}
 
 		note := &models.Note{
-			Title:   body.Title,
-			Content: body.Content,
-			Status:  body.Status,
-			UserID:  userID,
+			Title:     body.Title,
+			Content:   body.Content,
+			Status:    body.Status,
+			Priority:  body.Priority,
+			Tags:      tagsFromNames(body.Tags),
+			DueAt:     body.DueAt,
+			ProjectID: body.ProjectID,
+			RRule:     body.RRule,
+			RRuleTZ:   body.RRuleTZ,
+			UserID:    userID,
 		}
 
 		createdNote, err := h.NoteService.CreateNote(r.Context(), note)
 		if err != nil {
-			if errors.Is(err, ErrInvalidNoteStatus) {
+			switch {
+			case errors.Is(err, ErrInvalidNoteStatus):
 				res.JsonResponse(w, res.ErrorResponse{Error: "invalid note status"}, http.StatusBadRequest)
-			} else {
+			case errors.Is(err, ErrInvalidPriority):
+				res.JsonResponse(w, res.ErrorResponse{Error: "invalid note priority"}, http.StatusBadRequest)
+			case errors.Is(err, tags.ErrInvalidTagName):
+				res.JsonResponse(w, res.ErrorResponse{Error: "invalid tag name"}, http.StatusBadRequest)
+			case errors.Is(err, ErrInvalidRRule), errors.Is(err, ErrRecurrenceWithoutDueAt):
+				res.JsonResponse(w, res.ErrorResponse{Error: err.Error()}, http.StatusBadRequest)
+			default:
 				res.JsonResponse(w, res.ErrorResponse{Error: "failed to create note"}, http.StatusInternalServerError)
 			}
 			return
 		}
 
+		w.Header().Set("ETag", noteETag(createdNote))
 		res.JsonResponse(w, createdNote, http.StatusCreated)
 	}
 }
@@ -79,155 +371,1044 @@ func (h *NoteHandler) GetAllNotes() http.HandlerFunc {
 			return
 		}
 
-		limit, offset := parsePagination(r)
-		notes, totalCount, err := h.NoteService.GetAllNotes(r.Context(), userId, limit, offset)
+		filter, err := parseNoteFilter(r)
 		if err != nil {
-			res.JsonResponse(w, res.ErrorResponse{Error: "failed to get notes"}, http.StatusInternalServerError)
+			res.JsonResponse(w, res.ErrorResponse{Error: err.Error()}, http.StatusBadRequest)
+			return
+		}
+		h.listNotes(w, r, userId, filter)
+	}
+}
+
+// GetProjectNotes возвращает заметки проекта с теми же фильтрами и пагинацией, что и GET /notes
+func (h *NoteHandler) GetProjectNotes() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userId := getUserId(r)
+		if userId == "" {
+			res.JsonResponse(w, res.ErrorResponse{Error: "unauthorized"}, http.StatusUnauthorized)
 			return
 		}
 
-		res.JsonResponse(w, GetAllNotesResponse{
-			Notes:      notes,
-			TotalCount: totalCount,
-			Limit:      limit,
-			Offset:     offset,
-		}, http.StatusOK)
+		filter, err := parseNoteFilter(r)
+		if err != nil {
+			res.JsonResponse(w, res.ErrorResponse{Error: err.Error()}, http.StatusBadRequest)
+			return
+		}
+		projectId := r.PathValue("id")
+		if !h.checkProject(w, r, userId, projectId, false) {
+			return
+		}
+		filter.ProjectID = projectId
+		h.listNotes(w, r, userId, filter)
 	}
 }
 
-func (h *NoteHandler) GetNote() http.HandlerFunc {
+func (h *NoteHandler) listNotes(w http.ResponseWriter, r *http.Request, userId string, filter models.NoteFilter) {
+	notes, totalCount, err := h.NoteService.GetAllNotes(r.Context(), userId, filter)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidTagMatch):
+			res.JsonResponse(w, res.ErrorResponse{Error: "tag_match must be any or all"}, http.StatusBadRequest)
+		case errors.Is(err, tags.ErrInvalidTagName):
+			res.JsonResponse(w, res.ErrorResponse{Error: "invalid tag name"}, http.StatusBadRequest)
+		default:
+			res.JsonResponse(w, res.ErrorResponse{Error: "failed to get notes"}, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	response := GetAllNotesResponse{
+		Notes:  notes,
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+		Sort:   formatSort(filter.Sort),
+	}
+	if !filter.SkipTotal {
+		response.TotalCount = &totalCount
+	}
+	// Полная страница — возможно, есть следующая
+	if len(notes) == filter.Limit {
+		response.NextCursor = encodeCursor(filter.Sort, &notes[len(notes)-1])
+	}
+	res.JsonResponse(w, response, http.StatusOK)
+}
+
+func (h *NoteHandler) SearchNotes() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		noteId := r.PathValue("id")
-		if noteId == "" {
-			res.JsonResponse(w, res.ErrorResponse{Error: "note id is required"}, http.StatusBadRequest)
+		userId := getUserId(r)
+		if userId == "" {
+			res.JsonResponse(w, res.ErrorResponse{Error: "unauthorized"}, http.StatusUnauthorized)
+			return
+		}
+
+		query := r.URL.Query().Get("q")
+		filter, err := parseNoteFilter(r)
+		if err != nil {
+			res.JsonResponse(w, res.ErrorResponse{Error: err.Error()}, http.StatusBadRequest)
+			return
+		}
+
+		results, totalCount, err := h.NoteService.SearchNotes(r.Context(), userId, query, filter)
+		if err != nil {
+			switch {
+			case errors.Is(err, ErrEmptySearchQuery):
+				res.JsonResponse(w, res.ErrorResponse{Error: "query parameter q is required"}, http.StatusBadRequest)
+			case errors.Is(err, tags.ErrInvalidTagName):
+				res.JsonResponse(w, res.ErrorResponse{Error: "invalid tag name"}, http.StatusBadRequest)
+			default:
+				res.JsonResponse(w, res.ErrorResponse{Error: "failed to search notes"}, http.StatusInternalServerError)
+			}
 			return
 		}
 
+		response := SearchNotesResponse{
+			Results: results,
+			Query:   query,
+			Limit:   filter.Limit,
+			Offset:  filter.Offset,
+		}
+		if !filter.SkipTotal {
+			response.TotalCount = &totalCount
+		}
+		res.JsonResponse(w, response, http.StatusOK)
+	}
+}
+
+func (h *NoteHandler) GetUpcomingNotes() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
 		userId := getUserId(r)
 		if userId == "" {
 			res.JsonResponse(w, res.ErrorResponse{Error: "unauthorized"}, http.StatusUnauthorized)
 			return
 		}
 
+		location := time.UTC
+		if tz := r.URL.Query().Get("tz"); tz != "" {
+			loaded, err := time.LoadLocation(tz)
+			if err != nil {
+				res.JsonResponse(w, res.ErrorResponse{Error: "invalid timezone"}, http.StatusBadRequest)
+				return
+			}
+			location = loaded
+		}
+
+		upcoming, err := h.NoteService.GetUpcomingNotes(r.Context(), userId, time.Now().In(location))
+		if err != nil {
+			res.JsonResponse(w, res.ErrorResponse{Error: "failed to get upcoming notes"}, http.StatusInternalServerError)
+			return
+		}
+
+		res.JsonResponse(w, GetUpcomingNotesResponse{
+			UpcomingNotes: *upcoming,
+			Timezone:      location.String(),
+		}, http.StatusOK)
+	}
+}
+
+func (h *NoteHandler) GetNote() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		note := h.loadNote(w, r, models.PermissionViewer)
+		if note == nil {
+			return
+		}
+		w.Header().Set("ETag", noteETag(note))
+		if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" && etagMatches(ifNoneMatch, noteETag(note), true) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		res.JsonResponse(w, newGetNoteResponse(note), http.StatusOK)
+
+	}
+
+}
+
+func (h *NoteHandler) UpdateNote() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := req.HandleBody[UpdateNoteRequest](&w, r)
+		if err != nil {
+			return
+		}
+		existingNote := h.loadNote(w, r, models.PermissionEditor)
+		if existingNote == nil {
+			return
+		}
+		if !h.checkIfMatch(w, r, existingNote) {
+			return
+		}
+		applyNoteUpdate(existingNote, body)
+		// ?force=true завершает заметку, даже если ее блокируют незавершенные заметки
+		force, _ := strconv.ParseBool(r.URL.Query().Get("force"))
+
+		updatedNote, err := h.NoteService.UpdateNote(r.Context(), existingNote, force)
+		if err != nil {
+			var transitionErr *TransitionError
+			var blockedErr *BlockedError
+			switch {
+			case errors.Is(err, ErrInvalidNoteStatus):
+				res.JsonResponse(w, res.ErrorResponse{Error: "invalid note status"}, http.StatusBadRequest)
+			case errors.Is(err, ErrInvalidPriority):
+				res.JsonResponse(w, res.ErrorResponse{Error: "invalid note priority"}, http.StatusBadRequest)
+			case errors.Is(err, tags.ErrInvalidTagName):
+				res.JsonResponse(w, res.ErrorResponse{Error: "invalid tag name"}, http.StatusBadRequest)
+			case errors.Is(err, ErrInvalidRRule), errors.Is(err, ErrRecurrenceWithoutDueAt):
+				res.JsonResponse(w, res.ErrorResponse{Error: err.Error()}, http.StatusBadRequest)
+			case errors.Is(err, ErrVersionConflict):
+				res.JsonResponse(w, res.ErrorResponse{Error: ErrVersionConflict.Error()}, http.StatusPreconditionFailed)
+			case errors.As(err, &transitionErr):
+				writeTransitionError(w, transitionErr)
+			case errors.As(err, &blockedErr):
+				writeBlockedError(w, blockedErr)
+			default:
+				res.JsonResponse(w, res.ErrorResponse{Error: "failed to update note"}, http.StatusInternalServerError)
+			}
+			return
+		}
+		w.Header().Set("ETag", noteETag(updatedNote))
+		res.JsonResponse(w, newGetNoteResponse(updatedNote), http.StatusOK)
+	}
+}
+
+// DeleteNote перемещает заметку в корзину; с ?permanent=true удаляет ее окончательно (в том числе из корзины)
+func (h *NoteHandler) DeleteNote() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		noteId := r.PathValue("id")
+		if noteId == "" {
+			res.JsonResponse(w, res.ErrorResponse{Error: "note id is required"}, http.StatusBadRequest)
+			return
+		}
+		permanent, _ := strconv.ParseBool(r.URL.Query().Get("permanent"))
+
 		note, err := h.NoteService.GetNote(r.Context(), noteId)
+		if errors.Is(err, ErrNoteNotFound) && permanent {
+			note, err = h.NoteService.GetTrashedNote(r.Context(), noteId)
+		}
 		if err != nil {
 			if errors.Is(err, ErrNoteNotFound) {
 				res.JsonResponse(w, res.ErrorResponse{Error: "note not found"}, http.StatusNotFound)
 			} else {
-				res.JsonResponse(w, res.ErrorResponse{Error: "failed to get note by id"}, http.StatusInternalServerError)
+				res.JsonResponse(w, res.ErrorResponse{Error: "failed to delete note"}, http.StatusInternalServerError)
 			}
 			return
 		}
+
+		userId := getUserId(r)
+		if userId == "" {
+			res.JsonResponse(w, res.ErrorResponse{Error: "unauthorized"}, http.StatusUnauthorized)
+			return
+		}
 		if note.UserID != userId {
 			res.JsonResponse(w, res.ErrorResponse{Error: "unauthorized"}, http.StatusUnauthorized)
 			return
 		}
-		response := GetNoteResponse{
-			ID:      note.ID,
-			Title:   note.Title,
-			Content: note.Content,
-			Status:  note.Status,
+		if !h.checkIfMatch(w, r, note) {
+			return
 		}
 
-		res.JsonResponse(w, response, http.StatusOK)
+		if permanent {
+			err = h.NoteService.DeleteNotePermanently(r.Context(), noteId)
+		} else {
+			err = h.NoteService.DeleteNote(r.Context(), noteId)
+		}
+		if err != nil {
+			res.JsonResponse(w, res.ErrorResponse{Error: "failed to delete note"}, http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
 
 	}
+}
+
+func (h *NoteHandler) GetTrash() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userId := getUserId(r)
+		if userId == "" {
+			res.JsonResponse(w, res.ErrorResponse{Error: "unauthorized"}, http.StatusUnauthorized)
+			return
+		}
+
+		limit, offset := parsePagination(r)
+		notes, totalCount, err := h.NoteService.GetTrash(r.Context(), userId, limit, offset)
+		if err != nil {
+			res.JsonResponse(w, res.ErrorResponse{Error: "failed to get trash"}, http.StatusInternalServerError)
+			return
+		}
 
+		res.JsonResponse(w, GetTrashResponse{
+			Notes:      notes,
+			TotalCount: totalCount,
+			Limit:      limit,
+			Offset:     offset,
+			Retention:  h.Config.Notes.TrashRetention.String(),
+		}, http.StatusOK)
+	}
 }
 
-func (h *NoteHandler) UpdateNote() http.HandlerFunc {
+func (h *NoteHandler) RestoreNote() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		noteId := r.PathValue("id")
 		if noteId == "" {
 			res.JsonResponse(w, res.ErrorResponse{Error: "note id is required"}, http.StatusBadRequest)
 			return
 		}
-		body, err := req.HandleBody[UpdateNoteRequest](&w, r)
-		if err != nil {
-			return
-		}
 		userId := getUserId(r)
 		if userId == "" {
 			res.JsonResponse(w, res.ErrorResponse{Error: "unauthorized"}, http.StatusUnauthorized)
 			return
 		}
-		existingNote, err := h.NoteService.GetNote(r.Context(), noteId)
+
+		note, err := h.NoteService.GetTrashedNote(r.Context(), noteId)
 		if err != nil {
 			if errors.Is(err, ErrNoteNotFound) {
-				res.JsonResponse(w, res.ErrorResponse{Error: "note not found"}, http.StatusNotFound)
+				res.JsonResponse(w, res.ErrorResponse{Error: "note not found in trash"}, http.StatusNotFound)
 			} else {
-				res.JsonResponse(w, res.ErrorResponse{Error: "failed to get note by id"}, http.StatusInternalServerError)
+				res.JsonResponse(w, res.ErrorResponse{Error: "failed to restore note"}, http.StatusInternalServerError)
 			}
 			return
 		}
-		if existingNote.UserID != userId {
+		if note.UserID != userId {
 			res.JsonResponse(w, res.ErrorResponse{Error: "unauthorized"}, http.StatusUnauthorized)
 			return
 		}
-		// Обновляем только непустые поля
-		if body.Title != "" {
-			existingNote.Title = body.Title
-		}
-		if body.Content != "" {
-			existingNote.Content = body.Content
-		}
-		if body.Status != "" {
-			existingNote.Status = body.Status
-		}
 
-		updatedNote, err := h.NoteService.UpdateNote(r.Context(), existingNote)
+		restoredNote, err := h.NoteService.RestoreNote(r.Context(), noteId)
 		if err != nil {
-			if errors.Is(err, ErrInvalidNoteStatus) {
-				res.JsonResponse(w, res.ErrorResponse{Error: "invalid note status"}, http.StatusBadRequest)
+			if errors.Is(err, ErrNoteNotFound) {
+				res.JsonResponse(w, res.ErrorResponse{Error: "note not found in trash"}, http.StatusNotFound)
 			} else {
-				res.JsonResponse(w, res.ErrorResponse{Error: "failed to update note"}, http.StatusInternalServerError)
+				res.JsonResponse(w, res.ErrorResponse{Error: "failed to restore note"}, http.StatusInternalServerError)
 			}
 			return
 		}
-		response := GetNoteResponse{
-			ID:        updatedNote.ID,
-			Title:     updatedNote.Title,
-			Content:   updatedNote.Content,
-			Status:    updatedNote.Status,
-			UserID:    updatedNote.UserID,
-			CreatedAt: updatedNote.CreatedAt,
-			UpdatedAt: updatedNote.UpdatedAt,
-		}
-		res.JsonResponse(w, response, http.StatusOK)
+		res.JsonResponse(w, newGetNoteResponse(restoredNote), http.StatusOK)
 	}
 }
 
-func (h *NoteHandler) DeleteNote() http.HandlerFunc {
+// MoveNoteToProject переносит заметку в проект или во входящие (project_id: null)
+func (h *NoteHandler) MoveNoteToProject() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		noteId := r.PathValue("id")
-		if noteId == "" {
-			res.JsonResponse(w, res.ErrorResponse{Error: "note id is required"}, http.StatusBadRequest)
+		body, err := req.HandleBody[MoveNoteToProjectRequest](&w, r)
+		if err != nil {
+			return
+		}
+		note := h.loadNote(w, r, models.PermissionOwner)
+		if note == nil {
+			return
+		}
+		if body.ProjectID != nil && !h.checkProject(w, r, note.UserID, *body.ProjectID, true) {
 			return
 		}
 
-		_, err := h.NoteService.GetNote(r.Context(), noteId)
+		movedNote, err := h.NoteService.MoveNoteToProject(r.Context(), note, body.ProjectID)
 		if err != nil {
 			if errors.Is(err, ErrNoteNotFound) {
 				res.JsonResponse(w, res.ErrorResponse{Error: "note not found"}, http.StatusNotFound)
 			} else {
-				res.JsonResponse(w, res.ErrorResponse{Error: "failed to delete note"}, http.StatusInternalServerError)
+				res.JsonResponse(w, res.ErrorResponse{Error: "failed to move note"}, http.StatusInternalServerError)
 			}
 			return
 		}
+		res.JsonResponse(w, newGetNoteResponse(movedNote), http.StatusOK)
+	}
+}
 
-		userId := getUserId(r)
-		if userId == "" {
-			res.JsonResponse(w, res.ErrorResponse{Error: "unauthorized"}, http.StatusUnauthorized)
+func (h *NoteHandler) GetChecklistItems() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		note := h.loadNote(w, r, models.PermissionViewer)
+		if note == nil {
 			return
 		}
 
-		err = h.NoteService.DeleteNote(r.Context(), noteId)
+		items, err := h.NoteService.GetChecklistItems(r.Context(), note.ID)
 		if err != nil {
-			res.JsonResponse(w, res.ErrorResponse{Error: "failed to delete note"}, http.StatusInternalServerError)
+			res.JsonResponse(w, res.ErrorResponse{Error: "failed to get checklist items"}, http.StatusInternalServerError)
 			return
 		}
+		res.JsonResponse(w, GetChecklistItemsResponse{Items: items}, http.StatusOK)
+	}
+}
 
-		w.WriteHeader(http.StatusNoContent)
-
+func (h *NoteHandler) CreateChecklistItem() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := req.HandleBody[CreateChecklistItemRequest](&w, r)
+		if err != nil {
+			return
+		}
+		note := h.loadNote(w, r, models.PermissionEditor)
+		if note == nil {
+			return
+		}
+
+		item, status, err := h.NoteService.AddChecklistItem(r.Context(), note, &models.ChecklistItem{
+			Text: body.Text,
+			Done: body.Done,
+		})
+		if err != nil {
+			res.JsonResponse(w, res.ErrorResponse{Error: "failed to create checklist item"}, http.StatusInternalServerError)
+			return
+		}
+		res.JsonResponse(w, ChecklistItemResponse{Item: item, NoteStatus: status}, http.StatusCreated)
+	}
+}
+
+func (h *NoteHandler) UpdateChecklistItem() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := req.HandleBody[UpdateChecklistItemRequest](&w, r)
+		if err != nil {
+			return
+		}
+		note := h.loadNote(w, r, models.PermissionEditor)
+		if note == nil {
+			return
+		}
+		item := h.loadNoteItem(w, r, note)
+		if item == nil {
+			return
+		}
+
+		// Обновляем только переданные поля
+		if body.Text != "" {
+			item.Text = body.Text
+		}
+		if body.Done != nil {
+			item.Done = *body.Done
+		}
+		if body.Position != nil {
+			item.Position = *body.Position
+		}
+
+		updatedItem, status, err := h.NoteService.UpdateChecklistItem(r.Context(), note, item)
+		if err != nil {
+			if errors.Is(err, ErrItemNotFound) {
+				res.JsonResponse(w, res.ErrorResponse{Error: "checklist item not found"}, http.StatusNotFound)
+			} else {
+				res.JsonResponse(w, res.ErrorResponse{Error: "failed to update checklist item"}, http.StatusInternalServerError)
+			}
+			return
+		}
+		res.JsonResponse(w, ChecklistItemResponse{Item: updatedItem, NoteStatus: status}, http.StatusOK)
+	}
+}
+
+func (h *NoteHandler) DeleteChecklistItem() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		note := h.loadNote(w, r, models.PermissionEditor)
+		if note == nil {
+			return
+		}
+		item := h.loadNoteItem(w, r, note)
+		if item == nil {
+			return
+		}
+
+		status, err := h.NoteService.DeleteChecklistItem(r.Context(), note, item.ID)
+		if err != nil {
+			if errors.Is(err, ErrItemNotFound) {
+				res.JsonResponse(w, res.ErrorResponse{Error: "checklist item not found"}, http.StatusNotFound)
+			} else {
+				res.JsonResponse(w, res.ErrorResponse{Error: "failed to delete checklist item"}, http.StatusInternalServerError)
+			}
+			return
+		}
+		res.JsonResponse(w, DeleteChecklistItemResponse{NoteStatus: status}, http.StatusOK)
+	}
+}
+
+func (h *NoteHandler) GetRevisions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		note := h.loadNote(w, r, models.PermissionViewer)
+		if note == nil {
+			return
+		}
+
+		revisions, err := h.NoteService.GetRevisions(r.Context(), note.ID)
+		if err != nil {
+			res.JsonResponse(w, res.ErrorResponse{Error: "failed to get revisions"}, http.StatusInternalServerError)
+			return
+		}
+		res.JsonResponse(w, GetRevisionsResponse{Revisions: revisions}, http.StatusOK)
+	}
+}
+
+// GetRevision возвращает ревизию вместе с diff против текущей версии заметки
+func (h *NoteHandler) GetRevision() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		note := h.loadNote(w, r, models.PermissionViewer)
+		if note == nil {
+			return
+		}
+		revision := h.loadNoteRevision(w, r, note)
+		if revision == nil {
+			return
+		}
+
+		res.JsonResponse(w, GetRevisionResponse{
+			Revision:    revision,
+			TitleDiff:   unifiedDiff("title", revision.Title, note.Title),
+			ContentDiff: unifiedDiff("content", revision.Content, note.Content),
+		}, http.StatusOK)
+	}
+}
+
+func (h *NoteHandler) RestoreRevision() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		note := h.loadNote(w, r, models.PermissionEditor)
+		if note == nil {
+			return
+		}
+		revision := h.loadNoteRevision(w, r, note)
+		if revision == nil {
+			return
+		}
+
+		restoredNote, err := h.NoteService.RestoreRevision(r.Context(), note, revision.Revision)
+		if err != nil {
+			var transitionErr *TransitionError
+			var blockedErr *BlockedError
+			switch {
+			case errors.Is(err, ErrRevisionNotFound):
+				res.JsonResponse(w, res.ErrorResponse{Error: "revision not found"}, http.StatusNotFound)
+			case errors.Is(err, ErrInvalidNoteStatus):
+				res.JsonResponse(w, res.ErrorResponse{Error: "invalid note status"}, http.StatusBadRequest)
+			case errors.Is(err, ErrVersionConflict):
+				res.JsonResponse(w, res.ErrorResponse{Error: ErrVersionConflict.Error()}, http.StatusPreconditionFailed)
+			case errors.As(err, &transitionErr):
+				writeTransitionError(w, transitionErr)
+			case errors.As(err, &blockedErr):
+				writeBlockedError(w, blockedErr)
+			default:
+				res.JsonResponse(w, res.ErrorResponse{Error: "failed to restore revision"}, http.StatusInternalServerError)
+			}
+			return
+		}
+		res.JsonResponse(w, newGetNoteResponse(restoredNote), http.StatusOK)
+	}
+}
+
+// BatchNotes выполняет пакет операций create/update/delete над заметками в одной транзакции
+func (h *NoteHandler) BatchNotes() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := req.HandleBody[BatchNotesRequest](&w, r)
+		if err != nil {
+			return
+		}
+		userId := getUserId(r)
+		if userId == "" {
+			res.JsonResponse(w, res.ErrorResponse{Error: "unauthorized"}, http.StatusUnauthorized)
+			return
+		}
+
+		response := BatchNotesResponse{
+			Atomic:  body.Atomic,
+			Results: make([]BatchOperationResult, len(body.Operations)),
+		}
+		// Проекты проверяются до транзакции; операции, не прошедшие проверку, в пакет не попадают
+		ops := make([]models.NoteBatchOperation, 0, len(body.Operations))
+		indexes := make([]int, 0, len(body.Operations))
+		rejected := false
+		for i, op := range body.Operations {
+			response.Results[i] = BatchOperationResult{Index: i, Op: op.Op}
+			if op.Op == models.BatchOpCreate && op.Create.ProjectID != nil {
+				if status, message := h.projectError(r.Context(), userId, *op.Create.ProjectID, true); status != 0 {
+					response.Results[i].Status = status
+					response.Results[i].Error = message
+					rejected = true
+					continue
+				}
+			}
+			ops = append(ops, newBatchOperation(op))
+			indexes = append(indexes, i)
+		}
+
+		if body.Atomic && rejected {
+			for _, i := range indexes {
+				response.Results[i].Status = http.StatusFailedDependency
+				response.Results[i].Error = ErrBatchAborted.Error()
+			}
+			res.JsonResponse(w, response, http.StatusOK)
+			return
+		}
+
+		results, err := h.NoteService.BatchNotes(r.Context(), userId, ops, body.Atomic)
+		if err != nil && !errors.Is(err, ErrBatchAborted) {
+			res.JsonResponse(w, res.ErrorResponse{Error: "failed to run batch"}, http.StatusInternalServerError)
+			return
+		}
+		response.Committed = err == nil
+		for j, result := range results {
+			item := &response.Results[indexes[j]]
+			item.Status, item.Error = batchResultStatus(result)
+			if result.Note != nil {
+				note := newGetNoteResponse(result.Note)
+				item.Note = &note
+			}
+		}
+		res.JsonResponse(w, response, http.StatusOK)
+	}
+}
+
+func newBatchOperation(op BatchOperationRequest) models.NoteBatchOperation {
+	switch op.Op {
+	case models.BatchOpCreate:
+		return models.NoteBatchOperation{
+			Op: op.Op,
+			Note: &models.Note{
+				Title:     op.Create.Title,
+				Content:   op.Create.Content,
+				Status:    op.Create.Status,
+				Priority:  op.Create.Priority,
+				Tags:      tagsFromNames(op.Create.Tags),
+				DueAt:     op.Create.DueAt,
+				ProjectID: op.Create.ProjectID,
+				RRule:     op.Create.RRule,
+				RRuleTZ:   op.Create.RRuleTZ,
+			},
+		}
+	case models.BatchOpUpdate:
+		update := op.Update
+		return models.NoteBatchOperation{
+			Op:      op.Op,
+			NoteID:  op.ID,
+			Version: op.Version,
+			Apply: func(note *models.Note) {
+				applyNoteUpdate(note, update)
+			},
+		}
+	default:
+		return models.NoteBatchOperation{Op: op.Op, NoteID: op.ID, Version: op.Version}
+	}
+}
+
+// batchResultStatus переводит результат операции пакета в HTTP-статус и сообщение об ошибке
+func batchResultStatus(result models.NoteBatchResult) (int, string) {
+	switch {
+	case result.Err == nil && result.Op == models.BatchOpCreate:
+		return http.StatusCreated, ""
+	case result.Err == nil && result.Op == models.BatchOpDelete:
+		return http.StatusNoContent, ""
+	case result.Err == nil:
+		return http.StatusOK, ""
+	case errors.Is(result.Err, ErrBatchAborted):
+		return http.StatusFailedDependency, ErrBatchAborted.Error()
+	case errors.Is(result.Err, ErrNoteNotFound):
+		return http.StatusNotFound, "note not found"
+	case errors.Is(result.Err, ErrPermissionDenied):
+		return http.StatusForbidden, ErrPermissionDenied.Error()
+	case errors.Is(result.Err, ErrInvalidNoteStatus):
+		return http.StatusBadRequest, "invalid note status"
+	case errors.Is(result.Err, ErrInvalidPriority):
+		return http.StatusBadRequest, "invalid note priority"
+	case errors.Is(result.Err, tags.ErrInvalidTagName):
+		return http.StatusBadRequest, "invalid tag name"
+	case errors.Is(result.Err, ErrInvalidRRule), errors.Is(result.Err, ErrRecurrenceWithoutDueAt):
+		return http.StatusBadRequest, result.Err.Error()
+	case errors.Is(result.Err, ErrVersionConflict):
+		return http.StatusPreconditionFailed, ErrVersionConflict.Error()
+	case errors.Is(result.Err, ErrInvalidTransition), errors.Is(result.Err, ErrNoteBlocked):
+		return http.StatusConflict, result.Err.Error()
+	default:
+		return http.StatusInternalServerError, fmt.Sprintf("failed to %s note", result.Op)
+	}
+}
+
+// GetOccurrences показывает сроки будущих повторений заметки: ?from=&to= в RFC 3339,
+// по умолчанию от текущего момента на 90 дней вперед
+func (h *NoteHandler) GetOccurrences() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		note := h.loadNote(w, r, models.PermissionViewer)
+		if note == nil {
+			return
+		}
+		from, err := parseTimeParam(r, "from")
+		if err != nil {
+			res.JsonResponse(w, res.ErrorResponse{Error: err.Error()}, http.StatusBadRequest)
+			return
+		}
+		to, err := parseTimeParam(r, "to")
+		if err != nil {
+			res.JsonResponse(w, res.ErrorResponse{Error: err.Error()}, http.StatusBadRequest)
+			return
+		}
+		if from == nil {
+			now := time.Now().UTC()
+			from = &now
+		}
+		if to == nil {
+			end := from.AddDate(0, 0, 90)
+			to = &end
+		}
+		if to.Before(*from) {
+			res.JsonResponse(w, res.ErrorResponse{Error: "to must not be before from"}, http.StatusBadRequest)
+			return
+		}
+
+		occurrences, err := h.NoteService.GetOccurrences(r.Context(), note, *from, *to)
+		if err != nil {
+			res.JsonResponse(w, res.ErrorResponse{Error: "failed to compute occurrences"}, http.StatusInternalServerError)
+			return
+		}
+		res.JsonResponse(w, GetOccurrencesResponse{Occurrences: occurrences, From: *from, To: *to}, http.StatusOK)
+	}
+}
+
+// GetBoard отдает доску: колонки статусов workflow с заметками в ручном порядке; ?project_id= как у списка заметок
+func (h *NoteHandler) GetBoard() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userId := getUserId(r)
+		if userId == "" {
+			res.JsonResponse(w, res.ErrorResponse{Error: "unauthorized"}, http.StatusUnauthorized)
+			return
+		}
+		projectId := r.URL.Query().Get("project_id")
+		if projectId != "" && projectId != models.InboxProjectID && !h.checkProject(w, r, userId, projectId, false) {
+			return
+		}
+
+		columns, err := h.NoteService.GetBoard(r.Context(), userId, projectId)
+		if err != nil {
+			res.JsonResponse(w, res.ErrorResponse{Error: "failed to get board"}, http.StatusInternalServerError)
+			return
+		}
+		res.JsonResponse(w, GetBoardResponse{Columns: columns}, http.StatusOK)
+	}
+}
+
+func (h *NoteHandler) MoveNote() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := req.HandleBody[MoveNoteRequest](&w, r)
+		if err != nil {
+			return
+		}
+		note := h.loadNote(w, r, models.PermissionEditor)
+		if note == nil {
+			return
+		}
+		if !h.checkIfMatch(w, r, note) {
+			return
+		}
+
+		movedNote, err := h.NoteService.MoveNote(r.Context(), note, body.Status, body.AfterID, body.BeforeID)
+		if err != nil {
+			var transitionErr *TransitionError
+			switch {
+			case errors.Is(err, ErrInvalidNeighbour):
+				res.JsonResponse(w, res.ErrorResponse{Error: "neighbours must be other notes in the target column"}, http.StatusBadRequest)
+			case errors.Is(err, ErrInvalidNoteStatus):
+				res.JsonResponse(w, res.ErrorResponse{Error: "invalid note status"}, http.StatusBadRequest)
+			case errors.Is(err, ErrInvalidPosition):
+				res.JsonResponse(w, res.ErrorResponse{Error: "after_id must be above before_id"}, http.StatusConflict)
+			case errors.Is(err, ErrVersionConflict):
+				res.JsonResponse(w, res.ErrorResponse{Error: ErrVersionConflict.Error()}, http.StatusPreconditionFailed)
+			case errors.As(err, &transitionErr):
+				writeTransitionError(w, transitionErr)
+			default:
+				res.JsonResponse(w, res.ErrorResponse{Error: "failed to move note"}, http.StatusInternalServerError)
+			}
+			return
+		}
+		w.Header().Set("ETag", noteETag(movedNote))
+		res.JsonResponse(w, newGetNoteResponse(movedNote), http.StatusOK)
+	}
+}
+
+func newNoteShareResponse(share models.NoteShare) NoteShareResponse {
+	response := NoteShareResponse{
+		UserID:     share.UserID,
+		Permission: share.Permission,
+		CreatedAt:  share.CreatedAt,
+		UpdatedAt:  share.UpdatedAt,
+	}
+	if share.User != nil {
+		response.Email = share.User.Email
+		response.Name = share.User.Name
+	}
+	return response
+}
+
+// ShareNote открывает доступ к заметке другому пользователю по email; повторный запрос меняет уровень доступа
+func (h *NoteHandler) ShareNote() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := req.HandleBody[ShareNoteRequest](&w, r)
+		if err != nil {
+			return
+		}
+		note := h.loadNote(w, r, models.PermissionOwner)
+		if note == nil {
+			return
+		}
+
+		share, err := h.NoteService.ShareNote(r.Context(), note, body.Email, body.Permission)
+		if err != nil {
+			switch {
+			case errors.Is(err, user.ErrUserNotFound):
+				res.JsonResponse(w, res.ErrorResponse{Error: "user not found"}, http.StatusNotFound)
+			case errors.Is(err, ErrShareWithOwner), errors.Is(err, ErrInvalidPermission):
+				res.JsonResponse(w, res.ErrorResponse{Error: err.Error()}, http.StatusBadRequest)
+			default:
+				res.JsonResponse(w, res.ErrorResponse{Error: "failed to share note"}, http.StatusInternalServerError)
+			}
+			return
+		}
+		res.JsonResponse(w, newNoteShareResponse(*share), http.StatusOK)
+	}
+}
+
+func (h *NoteHandler) GetNoteShares() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		note := h.loadNote(w, r, models.PermissionOwner)
+		if note == nil {
+			return
+		}
+
+		shares, err := h.NoteService.GetShares(r.Context(), note.ID)
+		if err != nil {
+			res.JsonResponse(w, res.ErrorResponse{Error: "failed to get note shares"}, http.StatusInternalServerError)
+			return
+		}
+		response := GetNoteSharesResponse{Shares: make([]NoteShareResponse, 0, len(shares))}
+		for _, share := range shares {
+			response.Shares = append(response.Shares, newNoteShareResponse(share))
+		}
+		res.JsonResponse(w, response, http.StatusOK)
+	}
+}
+
+// RevokeShare отзывает доступ к заметке. Владелец может отозвать любой доступ, получатель — отказаться от своего
+func (h *NoteHandler) RevokeShare() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		note := h.loadNote(w, r, models.PermissionViewer)
+		if note == nil {
+			return
+		}
+		targetId := r.PathValue("userId")
+		if userId := getUserId(r); userId != note.UserID && userId != targetId {
+			res.JsonResponse(w, res.ErrorResponse{Error: ErrPermissionDenied.Error()}, http.StatusForbidden)
+			return
+		}
+
+		if err := h.NoteService.RevokeShare(r.Context(), note.ID, targetId); err != nil {
+			if errors.Is(err, ErrShareNotFound) {
+				res.JsonResponse(w, res.ErrorResponse{Error: "share not found"}, http.StatusNotFound)
+			} else {
+				res.JsonResponse(w, res.ErrorResponse{Error: "failed to revoke share"}, http.StatusInternalServerError)
+			}
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// GetSharedNotes возвращает заметки других пользователей, к которым открыт доступ текущему
+func (h *NoteHandler) GetSharedNotes() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userId := getUserId(r)
+		if userId == "" {
+			res.JsonResponse(w, res.ErrorResponse{Error: "unauthorized"}, http.StatusUnauthorized)
+			return
+		}
+
+		limit, offset := parsePagination(r)
+		notes, totalCount, err := h.NoteService.GetSharedNotes(r.Context(), userId, limit, offset)
+		if err != nil {
+			res.JsonResponse(w, res.ErrorResponse{Error: "failed to get shared notes"}, http.StatusInternalServerError)
+			return
+		}
+		res.JsonResponse(w, GetSharedNotesResponse{
+			Notes:      notes,
+			TotalCount: totalCount,
+			Limit:      limit,
+			Offset:     offset,
+		}, http.StatusOK)
+	}
+}
+
+func newNoteLinkResponse(link *models.NoteLink) NoteLinkResponse {
+	return NoteLinkResponse{
+		ID:             link.ID,
+		Token:          link.Token,
+		URL:            "/public/notes/" + link.Token,
+		Protected:      link.PasswordHash != "",
+		ExpiresAt:      link.ExpiresAt,
+		AccessCount:    link.AccessCount,
+		LastAccessedAt: link.LastAccessedAt,
+		CreatedAt:      link.CreatedAt,
+	}
+}
+
+func newPublicNoteResponse(note *models.Note) PublicNoteResponse {
+	response := newGetNoteResponse(note)
+	items := make([]PublicItem, 0, len(note.Items))
+	for _, item := range note.Items {
+		items = append(items, PublicItem{Text: item.Text, Done: item.Done})
+	}
+	return PublicNoteResponse{
+		Title:     response.Title,
+		Content:   response.Content,
+		Status:    response.Status,
+		Priority:  response.Priority,
+		Tags:      response.Tags,
+		DueAt:     response.DueAt,
+		Items:     items,
+		UpdatedAt: response.UpdatedAt,
+	}
+}
+
+// CreateLink создает публичную ссылку только для чтения на заметку
+func (h *NoteHandler) CreateLink() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := req.HandleBody[CreateLinkRequest](&w, r)
+		if err != nil {
+			return
+		}
+		note := h.loadNote(w, r, models.PermissionOwner)
+		if note == nil {
+			return
+		}
+
+		link, err := h.NoteService.CreateLink(r.Context(), note, body.Password, body.ExpiresAt)
+		if err != nil {
+			if errors.Is(err, ErrInvalidLinkExpiry) {
+				res.JsonResponse(w, res.ErrorResponse{Error: ErrInvalidLinkExpiry.Error()}, http.StatusBadRequest)
+			} else {
+				res.JsonResponse(w, res.ErrorResponse{Error: "failed to create link"}, http.StatusInternalServerError)
+			}
+			return
+		}
+		res.JsonResponse(w, newNoteLinkResponse(link), http.StatusCreated)
+	}
+}
+
+func (h *NoteHandler) GetLinks() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		note := h.loadNote(w, r, models.PermissionOwner)
+		if note == nil {
+			return
+		}
+
+		links, err := h.NoteService.GetLinks(r.Context(), note.ID)
+		if err != nil {
+			res.JsonResponse(w, res.ErrorResponse{Error: "failed to get links"}, http.StatusInternalServerError)
+			return
+		}
+		response := GetNoteLinksResponse{Links: make([]NoteLinkResponse, 0, len(links))}
+		for i := range links {
+			response.Links = append(response.Links, newNoteLinkResponse(&links[i]))
+		}
+		res.JsonResponse(w, response, http.StatusOK)
+	}
+}
+
+func (h *NoteHandler) RevokeLink() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		note := h.loadNote(w, r, models.PermissionOwner)
+		if note == nil {
+			return
+		}
+
+		if err := h.NoteService.RevokeLink(r.Context(), note.ID, r.PathValue("linkId")); err != nil {
+			if errors.Is(err, ErrLinkNotFound) {
+				res.JsonResponse(w, res.ErrorResponse{Error: "link not found"}, http.StatusNotFound)
+			} else {
+				res.JsonResponse(w, res.ErrorResponse{Error: "failed to revoke link"}, http.StatusInternalServerError)
+			}
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// GetPublicNote открывает заметку по публичной ссылке без аутентификации.
+// Пароль защищенной ссылки передается в заголовке X-Link-Password
+func (h *NoteHandler) GetPublicNote() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		note, err := h.NoteService.OpenLink(r.Context(), r.PathValue("token"), r.Header.Get("X-Link-Password"), time.Now())
+		if err != nil {
+			switch {
+			case errors.Is(err, ErrLinkNotFound):
+				res.JsonResponse(w, res.ErrorResponse{Error: "link not found"}, http.StatusNotFound)
+			case errors.Is(err, ErrLinkExpired):
+				res.JsonResponse(w, res.ErrorResponse{Error: ErrLinkExpired.Error()}, http.StatusGone)
+			case errors.Is(err, ErrLinkPasswordRequired), errors.Is(err, ErrLinkPasswordInvalid):
+				res.JsonResponse(w, res.ErrorResponse{Error: err.Error()}, http.StatusUnauthorized)
+			default:
+				res.JsonResponse(w, res.ErrorResponse{Error: "failed to open link"}, http.StatusInternalServerError)
+			}
+			return
+		}
+		w.Header().Set("Cache-Control", "no-store") // Иначе кэш отдаст заметку после отзыва ссылки
+		res.JsonResponse(w, newPublicNoteResponse(note), http.StatusOK)
+	}
+}
+
+// AddDependency отмечает, что заметка заблокирована другой заметкой того же владельца
+func (h *NoteHandler) AddDependency() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := req.HandleBody[AddDependencyRequest](&w, r)
+		if err != nil {
+			return
+		}
+		note := h.loadNote(w, r, models.PermissionEditor)
+		if note == nil {
+			return
+		}
+
+		if err := h.NoteService.AddDependency(r.Context(), getUserId(r), note, body.BlockerID); err != nil {
+			switch {
+			case errors.Is(err, ErrNoteNotFound):
+				res.JsonResponse(w, res.ErrorResponse{Error: "blocker note not found"}, http.StatusNotFound)
+			case errors.Is(err, ErrInvalidDependency):
+				res.JsonResponse(w, res.ErrorResponse{Error: ErrInvalidDependency.Error()}, http.StatusBadRequest)
+			case errors.Is(err, ErrDependencyCycle):
+				res.JsonResponse(w, res.ErrorResponse{Error: ErrDependencyCycle.Error()}, http.StatusConflict)
+			default:
+				res.JsonResponse(w, res.ErrorResponse{Error: "failed to add dependency"}, http.StatusInternalServerError)
+			}
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (h *NoteHandler) RemoveDependency() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		note := h.loadNote(w, r, models.PermissionEditor)
+		if note == nil {
+			return
+		}
+
+		if err := h.NoteService.RemoveDependency(r.Context(), note.ID, r.PathValue("blockerId")); err != nil {
+			if errors.Is(err, ErrDependencyNotFound) {
+				res.JsonResponse(w, res.ErrorResponse{Error: "dependency not found"}, http.StatusNotFound)
+			} else {
+				res.JsonResponse(w, res.ErrorResponse{Error: "failed to remove dependency"}, http.StatusInternalServerError)
+			}
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// GetDependencyGraph возвращает транзитивный граф зависимостей заметки в обе стороны
+func (h *NoteHandler) GetDependencyGraph() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		note := h.loadNote(w, r, models.PermissionViewer)
+		if note == nil {
+			return
+		}
+
+		graph, err := h.NoteService.GetDependencyGraph(r.Context(), note.ID, getUserId(r))
+		if err != nil {
+			res.JsonResponse(w, res.ErrorResponse{Error: "failed to get dependency graph"}, http.StatusInternalServerError)
+			return
+		}
+		response := DependencyGraphResponse{Nodes: make([]DependencyNode, 0, len(graph.Nodes)), Edges: graph.Edges}
+		for _, node := range graph.Nodes {
+			item := DependencyNode{ID: node.ID, Status: node.Status, Accessible: graph.Permissions[node.ID] != ""}
+			if item.Accessible {
+				item.Title = node.Title
+			}
+			response.Nodes = append(response.Nodes, item)
+		}
+		res.JsonResponse(w, response, http.StatusOK)
 	}
 }
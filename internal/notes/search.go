@@ -0,0 +1,61 @@
+package notes
+
+import (
+	"strings"
+	"unicode"
+)
+
+// searchConfig — конфигурация полнотекстового поиска PostgreSQL; simple не зависит от языка заметок
+const searchConfig = "simple"
+
+// headlineOptions — параметры подсветки найденных слов в сниппетах
+const headlineOptions = "StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=25, MinWords=8"
+
+// buildTsQuery переводит пользовательский запрос в синтаксис to_tsquery:
+//   - слова объединяются через & (все должны встречаться);
+//   - "фраза в кавычках" ищется как последовательность слов (<->);
+//   - слово* ищется по префиксу (:*);
+//   - -слово исключает заметки с этим словом (!).
+//
+// Из слов удаляются все символы, кроме букв и цифр, поэтому операторы tsquery
+// не могут попасть в запрос из пользовательского ввода.
+func buildTsQuery(raw string) string {
+	terms := make([]string, 0)
+	for i, chunk := range strings.Split(raw, `"`) {
+		if i%2 == 1 {
+			// Внутри кавычек — фраза
+			words := lexemes(chunk)
+			if len(words) > 0 {
+				terms = append(terms, "("+strings.Join(words, " <-> ")+")")
+			}
+			continue
+		}
+		for _, word := range strings.Fields(chunk) {
+			negate := strings.HasPrefix(word, "-")
+			prefix := strings.HasSuffix(word, "*")
+			parts := lexemes(word)
+			if len(parts) == 0 {
+				continue
+			}
+			if prefix {
+				parts[len(parts)-1] += ":*"
+			}
+			term := strings.Join(parts, " <-> ")
+			if len(parts) > 1 {
+				term = "(" + term + ")"
+			}
+			if negate {
+				term = "!" + term
+			}
+			terms = append(terms, term)
+		}
+	}
+	return strings.Join(terms, " & ")
+}
+
+// lexemes разбивает текст на слова из букв и цифр в нижнем регистре
+func lexemes(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
@@ -1,35 +1,141 @@
 package notes
 
 import (
+	"ToDo/configs"
 	"ToDo/internal/models"
+	"ToDo/internal/tags"
 	"ToDo/pkg/di"
+	"ToDo/pkg/idgen"
 	"context"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/bcrypt"
 	"log/slog"
+	"strings"
+	"time"
 )
 
+var validPriorities = map[string]bool{"low": true, "normal": true, "high": true, "urgent": true}
+
+// maxBoardNotes ограничивает число заметок, которые отдает доска
+const maxBoardNotes = 1000
+
 type NoteService struct {
-	noteRepository di.INoteRepository // Используем интерфейс вместо конкретного типа
+	noteRepository  di.INoteRepository // Используем интерфейс вместо конкретного типа
+	userRepository  di.IUserRepository
+	workflowService di.IWorkflowService
+	config          *configs.Config
+}
+
+func NewNoteService(noteRepo di.INoteRepository, userRepo di.IUserRepository, workflowService di.IWorkflowService, config *configs.Config) *NoteService { // Принимаем интерфейс
+	return &NoteService{noteRepository: noteRepo, userRepository: userRepo, workflowService: workflowService, config: config}
 }
 
-func NewNoteService(noteRepo di.INoteRepository) *NoteService { // Принимаем интерфейс
-	return &NoteService{noteRepository: noteRepo}
+// withRepository возвращает копию сервиса, работающую через другой репозиторий (например, привязанный к транзакции)
+func (s *NoteService) withRepository(repo di.INoteRepository) *NoteService {
+	return &NoteService{noteRepository: repo, userRepository: s.userRepository, workflowService: s.workflowService, config: s.config}
 }
 
 func (s *NoteService) CreateNote(ctx context.Context, note *models.Note) (*models.Note, error) {
-	validStatuses := map[string]bool{"created": true, "in_progress": true, "done": true}
+	if note.Priority == "" {
+		note.Priority = "normal"
+	} else if !validPriorities[note.Priority] {
+		return nil, ErrInvalidPriority
+	}
+	workflow, err := s.workflowService.GetWorkflow(ctx, note.UserID, note.ProjectID)
+	if err != nil {
+		return nil, err
+	}
 	if note.Status == "" {
-		note.Status = "created" // Значение по умолчанию
-	} else if !validStatuses[note.Status] {
+		note.Status = workflow.Initial() // Значение по умолчанию
+	} else if workflow.Status(note.Status) == nil {
 		return nil, ErrInvalidNoteStatus
 	}
+	if err := normalizeNoteTags(note); err != nil {
+		return nil, err
+	}
+	normalizeDueAt(note)
+	if err := normalizeRecurrence(note); err != nil {
+		return nil, err
+	}
 
 	slog.Info("Creating note", "title", note.Title, "user_id", note.UserID)
 	return s.noteRepository.Create(ctx, note)
 }
 
-func (s *NoteService) GetAllNotes(ctx context.Context, userID string, limit, offset int) ([]models.Note, int64, error) {
-	slog.Info("Fetching all notes", "user_id", userID, "limit", limit, "offset", offset)
-	return s.noteRepository.GetAll(ctx, userID, limit, offset)
+func (s *NoteService) GetAllNotes(ctx context.Context, userID string, filter models.NoteFilter) ([]models.Note, int64, error) {
+	tagNames, err := tags.NormalizeNames(filter.Tags)
+	if err != nil {
+		return nil, 0, err
+	}
+	filter.Tags = tagNames
+	if filter.TagMatch == "" {
+		filter.TagMatch = models.TagMatchAny
+	} else if filter.TagMatch != models.TagMatchAny && filter.TagMatch != models.TagMatchAll {
+		return nil, 0, ErrInvalidTagMatch
+	}
+
+	slog.Info("Fetching all notes", "user_id", userID, "limit", filter.Limit, "offset", filter.Offset, "tags", filter.Tags)
+	return s.noteRepository.GetAll(ctx, userID, filter)
+}
+
+func (s *NoteService) SearchNotes(ctx context.Context, userID string, query string, filter models.NoteFilter) ([]models.NoteSearchResult, int64, error) {
+	tsQuery := buildTsQuery(query)
+	if tsQuery == "" {
+		return nil, 0, ErrEmptySearchQuery
+	}
+	tagNames, err := tags.NormalizeNames(filter.Tags)
+	if err != nil {
+		return nil, 0, err
+	}
+	filter.Tags = tagNames
+
+	slog.Info("Searching notes", "user_id", userID, "query", tsQuery)
+	return s.noteRepository.Search(ctx, userID, tsQuery, filter)
+}
+
+// GetUpcomingNotes группирует незавершенные заметки по срокам относительно now (в его часовом поясе)
+func (s *NoteService) GetUpcomingNotes(ctx context.Context, userID string, now time.Time) (*models.UpcomingNotes, error) {
+	startOfToday := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	startOfTomorrow := startOfToday.AddDate(0, 0, 1)
+	startOfDayAfter := startOfToday.AddDate(0, 0, 2)
+
+	// Неделя заканчивается в воскресенье, следующая начинается с понедельника
+	daysToMonday := (8 - int(startOfToday.Weekday())) % 7
+	if daysToMonday == 0 {
+		daysToMonday = 7
+	}
+	endOfWeek := startOfToday.AddDate(0, 0, daysToMonday)
+	until := endOfWeek
+	if until.Before(startOfDayAfter) {
+		until = startOfDayAfter
+	}
+
+	slog.Info("Fetching upcoming notes", "user_id", userID, "until", until)
+	notes, err := s.noteRepository.GetDue(ctx, userID, until)
+	if err != nil {
+		return nil, err
+	}
+
+	upcoming := &models.UpcomingNotes{
+		Overdue:  []models.Note{},
+		Today:    []models.Note{},
+		Tomorrow: []models.Note{},
+		ThisWeek: []models.Note{},
+	}
+	for _, note := range notes {
+		switch due := *note.DueAt; {
+		case due.Before(startOfToday):
+			upcoming.Overdue = append(upcoming.Overdue, note)
+		case due.Before(startOfTomorrow):
+			upcoming.Today = append(upcoming.Today, note)
+		case due.Before(startOfDayAfter):
+			upcoming.Tomorrow = append(upcoming.Tomorrow, note)
+		case due.Before(endOfWeek):
+			upcoming.ThisWeek = append(upcoming.ThisWeek, note)
+		}
+	}
+	return upcoming, nil
 }
 
 func (s *NoteService) GetNote(ctx context.Context, noteID string) (*models.Note, error) {
@@ -37,15 +143,685 @@ func (s *NoteService) GetNote(ctx context.Context, noteID string) (*models.Note,
 	return s.noteRepository.Get(ctx, noteID)
 }
 
-func (s *NoteService) UpdateNote(ctx context.Context, note *models.Note) (*models.Note, error) {
-	validStatuses := map[string]bool{"created": true, "in_progress": true, "done": true}
-	if note.Status != "" && !validStatuses[note.Status] {
-		return nil, ErrInvalidNoteStatus
+// UpdateNote сохраняет изменения заметки. Перевод в завершающий статус запрещен, пока заметку блокируют
+// незавершенные заметки (BlockedError); force снимает это ограничение
+func (s *NoteService) UpdateNote(ctx context.Context, note *models.Note, force bool) (*models.Note, error) {
+	if note.Priority != "" && !validPriorities[note.Priority] {
+		return nil, ErrInvalidPriority
+	}
+	if err := normalizeNoteTags(note); err != nil {
+		return nil, err
+	}
+	normalizeDueAt(note)
+
+	// Текущее состояние из базы сохраняется как ревизия, если изменилось содержимое
+	previous, err := s.noteRepository.Get(ctx, note.ID)
+	if err != nil {
+		return nil, err
+	}
+	// Выполнение определяется завершающими статусами workflow заметки, а не именем done
+	completing := false
+	if note.Status != "" && note.Status != previous.Status {
+		workflow, err := s.workflowService.GetWorkflow(ctx, previous.UserID, previous.ProjectID)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkTransition(workflow, previous.Status, note.Status); err != nil {
+			return nil, err
+		}
+		completing = !workflow.IsTerminal(previous.Status) && workflow.IsTerminal(note.Status)
+	}
+	if !force && completing {
+		blockers, err := s.noteRepository.GetOpenBlockers(ctx, note.ID)
+		if err != nil {
+			return nil, err
+		}
+		if len(blockers) > 0 {
+			return nil, &BlockedError{Blockers: blockers}
+		}
 	}
-	return s.noteRepository.Update(ctx, note)
+	if note.RRule != previous.RRule || note.RRuleTZ != previous.RRuleTZ {
+		note.SeriesStart = nil // Новое правило отсчитывается от текущего срока
+	}
+	if err := normalizeRecurrence(note); err != nil {
+		return nil, err
+	}
+
+	var updatedNote *models.Note
+	err = s.noteRepository.WithTransaction(ctx, func(repo di.INoteRepository) error {
+		txService := s.withRepository(repo)
+		var next *models.Note
+		if completing && note.RRule != "" {
+			if next, err = nextOccurrenceNote(note); err != nil {
+				return err
+			}
+			// Правило переходит к следующему повторению, выполненная заметка остается в серии
+			if note.SeriesID == nil {
+				note.SeriesID = &note.ID
+			}
+			note.RRule = ""
+			note.RRuleTZ = ""
+			note.SeriesStart = nil
+		}
+
+		updatedNote, err = repo.Update(ctx, note)
+		if err != nil {
+			return err
+		}
+		if revisionChanged(previous, updatedNote) {
+			revision := &models.NoteRevision{
+				NoteID:   previous.ID,
+				Title:    previous.Title,
+				Content:  previous.Content,
+				Status:   previous.Status,
+				Priority: previous.Priority,
+			}
+			if err := repo.CreateRevision(ctx, revision, s.config.Notes.MaxRevisions); err != nil {
+				return err
+			}
+		}
+		if next != nil {
+			next.SeriesID = note.SeriesID
+			if _, err := txService.CreateNote(ctx, next); err != nil {
+				return err
+			}
+			slog.Info("Created next occurrence", "series_id", *next.SeriesID, "note_id", next.ID, "due_at", next.DueAt)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updatedNote, nil
 }
 
+// checkTransition проверяет смену статуса по workflow заметки: неизвестный статус — ErrInvalidNoteStatus,
+// запрещенный переход — TransitionError со списком допустимых статусов
+func checkTransition(workflow *models.Workflow, from, to string) error {
+	if workflow.Status(to) == nil {
+		return ErrInvalidNoteStatus
+	}
+	if !workflow.CanTransition(from, to) {
+		return &TransitionError{From: from, To: to, Allowed: workflow.AllowedNext(from)}
+	}
+	return nil
+}
+
+// nextOccurrenceNote готовит следующее повторение заметки; nil — серия закончилась (UNTIL/COUNT)
+func nextOccurrenceNote(note *models.Note) (*models.Note, error) {
+	dueAt, err := nextOccurrence(note)
+	if err != nil || dueAt == nil {
+		return nil, err
+	}
+	next := &models.Note{
+		Title:       note.Title,
+		Content:     note.Content,
+		Priority:    note.Priority,
+		UserID:      note.UserID,
+		ProjectID:   note.ProjectID,
+		DueAt:       dueAt,
+		RRule:       note.RRule,
+		RRuleTZ:     note.RRuleTZ,
+		SeriesStart: note.SeriesStart,
+	}
+	for _, tag := range note.Tags {
+		next.Tags = append(next.Tags, models.Tag{Name: tag.Name})
+	}
+	return next, nil
+}
+
+// GetOccurrences возвращает сроки будущих повторений заметки в интервале [from, to]
+func (s *NoteService) GetOccurrences(ctx context.Context, note *models.Note, from, to time.Time) ([]time.Time, error) {
+	slog.Info("Fetching occurrences", "note_id", note.ID, "from", from, "to", to)
+	return occurrencesBetween(note, from, to)
+}
+
+// DeleteNote перемещает заметку в корзину
 func (s *NoteService) DeleteNote(ctx context.Context, noteID string) error {
 	slog.Info("Deleting note", "note_id", noteID)
 	return s.noteRepository.Delete(ctx, noteID)
 }
+
+func (s *NoteService) DeleteNotePermanently(ctx context.Context, noteID string) error {
+	slog.Info("Permanently deleting note", "note_id", noteID)
+	return s.noteRepository.DeletePermanently(ctx, noteID)
+}
+
+func (s *NoteService) GetTrash(ctx context.Context, userID string, limit, offset int) ([]models.Note, int64, error) {
+	slog.Info("Fetching trash", "user_id", userID, "limit", limit, "offset", offset)
+	return s.noteRepository.GetTrash(ctx, userID, limit, offset)
+}
+
+func (s *NoteService) GetTrashedNote(ctx context.Context, noteID string) (*models.Note, error) {
+	slog.Info("Fetching trashed note", "note_id", noteID)
+	return s.noteRepository.GetTrashed(ctx, noteID)
+}
+
+func (s *NoteService) RestoreNote(ctx context.Context, noteID string) (*models.Note, error) {
+	slog.Info("Restoring note", "note_id", noteID)
+	if err := s.noteRepository.Restore(ctx, noteID); err != nil {
+		return nil, err
+	}
+	return s.noteRepository.Get(ctx, noteID)
+}
+
+// PurgeTrash окончательно удаляет заметки, пролежавшие в корзине дольше TrashRetention
+func (s *NoteService) PurgeTrash(ctx context.Context, now time.Time) (int64, error) {
+	before := now.Add(-s.config.Notes.TrashRetention)
+	purged, err := s.noteRepository.PurgeDeleted(ctx, before)
+	if err != nil {
+		return 0, err
+	}
+	if purged > 0 {
+		slog.Info("Purged trashed notes", "count", purged, "deleted_before", before)
+	}
+	return purged, nil
+}
+
+// MoveNoteToProject переносит заметку в проект (принадлежность проекта проверяется вызывающей стороной); nil — во входящие
+func (s *NoteService) MoveNoteToProject(ctx context.Context, note *models.Note, projectID *string) (*models.Note, error) {
+	slog.Info("Moving note", "note_id", note.ID, "project_id", projectID)
+	if err := s.noteRepository.UpdateProject(ctx, note.ID, projectID); err != nil {
+		return nil, err
+	}
+	note.ProjectID = projectID
+	return note, nil
+}
+
+func (s *NoteService) GetRevisions(ctx context.Context, noteID string) ([]models.NoteRevision, error) {
+	slog.Info("Fetching revisions", "note_id", noteID)
+	return s.noteRepository.GetRevisions(ctx, noteID)
+}
+
+func (s *NoteService) GetRevision(ctx context.Context, noteID string, revision int) (*models.NoteRevision, error) {
+	slog.Info("Fetching revision", "note_id", noteID, "revision", revision)
+	return s.noteRepository.GetRevision(ctx, noteID, revision)
+}
+
+// RestoreRevision возвращает заметке содержимое ревизии; текущее состояние само становится новой ревизией
+func (s *NoteService) RestoreRevision(ctx context.Context, note *models.Note, revision int) (*models.Note, error) {
+	rev, err := s.noteRepository.GetRevision(ctx, note.ID, revision)
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("Restoring revision", "note_id", note.ID, "revision", revision)
+	note.Title = rev.Title
+	note.Content = rev.Content
+	note.Status = rev.Status
+	note.Priority = rev.Priority
+	return s.UpdateNote(ctx, note, false)
+}
+
+func (s *NoteService) GetChecklistItems(ctx context.Context, noteID string) ([]models.ChecklistItem, error) {
+	slog.Info("Fetching checklist items", "note_id", noteID)
+	return s.noteRepository.GetItems(ctx, noteID)
+}
+
+func (s *NoteService) GetChecklistItem(ctx context.Context, itemID string) (*models.ChecklistItem, error) {
+	return s.noteRepository.GetItem(ctx, itemID)
+}
+
+// AddChecklistItem добавляет пункт в конец списка и возвращает его вместе с итоговым статусом заметки
+func (s *NoteService) AddChecklistItem(ctx context.Context, note *models.Note, item *models.ChecklistItem) (*models.ChecklistItem, string, error) {
+	item.NoteID = note.ID
+	slog.Info("Adding checklist item", "note_id", note.ID)
+	createdItem, err := s.noteRepository.CreateItem(ctx, item)
+	if err != nil {
+		return nil, "", err
+	}
+	status, err := s.rollupStatus(ctx, note)
+	if err != nil {
+		return nil, "", err
+	}
+	return createdItem, status, nil
+}
+
+func (s *NoteService) UpdateChecklistItem(ctx context.Context, note *models.Note, item *models.ChecklistItem) (*models.ChecklistItem, string, error) {
+	slog.Info("Updating checklist item", "note_id", note.ID, "item_id", item.ID)
+	updatedItem, err := s.noteRepository.UpdateItem(ctx, item)
+	if err != nil {
+		return nil, "", err
+	}
+	status, err := s.rollupStatus(ctx, note)
+	if err != nil {
+		return nil, "", err
+	}
+	return updatedItem, status, nil
+}
+
+func (s *NoteService) DeleteChecklistItem(ctx context.Context, note *models.Note, itemID string) (string, error) {
+	slog.Info("Deleting checklist item", "note_id", note.ID, "item_id", itemID)
+	if err := s.noteRepository.DeleteItem(ctx, itemID); err != nil {
+		return "", err
+	}
+	return s.rollupStatus(ctx, note)
+}
+
+// rollupStatus пересчитывает статус заметки по ее чек-листу и сохраняет его, если он изменился.
+// Автоматическая смена статуса подчиняется тем же переходам workflow, что и ручная
+func (s *NoteService) rollupStatus(ctx context.Context, note *models.Note) (string, error) {
+	items, err := s.noteRepository.GetItems(ctx, note.ID)
+	if err != nil {
+		return "", err
+	}
+	workflow, err := s.workflowService.GetWorkflow(ctx, note.UserID, note.ProjectID)
+	if err != nil {
+		return "", err
+	}
+	status := checklistStatus(workflow, note.Status, items, s.config.Notes.ChecklistAutoDone)
+	if status == note.Status {
+		return status, nil
+	}
+	// Запрещенный workflow переход не выполняется автоматически
+	if checkTransition(workflow, note.Status, status) != nil {
+		return note.Status, nil
+	}
+	// Заблокированная заметка не завершается автоматически
+	if workflow.IsTerminal(status) {
+		blockers, err := s.noteRepository.GetOpenBlockers(ctx, note.ID)
+		if err != nil {
+			return "", err
+		}
+		if len(blockers) > 0 {
+			return note.Status, nil
+		}
+	}
+
+	slog.Info("Rolling up note status", "note_id", note.ID, "from", note.Status, "to", status)
+	if err := s.noteRepository.UpdateStatus(ctx, note.ID, status); err != nil {
+		return "", err
+	}
+	note.Status = status
+	return status, nil
+}
+
+// checklistStatus вычисляет статус заметки по пунктам чек-листа и workflow:
+// первый отмеченный пункт переводит заметку из начального статуса в статус начатой заметки, все отмеченные —
+// в завершающий статус (если включено autoDone), снятая отметка возвращает завершенную заметку в начатую.
+// Статус, которого нет в workflow, не выставляется
+func checklistStatus(workflow *models.Workflow, current string, items []models.ChecklistItem, autoDone bool) string {
+	if len(items) == 0 {
+		return current
+	}
+	doneCount := 0
+	for _, item := range items {
+		if item.Done {
+			doneCount++
+		}
+	}
+
+	target := current
+	switch {
+	case autoDone && doneCount == len(items):
+		if !workflow.IsTerminal(current) {
+			target = workflow.Terminal()
+		}
+	case autoDone && workflow.IsTerminal(current):
+		target = workflow.InProgress()
+	case doneCount > 0 && current == workflow.Initial():
+		target = workflow.InProgress()
+	}
+	if target == "" {
+		return current
+	}
+	return target
+}
+
+// BatchNotes выполняет операции в одной транзакции. В режиме atomic первая ошибка откатывает все
+// операции; иначе каждая операция выполняется в своей точке сохранения и ошибка откатывает только ее
+func (s *NoteService) BatchNotes(ctx context.Context, userID string, ops []models.NoteBatchOperation, atomic bool) ([]models.NoteBatchResult, error) {
+	slog.Info("Running note batch", "user_id", userID, "operations", len(ops), "atomic", atomic)
+	results := make([]models.NoteBatchResult, len(ops))
+	for i, op := range ops {
+		results[i].Op = op.Op
+	}
+
+	err := s.noteRepository.WithTransaction(ctx, func(repo di.INoteRepository) error {
+		for i, op := range ops {
+			if atomic {
+				txService := s.withRepository(repo)
+				results[i].Note, results[i].Err = txService.applyBatchOperation(ctx, userID, op)
+				if results[i].Err != nil {
+					return ErrBatchAborted
+				}
+				continue
+			}
+			_ = repo.WithTransaction(ctx, func(opRepo di.INoteRepository) error {
+				opService := s.withRepository(opRepo)
+				results[i].Note, results[i].Err = opService.applyBatchOperation(ctx, userID, op)
+				return results[i].Err
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		if !errors.Is(err, ErrBatchAborted) {
+			return nil, err
+		}
+		// Все операции, кроме сбойной, помечаются как откаченные
+		for i := range results {
+			if results[i].Err == nil {
+				results[i].Note = nil
+				results[i].Err = ErrBatchAborted
+			}
+		}
+		return results, ErrBatchAborted
+	}
+	return results, nil
+}
+
+func (s *NoteService) applyBatchOperation(ctx context.Context, userID string, op models.NoteBatchOperation) (*models.Note, error) {
+	if op.Op == models.BatchOpCreate {
+		if op.Note == nil {
+			return nil, ErrInvalidBatchOp
+		}
+		op.Note.UserID = userID
+		return s.CreateNote(ctx, op.Note)
+	}
+
+	note, err := s.noteRepository.Get(ctx, op.NoteID)
+	if err != nil {
+		return nil, err
+	}
+	permission, err := s.GetPermission(ctx, note, userID)
+	if err != nil {
+		return nil, err
+	}
+	if permission == "" {
+		return nil, ErrNoteNotFound
+	}
+	if op.Version != 0 && op.Version != note.Version {
+		return nil, ErrVersionConflict
+	}
+
+	switch op.Op {
+	case models.BatchOpUpdate:
+		if !models.PermissionAllows(permission, models.PermissionEditor) {
+			return nil, ErrPermissionDenied
+		}
+		if op.Apply != nil {
+			op.Apply(note)
+		}
+		return s.UpdateNote(ctx, note, false)
+	case models.BatchOpDelete:
+		if permission != models.PermissionOwner {
+			return nil, ErrPermissionDenied
+		}
+		return nil, s.DeleteNote(ctx, note.ID)
+	default:
+		return nil, ErrInvalidBatchOp
+	}
+}
+
+// GetBoard раскладывает заметки по колонкам статусов workflow в ручном порядке.
+// Заметки со статусами вне workflow попадают в дополнительные колонки в конце доски
+func (s *NoteService) GetBoard(ctx context.Context, userID string, projectID string) ([]models.BoardColumn, error) {
+	slog.Info("Fetching board", "user_id", userID, "project_id", projectID)
+	var workflowProject *string
+	if projectID != "" && projectID != models.InboxProjectID {
+		workflowProject = &projectID
+	}
+	workflow, err := s.workflowService.GetWorkflow(ctx, userID, workflowProject)
+	if err != nil {
+		return nil, err
+	}
+	notes, err := s.noteRepository.GetBoard(ctx, userID, models.NoteFilter{ProjectID: projectID, Limit: maxBoardNotes})
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make([]models.BoardColumn, 0, len(workflow.Statuses))
+	index := make(map[string]int, len(workflow.Statuses))
+	for _, status := range workflow.Statuses {
+		index[status.Name] = len(columns)
+		columns = append(columns, models.BoardColumn{Status: status.Name, Notes: []models.Note{}})
+	}
+	for _, note := range notes {
+		i, ok := index[note.Status]
+		if !ok {
+			i = len(columns)
+			index[note.Status] = i
+			columns = append(columns, models.BoardColumn{Status: note.Status, Notes: []models.Note{}})
+		}
+		columns[i].Notes = append(columns[i].Notes, note)
+	}
+	return columns, nil
+}
+
+// MoveNote переносит заметку в колонку status между соседями afterID (выше) и beforeID (ниже).
+// Без соседей заметка встает в конец колонки; меняется только ключ позиции самой заметки
+func (s *NoteService) MoveNote(ctx context.Context, note *models.Note, status, afterID, beforeID string) (*models.Note, error) {
+	if status == "" {
+		status = note.Status
+	}
+
+	var lower, upper string
+	var err error
+	if afterID != "" {
+		if lower, err = s.neighbourPosition(ctx, note, status, afterID); err != nil {
+			return nil, err
+		}
+	}
+	if beforeID != "" {
+		if upper, err = s.neighbourPosition(ctx, note, status, beforeID); err != nil {
+			return nil, err
+		}
+	}
+	switch {
+	case afterID != "" && beforeID == "":
+		upper, _, err = s.noteRepository.NeighbourPosition(ctx, note.UserID, status, lower, true, note.ID)
+	case afterID == "" && beforeID != "":
+		lower, _, err = s.noteRepository.NeighbourPosition(ctx, note.UserID, status, upper, false, note.ID)
+	case afterID == "" && beforeID == "":
+		lower, _, err = s.noteRepository.NeighbourPosition(ctx, note.UserID, status, "", false, note.ID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	position, err := positionBetween(lower, upper)
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Info("Moving note on board", "note_id", note.ID, "status", status, "position", position)
+	note.Status = status
+	note.Position = position
+	return s.UpdateNote(ctx, note, false)
+}
+
+// neighbourPosition загружает соседа по доске и проверяет, что он принадлежит тому же пользователю и стоит в целевой колонке
+func (s *NoteService) neighbourPosition(ctx context.Context, note *models.Note, status, neighbourID string) (string, error) {
+	if neighbourID == note.ID {
+		return "", ErrInvalidNeighbour
+	}
+	neighbour, err := s.noteRepository.Get(ctx, neighbourID)
+	if err != nil {
+		if errors.Is(err, ErrNoteNotFound) {
+			return "", ErrInvalidNeighbour
+		}
+		return "", err
+	}
+	if neighbour.UserID != note.UserID || neighbour.Status != status {
+		return "", ErrInvalidNeighbour
+	}
+	return neighbour.Position, nil
+}
+
+// revisionChanged сообщает, изменились ли поля, которые хранятся в ревизиях
+func revisionChanged(before, after *models.Note) bool {
+	return before.Title != after.Title ||
+		before.Content != after.Content ||
+		before.Status != after.Status ||
+		before.Priority != after.Priority
+}
+
+// normalizeDueAt переводит срок выполнения в UTC перед сохранением
+func normalizeDueAt(note *models.Note) {
+	if note.DueAt != nil {
+		dueAt := note.DueAt.UTC()
+		note.DueAt = &dueAt
+	}
+}
+
+// normalizeNoteTags приводит имена тегов заметки к каноничному виду
+func normalizeNoteTags(note *models.Note) error {
+	names := make([]string, 0, len(note.Tags))
+	for _, tag := range note.Tags {
+		names = append(names, tag.Name)
+	}
+	normalized, err := tags.NormalizeNames(names)
+	if err != nil {
+		return err
+	}
+	note.Tags = make([]models.Tag, 0, len(normalized))
+	for _, name := range normalized {
+		note.Tags = append(note.Tags, models.Tag{Name: name})
+	}
+	return nil
+}
+
+// GetPermission возвращает уровень доступа пользователя к заметке: owner, editor, viewer или пустую строку
+func (s *NoteService) GetPermission(ctx context.Context, note *models.Note, userID string) (string, error) {
+	if note.UserID == userID {
+		return models.PermissionOwner, nil
+	}
+	share, err := s.noteRepository.GetShare(ctx, note.ID, userID)
+	if err != nil {
+		if errors.Is(err, ErrShareNotFound) {
+			return "", nil
+		}
+		return "", err
+	}
+	return share.Permission, nil
+}
+
+// ShareNote открывает доступ к заметке пользователю с указанным email. Повторный вызов меняет уровень доступа
+func (s *NoteService) ShareNote(ctx context.Context, note *models.Note, email, permission string) (*models.NoteShare, error) {
+	if permission != models.PermissionViewer && permission != models.PermissionEditor {
+		return nil, ErrInvalidPermission
+	}
+	user, err := s.userRepository.FindByEmail(ctx, strings.TrimSpace(email))
+	if err != nil {
+		return nil, err
+	}
+	if user.ID == note.UserID {
+		return nil, ErrShareWithOwner
+	}
+
+	slog.Info("Sharing note", "note_id", note.ID, "user_id", user.ID, "permission", permission)
+	return s.noteRepository.SaveShare(ctx, &models.NoteShare{NoteID: note.ID, UserID: user.ID, Permission: permission})
+}
+
+func (s *NoteService) GetShares(ctx context.Context, noteID string) ([]models.NoteShare, error) {
+	slog.Info("Fetching note shares", "note_id", noteID)
+	return s.noteRepository.GetShares(ctx, noteID)
+}
+
+func (s *NoteService) RevokeShare(ctx context.Context, noteID, userID string) error {
+	slog.Info("Revoking note share", "note_id", noteID, "user_id", userID)
+	return s.noteRepository.DeleteShare(ctx, noteID, userID)
+}
+
+func (s *NoteService) GetSharedNotes(ctx context.Context, userID string, limit, offset int) ([]models.SharedNote, int64, error) {
+	slog.Info("Fetching notes shared with user", "user_id", userID, "limit", limit, "offset", offset)
+	return s.noteRepository.GetSharedWith(ctx, userID, limit, offset)
+}
+
+// CreateLink создает публичную ссылку на заметку с необязательными паролем и сроком действия
+func (s *NoteService) CreateLink(ctx context.Context, note *models.Note, password string, expiresAt *time.Time) (*models.NoteLink, error) {
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return nil, ErrInvalidLinkExpiry
+	}
+	link := &models.NoteLink{NoteID: note.ID, Token: idgen.GenerateNanoID(), ExpiresAt: expiresAt}
+	if link.Token == "" {
+		return nil, fmt.Errorf("generate token: %w", ErrCreateNote)
+	}
+	if password != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, fmt.Errorf("hash link password: %w", err)
+		}
+		link.PasswordHash = string(hash)
+	}
+
+	slog.Info("Creating note link", "note_id", note.ID, "protected", password != "", "expires_at", expiresAt)
+	return s.noteRepository.CreateLink(ctx, link)
+}
+
+func (s *NoteService) GetLinks(ctx context.Context, noteID string) ([]models.NoteLink, error) {
+	slog.Info("Fetching note links", "note_id", noteID)
+	return s.noteRepository.GetLinks(ctx, noteID)
+}
+
+func (s *NoteService) RevokeLink(ctx context.Context, noteID, linkID string) error {
+	slog.Info("Revoking note link", "note_id", noteID, "link_id", linkID)
+	return s.noteRepository.DeleteLink(ctx, noteID, linkID)
+}
+
+// OpenLink проверяет публичную ссылку и возвращает заметку, учитывая открытие в счетчике ссылки.
+// Заметка в корзине по ссылке недоступна
+func (s *NoteService) OpenLink(ctx context.Context, token, password string, now time.Time) (*models.Note, error) {
+	link, err := s.noteRepository.GetLinkByToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if link.ExpiresAt != nil && !now.Before(*link.ExpiresAt) {
+		return nil, ErrLinkExpired
+	}
+	if link.PasswordHash != "" {
+		if password == "" {
+			return nil, ErrLinkPasswordRequired
+		}
+		if err := bcrypt.CompareHashAndPassword([]byte(link.PasswordHash), []byte(password)); err != nil {
+			return nil, ErrLinkPasswordInvalid
+		}
+	}
+	note, err := s.noteRepository.Get(ctx, link.NoteID)
+	if err != nil {
+		if errors.Is(err, ErrNoteNotFound) {
+			return nil, ErrLinkNotFound
+		}
+		return nil, err
+	}
+	if err := s.noteRepository.RecordLinkAccess(ctx, link.ID, now); err != nil {
+		return nil, err
+	}
+
+	slog.Info("Opened note by public link", "note_id", note.ID, "link_id", link.ID)
+	return note, nil
+}
+
+// AddDependency отмечает, что note заблокирована заметкой blockerID. Обе заметки должны принадлежать одному владельцу,
+// а пользователь — иметь доступ к блокирующей заметке
+func (s *NoteService) AddDependency(ctx context.Context, userID string, note *models.Note, blockerID string) error {
+	if blockerID == note.ID {
+		return ErrDependencyCycle
+	}
+	blocker, err := s.noteRepository.Get(ctx, blockerID)
+	if err != nil {
+		return err
+	}
+	if blocker.UserID != note.UserID {
+		return ErrInvalidDependency
+	}
+	permission, err := s.GetPermission(ctx, blocker, userID)
+	if err != nil {
+		return err
+	}
+	if permission == "" {
+		return ErrNoteNotFound
+	}
+
+	slog.Info("Adding note dependency", "note_id", note.ID, "blocker_id", blockerID)
+	return s.noteRepository.AddDependency(ctx, note.UserID, note.ID, blockerID)
+}
+
+func (s *NoteService) RemoveDependency(ctx context.Context, noteID, blockerID string) error {
+	slog.Info("Removing note dependency", "note_id", noteID, "blocker_id", blockerID)
+	return s.noteRepository.DeleteDependency(ctx, noteID, blockerID)
+}
+
+func (s *NoteService) GetDependencyGraph(ctx context.Context, noteID, userID string) (*models.DependencyGraph, error) {
+	slog.Info("Fetching dependency graph", "note_id", noteID, "user_id", userID)
+	return s.noteRepository.GetDependencyGraph(ctx, noteID, userID)
+}
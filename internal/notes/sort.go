@@ -0,0 +1,202 @@
+package notes
+
+import (
+	"ToDo/internal/models"
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultSortField = "created_at"
+
+// sortColumn описывает поле сортировки: SQL-выражение, тип для сравнения в keyset-запросе
+// и способ получить значение из заметки для курсора
+type sortColumn struct {
+	expr  string
+	cast  string
+	value func(note *models.Note) string
+}
+
+var priorityRanks = map[string]int{"low": 0, "normal": 1, "high": 2, "urgent": 3}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// sortableColumns — белый список полей сортировки и соответствующие им SQL-выражения.
+// Значения из запроса никогда не попадают в ORDER BY напрямую.
+var sortableColumns = map[string]sortColumn{
+	"created_at": {
+		expr:  "notes.created_at",
+		cast:  "timestamptz",
+		value: func(note *models.Note) string { return formatTime(note.CreatedAt) },
+	},
+	"updated_at": {
+		expr:  "notes.updated_at",
+		cast:  "timestamptz",
+		value: func(note *models.Note) string { return formatTime(note.UpdatedAt) },
+	},
+	"due_at": {
+		expr: "COALESCE(notes.due_at, 'infinity'::timestamptz)",
+		cast: "timestamptz",
+		value: func(note *models.Note) string {
+			if note.DueAt == nil {
+				return "infinity"
+			}
+			return formatTime(*note.DueAt)
+		},
+	},
+	"title": {
+		expr:  "notes.title",
+		cast:  "text",
+		value: func(note *models.Note) string { return note.Title },
+	},
+	"status": {
+		expr:  "notes.status",
+		cast:  "text",
+		value: func(note *models.Note) string { return note.Status },
+	},
+	"position": {
+		expr:  `notes.position COLLATE "C"`,
+		cast:  "text",
+		value: func(note *models.Note) string { return note.Position },
+	},
+	"priority": {
+		expr: "CASE notes.priority WHEN 'low' THEN 0 WHEN 'normal' THEN 1 WHEN 'high' THEN 2 WHEN 'urgent' THEN 3 ELSE 1 END",
+		cast: "integer",
+		value: func(note *models.Note) string {
+			rank, ok := priorityRanks[note.Priority]
+			if !ok {
+				rank = priorityRanks["normal"]
+			}
+			return strconv.Itoa(rank)
+		},
+	},
+}
+
+// parseSort разбирает параметр вида "-priority,due_at,title"
+func parseSort(raw string) ([]models.SortField, error) {
+	if strings.TrimSpace(raw) == "" {
+		return []models.SortField{{Field: defaultSortField}}, nil
+	}
+
+	seen := make(map[string]bool)
+	fields := make([]models.SortField, 0)
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		field := models.SortField{Field: strings.TrimPrefix(part, "-"), Desc: strings.HasPrefix(part, "-")}
+		if _, ok := sortableColumns[field.Field]; !ok || seen[field.Field] {
+			return nil, ErrInvalidSort
+		}
+		seen[field.Field] = true
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// formatSort возвращает примененную сортировку в том же формате, что и параметр запроса
+func formatSort(fields []models.SortField) string {
+	parts := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if field.Desc {
+			parts = append(parts, "-"+field.Field)
+		} else {
+			parts = append(parts, field.Field)
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+func withDefaultSort(fields []models.SortField) []models.SortField {
+	if len(fields) == 0 {
+		return []models.SortField{{Field: defaultSortField}}
+	}
+	return fields
+}
+
+// orderClause строит ORDER BY из разобранных полей; id добавляется для стабильного порядка
+func orderClause(fields []models.SortField) string {
+	fields = withDefaultSort(fields)
+	parts := make([]string, 0, len(fields)+1)
+	for _, field := range fields {
+		direction := " asc"
+		if field.Desc {
+			direction = " desc"
+		}
+		parts = append(parts, sortableColumns[field.Field].expr+direction)
+	}
+	parts = append(parts, "notes.id asc")
+	return strings.Join(parts, ", ")
+}
+
+// keysetCondition строит условие "строго после курсора" для сортировки (f1, ..., fn, id):
+// (f1 > v1) OR (f1 = v1 AND f2 > v2) OR ... OR (f1 = v1 AND ... AND fn = vn AND id > vid)
+func keysetCondition(fields []models.SortField, cursor *models.NoteCursor) (string, []any) {
+	fields = withDefaultSort(fields)
+	branches := make([]string, 0, len(fields)+1)
+	args := make([]any, 0)
+	equalities := make([]string, 0, len(fields))
+	equalityArgs := make([]any, 0, len(fields))
+
+	for i, field := range fields {
+		column := sortableColumns[field.Field]
+		placeholder := "?::" + column.cast
+		operator := " > "
+		if field.Desc {
+			operator = " < "
+		}
+
+		branch := append(append([]string{}, equalities...), column.expr+operator+placeholder)
+		branches = append(branches, "("+strings.Join(branch, " AND ")+")")
+		args = append(args, equalityArgs...)
+		args = append(args, cursor.Values[i])
+
+		equalities = append(equalities, column.expr+" = "+placeholder)
+		equalityArgs = append(equalityArgs, cursor.Values[i])
+	}
+
+	last := append(equalities, "notes.id > ?")
+	branches = append(branches, "("+strings.Join(last, " AND ")+")")
+	args = append(args, equalityArgs...)
+	args = append(args, cursor.ID)
+
+	return "(" + strings.Join(branches, " OR ") + ")", args
+}
+
+// cursorPayload — содержимое непрозрачного курсора; сортировка сохраняется,
+// чтобы курсор нельзя было применить к выдаче с другим порядком
+type cursorPayload struct {
+	Sort   string   `json:"s"`
+	Values []string `json:"v"`
+	ID     string   `json:"id"`
+}
+
+// encodeCursor формирует курсор, указывающий на позицию сразу после note
+func encodeCursor(fields []models.SortField, note *models.Note) string {
+	fields = withDefaultSort(fields)
+	payload := cursorPayload{Sort: formatSort(fields), ID: note.ID}
+	for _, field := range fields {
+		payload.Values = append(payload.Values, sortableColumns[field.Field].value(note))
+	}
+	data, _ := json.Marshal(payload)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor проверяет курсор и его соответствие текущей сортировке
+func decodeCursor(raw string, fields []models.SortField) (*models.NoteCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var payload cursorPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	fields = withDefaultSort(fields)
+	if payload.Sort != formatSort(fields) || len(payload.Values) != len(fields) || payload.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+	return &models.NoteCursor{Values: payload.Values, ID: payload.ID}, nil
+}
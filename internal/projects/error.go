@@ -0,0 +1,10 @@
+package projects
+
+import "errors"
+
+var (
+	ErrProjectNotFound   = errors.New("project not found")
+	ErrCreateProject     = errors.New("failed to create project")
+	ErrProjectArchived   = errors.New("project is archived")
+	ErrInvalidDeleteMode = errors.New("invalid project delete mode")
+)
@@ -0,0 +1,38 @@
+package projects
+
+import (
+	"ToDo/configs"
+	"ToDo/pkg/di"
+	"ToDo/pkg/middleware"
+	"net/http"
+)
+
+type ProjectHandlerDeps struct {
+	Config         *configs.Config
+	TokenDenylist  di.ITokenDenylist
+	ProjectService di.IProjectService
+}
+
+type ProjectHandler struct {
+	Config         *configs.Config
+	ProjectService di.IProjectService
+}
+
+func NewProjectHandler(router *http.ServeMux, deps *ProjectHandlerDeps) {
+	handler := &ProjectHandler{
+		Config:         deps.Config,
+		ProjectService: deps.ProjectService,
+	}
+	middlewares := middleware.Chain(
+		middleware.CORS,
+		middleware.Logging,
+		middleware.RateLimiter(deps.Config.RateLimit.MaxRequests, deps.Config.RateLimit.Burst, deps.Config.RateLimit.TTL),
+		middleware.IsAuthenticated(deps.Config, deps.TokenDenylist),
+	)
+
+	router.Handle("POST /projects", middlewares(handler.CreateProject()))
+	router.Handle("GET /projects", middlewares(handler.GetAllProjects()))
+	router.Handle("GET /projects/{id}", middlewares(handler.GetProject()))
+	router.Handle("PATCH /projects/{id}", middlewares(handler.UpdateProject()))
+	router.Handle("DELETE /projects/{id}", middlewares(handler.DeleteProject()))
+}
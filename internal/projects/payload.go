@@ -0,0 +1,19 @@
+package projects
+
+import "ToDo/internal/models"
+
+type CreateProjectRequest struct {
+	Name  string `json:"name" validate:"required,max=100"`
+	Color string `json:"color" validate:"omitempty,hexcolor,len=7"`
+}
+
+type UpdateProjectRequest struct {
+	Name     string `json:"name" validate:"omitempty,max=100"`
+	Color    string `json:"color" validate:"omitempty,hexcolor,len=7"`
+	Archived *bool  `json:"archived"`
+	Position *int   `json:"position" validate:"omitempty,min=0"`
+}
+
+type GetAllProjectsResponse struct {
+	Projects []models.Project `json:"projects"`
+}
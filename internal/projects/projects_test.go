@@ -0,0 +1,104 @@
+package projects
+
+import (
+	"context"
+	"testing"
+
+	"ToDo/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockProjectRepository — мок для IProjectRepository
+type MockProjectRepository struct {
+	mock.Mock
+}
+
+func (m *MockProjectRepository) Create(ctx context.Context, project *models.Project) (*models.Project, error) {
+	args := m.Called(ctx, project)
+	result, _ := args.Get(0).(*models.Project)
+	return result, args.Error(1)
+}
+
+func (m *MockProjectRepository) GetAll(ctx context.Context, userID string, includeArchived bool) ([]models.Project, error) {
+	args := m.Called(ctx, userID, includeArchived)
+	result, _ := args.Get(0).([]models.Project)
+	return result, args.Error(1)
+}
+
+func (m *MockProjectRepository) Get(ctx context.Context, projectID string) (*models.Project, error) {
+	args := m.Called(ctx, projectID)
+	result, _ := args.Get(0).(*models.Project)
+	return result, args.Error(1)
+}
+
+func (m *MockProjectRepository) Update(ctx context.Context, project *models.Project) (*models.Project, error) {
+	args := m.Called(ctx, project)
+	result, _ := args.Get(0).(*models.Project)
+	return result, args.Error(1)
+}
+
+func (m *MockProjectRepository) Delete(ctx context.Context, projectID string, cascade bool) error {
+	args := m.Called(ctx, projectID, cascade)
+	return args.Error(0)
+}
+
+// TestProjectService_DeleteProject — тесты для DeleteProject
+func TestProjectService_DeleteProject(t *testing.T) {
+	tests := []struct {
+		name      string
+		mode      string
+		mockSetup func(m *MockProjectRepository)
+		wantErr   bool
+		err       error
+	}{
+		{
+			name: "Default mode moves notes to inbox",
+			mode: "",
+			mockSetup: func(m *MockProjectRepository) {
+				m.On("Delete", mock.Anything, "project123", false).Return(nil)
+			},
+		},
+		{
+			name: "Cascade mode deletes notes",
+			mode: DeleteModeCascade,
+			mockSetup: func(m *MockProjectRepository) {
+				m.On("Delete", mock.Anything, "project123", true).Return(nil)
+			},
+		},
+		{
+			name:      "Unknown mode returns error",
+			mode:      "archive",
+			mockSetup: func(m *MockProjectRepository) {},
+			wantErr:   true,
+			err:       ErrInvalidDeleteMode,
+		},
+		{
+			name: "Repository error on deletion",
+			mode: DeleteModeInbox,
+			mockSetup: func(m *MockProjectRepository) {
+				m.On("Delete", mock.Anything, "project123", false).Return(assert.AnError)
+			},
+			wantErr: true,
+			err:     assert.AnError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockProjectRepository)
+			tt.mockSetup(mockRepo)
+			service := NewProjectService(mockRepo)
+
+			err := service.DeleteProject(context.Background(), "project123", tt.mode)
+
+			if tt.wantErr {
+				assert.ErrorIs(t, err, tt.err, "expected error")
+			} else {
+				assert.NoError(t, err, "unexpected error")
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
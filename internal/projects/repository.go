@@ -0,0 +1,106 @@
+package projects
+
+import (
+	"ToDo/internal/models"
+	"ToDo/pkg/idgen"
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ProjectRepository struct {
+	db *gorm.DB
+}
+
+func NewProjectRepository(dataBase *gorm.DB) *ProjectRepository {
+	return &ProjectRepository{
+		db: dataBase,
+	}
+}
+
+// Create добавляет проект в конец списка проектов пользователя
+func (r *ProjectRepository) Create(ctx context.Context, project *models.Project) (*models.Project, error) {
+	project.ID = idgen.GenerateNanoID()
+	if project.ID == "" {
+		return nil, fmt.Errorf("generate id: %w", ErrCreateProject)
+	}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var maxPosition *int
+		if err := tx.Model(&models.Project{}).
+			Where("user_id = ?", project.UserID).
+			Select("MAX(position)").
+			Scan(&maxPosition).Error; err != nil {
+			return err
+		}
+		if maxPosition != nil {
+			project.Position = *maxPosition + 1
+		}
+		return tx.Omit(clause.Associations).Create(project).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create project with ID %s: %w", project.ID, err)
+	}
+	return project, nil
+}
+
+func (r *ProjectRepository) GetAll(ctx context.Context, userId string, includeArchived bool) ([]models.Project, error) {
+	var projects []models.Project
+	query := r.db.WithContext(ctx).Where("user_id = ?", userId)
+	if !includeArchived {
+		query = query.Where("archived = ?", false)
+	}
+	if err := query.Order("position asc, created_at asc").Find(&projects).Error; err != nil {
+		return nil, fmt.Errorf("get all projects for user %s: %w", userId, err)
+	}
+	return projects, nil
+}
+
+func (r *ProjectRepository) Get(ctx context.Context, projectId string) (*models.Project, error) {
+	var project models.Project
+	result := r.db.WithContext(ctx).Where("id = ?", projectId).First(&project)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("get project by id %s: %w", projectId, ErrProjectNotFound)
+		}
+		return nil, fmt.Errorf("get project by id %s: %w", projectId, result.Error)
+	}
+	return &project, nil
+}
+
+func (r *ProjectRepository) Update(ctx context.Context, project *models.Project) (*models.Project, error) {
+	result := r.db.WithContext(ctx).Omit(clause.Associations).Save(project)
+	if result.Error != nil {
+		return nil, fmt.Errorf("update project with ID %s: %w", project.ID, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, fmt.Errorf("update project with ID %s: %w", project.ID, ErrProjectNotFound)
+	}
+	return project, nil
+}
+
+// Delete удаляет проект вместе с заметками (cascade) или переносит заметки во входящие
+func (r *ProjectRepository) Delete(ctx context.Context, projectId string, cascade bool) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if cascade {
+			if err := tx.Where("project_id = ?", projectId).Delete(&models.Note{}).Error; err != nil {
+				return fmt.Errorf("delete notes of project %s: %w", projectId, err)
+			}
+		} else {
+			if err := tx.Model(&models.Note{}).Where("project_id = ?", projectId).Update("project_id", nil).Error; err != nil {
+				return fmt.Errorf("move notes of project %s to inbox: %w", projectId, err)
+			}
+		}
+
+		result := tx.Where("id = ?", projectId).Delete(&models.Project{})
+		if result.Error != nil {
+			return fmt.Errorf("delete project with ID %s: %w", projectId, result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("delete project with ID %s: %w", projectId, ErrProjectNotFound)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,162 @@
+package projects
+
+import (
+	"ToDo/internal/models"
+	"ToDo/pkg/middleware"
+	"ToDo/pkg/req"
+	"ToDo/pkg/res"
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+func getUserId(r *http.Request) string {
+	userId, _ := r.Context().Value(middleware.ContextUserIDKey).(string)
+	return userId
+}
+
+// loadOwnedProject загружает проект из пути запроса и проверяет, что он принадлежит пользователю.
+// При ошибке ответ уже записан и возвращается nil
+func (h *ProjectHandler) loadOwnedProject(w http.ResponseWriter, r *http.Request) *models.Project {
+	projectId := r.PathValue("id")
+	if projectId == "" {
+		res.JsonResponse(w, res.ErrorResponse{Error: "project id is required"}, http.StatusBadRequest)
+		return nil
+	}
+	userId := getUserId(r)
+	if userId == "" {
+		res.JsonResponse(w, res.ErrorResponse{Error: "unauthorized"}, http.StatusUnauthorized)
+		return nil
+	}
+
+	project, err := h.ProjectService.GetProject(r.Context(), projectId)
+	if err != nil {
+		if errors.Is(err, ErrProjectNotFound) {
+			res.JsonResponse(w, res.ErrorResponse{Error: "project not found"}, http.StatusNotFound)
+		} else {
+			res.JsonResponse(w, res.ErrorResponse{Error: "failed to get project by id"}, http.StatusInternalServerError)
+		}
+		return nil
+	}
+	if project.UserID != userId {
+		res.JsonResponse(w, res.ErrorResponse{Error: "unauthorized"}, http.StatusUnauthorized)
+		return nil
+	}
+	return project
+}
+
+func (h *ProjectHandler) CreateProject() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := req.HandleBody[CreateProjectRequest](&w, r)
+		if err != nil {
+			return
+		}
+		userId := getUserId(r)
+		if userId == "" {
+			res.JsonResponse(w, res.ErrorResponse{Error: "unauthorized"}, http.StatusUnauthorized)
+			return
+		}
+
+		project, err := h.ProjectService.CreateProject(r.Context(), &models.Project{
+			Name:   body.Name,
+			Color:  body.Color,
+			UserID: userId,
+		})
+		if err != nil {
+			res.JsonResponse(w, res.ErrorResponse{Error: "failed to create project"}, http.StatusInternalServerError)
+			return
+		}
+		res.JsonResponse(w, project, http.StatusCreated)
+	}
+}
+
+func (h *ProjectHandler) GetAllProjects() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userId := getUserId(r)
+		if userId == "" {
+			res.JsonResponse(w, res.ErrorResponse{Error: "unauthorized"}, http.StatusUnauthorized)
+			return
+		}
+		includeArchived, _ := strconv.ParseBool(r.URL.Query().Get("archived"))
+
+		projects, err := h.ProjectService.GetAllProjects(r.Context(), userId, includeArchived)
+		if err != nil {
+			res.JsonResponse(w, res.ErrorResponse{Error: "failed to get projects"}, http.StatusInternalServerError)
+			return
+		}
+		res.JsonResponse(w, GetAllProjectsResponse{Projects: projects}, http.StatusOK)
+	}
+}
+
+func (h *ProjectHandler) GetProject() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		project := h.loadOwnedProject(w, r)
+		if project == nil {
+			return
+		}
+		res.JsonResponse(w, project, http.StatusOK)
+	}
+}
+
+func (h *ProjectHandler) UpdateProject() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := req.HandleBody[UpdateProjectRequest](&w, r)
+		if err != nil {
+			return
+		}
+		project := h.loadOwnedProject(w, r)
+		if project == nil {
+			return
+		}
+
+		// Обновляем только переданные поля
+		if body.Name != "" {
+			project.Name = body.Name
+		}
+		if body.Color != "" {
+			project.Color = body.Color
+		}
+		if body.Archived != nil {
+			project.Archived = *body.Archived
+		}
+		if body.Position != nil {
+			project.Position = *body.Position
+		}
+
+		updatedProject, err := h.ProjectService.UpdateProject(r.Context(), project)
+		if err != nil {
+			if errors.Is(err, ErrProjectNotFound) {
+				res.JsonResponse(w, res.ErrorResponse{Error: "project not found"}, http.StatusNotFound)
+			} else {
+				res.JsonResponse(w, res.ErrorResponse{Error: "failed to update project"}, http.StatusInternalServerError)
+			}
+			return
+		}
+		res.JsonResponse(w, updatedProject, http.StatusOK)
+	}
+}
+
+// DeleteProject удаляет проект; ?mode=cascade удаляет и его заметки, по умолчанию (mode=inbox) они переносятся во входящие
+func (h *ProjectHandler) DeleteProject() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		project := h.loadOwnedProject(w, r)
+		if project == nil {
+			return
+		}
+
+		err := h.ProjectService.DeleteProject(r.Context(), project.ID, r.URL.Query().Get("mode"))
+		if err != nil {
+			switch {
+			case errors.Is(err, ErrInvalidDeleteMode):
+				res.JsonResponse(w, res.ErrorResponse{Error: "mode must be inbox or cascade"}, http.StatusBadRequest)
+			case errors.Is(err, ErrProjectNotFound):
+				res.JsonResponse(w, res.ErrorResponse{Error: "project not found"}, http.StatusNotFound)
+			default:
+				res.JsonResponse(w, res.ErrorResponse{Error: "failed to delete project"}, http.StatusInternalServerError)
+			}
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
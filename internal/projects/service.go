@@ -0,0 +1,52 @@
+package projects
+
+import (
+	"ToDo/internal/models"
+	"ToDo/pkg/di"
+	"context"
+	"log/slog"
+)
+
+const (
+	DeleteModeInbox   = "inbox"   // Заметки проекта переносятся во входящие
+	DeleteModeCascade = "cascade" // Заметки удаляются вместе с проектом (попадают в корзину)
+)
+
+type ProjectService struct {
+	projectRepository di.IProjectRepository
+}
+
+func NewProjectService(projectRepo di.IProjectRepository) *ProjectService {
+	return &ProjectService{projectRepository: projectRepo}
+}
+
+func (s *ProjectService) CreateProject(ctx context.Context, project *models.Project) (*models.Project, error) {
+	slog.Info("Creating project", "name", project.Name, "user_id", project.UserID)
+	return s.projectRepository.Create(ctx, project)
+}
+
+func (s *ProjectService) GetAllProjects(ctx context.Context, userID string, includeArchived bool) ([]models.Project, error) {
+	slog.Info("Fetching all projects", "user_id", userID, "include_archived", includeArchived)
+	return s.projectRepository.GetAll(ctx, userID, includeArchived)
+}
+
+func (s *ProjectService) GetProject(ctx context.Context, projectID string) (*models.Project, error) {
+	slog.Info("Fetching project", "project_id", projectID)
+	return s.projectRepository.Get(ctx, projectID)
+}
+
+func (s *ProjectService) UpdateProject(ctx context.Context, project *models.Project) (*models.Project, error) {
+	slog.Info("Updating project", "project_id", project.ID)
+	return s.projectRepository.Update(ctx, project)
+}
+
+func (s *ProjectService) DeleteProject(ctx context.Context, projectID string, mode string) error {
+	if mode == "" {
+		mode = DeleteModeInbox
+	}
+	if mode != DeleteModeInbox && mode != DeleteModeCascade {
+		return ErrInvalidDeleteMode
+	}
+	slog.Info("Deleting project", "project_id", projectID, "mode", mode)
+	return s.projectRepository.Delete(ctx, projectID, mode == DeleteModeCascade)
+}
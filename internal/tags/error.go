@@ -0,0 +1,9 @@
+package tags
+
+import "errors"
+
+var (
+	ErrTagNotFound      = errors.New("tag not found")
+	ErrTagAlreadyExists = errors.New("tag already exists")
+	ErrInvalidTagName   = errors.New("invalid tag name")
+)
@@ -0,0 +1,36 @@
+package tags
+
+import (
+	"ToDo/configs"
+	"ToDo/pkg/di"
+	"ToDo/pkg/middleware"
+	"net/http"
+)
+
+type TagHandlerDeps struct {
+	Config        *configs.Config
+	TokenDenylist di.ITokenDenylist
+	TagService    di.ITagService
+}
+
+type TagHandler struct {
+	Config     *configs.Config
+	TagService di.ITagService
+}
+
+func NewTagHandler(router *http.ServeMux, deps *TagHandlerDeps) {
+	handler := &TagHandler{
+		Config:     deps.Config,
+		TagService: deps.TagService,
+	}
+	middlewares := middleware.Chain(
+		middleware.CORS,
+		middleware.Logging,
+		middleware.RateLimiter(deps.Config.RateLimit.MaxRequests, deps.Config.RateLimit.Burst, deps.Config.RateLimit.TTL),
+		middleware.IsAuthenticated(deps.Config, deps.TokenDenylist),
+	)
+
+	router.Handle("GET /tags", middlewares(handler.GetAllTags()))
+	router.Handle("PATCH /tags/{id}", middlewares(handler.RenameTag()))
+	router.Handle("DELETE /tags/{id}", middlewares(handler.DeleteTag()))
+}
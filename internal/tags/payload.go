@@ -0,0 +1,11 @@
+package tags
+
+import "ToDo/internal/models"
+
+type GetAllTagsResponse struct {
+	Tags []models.Tag `json:"tags"`
+}
+
+type RenameTagRequest struct {
+	Name string `json:"name" validate:"required,max=50"`
+}
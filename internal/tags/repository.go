@@ -0,0 +1,99 @@
+package tags
+
+import (
+	"ToDo/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+)
+
+type TagRepository struct {
+	db *gorm.DB
+}
+
+func NewTagRepository(dataBase *gorm.DB) *TagRepository {
+	return &TagRepository{
+		db: dataBase,
+	}
+}
+
+func (r *TagRepository) GetAll(ctx context.Context, userId string) ([]models.Tag, error) {
+	var tags []models.Tag
+	result := r.db.WithContext(ctx).Where("user_id = ?", userId).Order("name asc").Find(&tags)
+	if result.Error != nil {
+		return nil, fmt.Errorf("get all tags for user %s: %w", userId, result.Error)
+	}
+	return tags, nil
+}
+
+func (r *TagRepository) Get(ctx context.Context, tagId string) (*models.Tag, error) {
+	var tag models.Tag
+	result := r.db.WithContext(ctx).Where("id = ?", tagId).First(&tag)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("get tag by id %s: %w", tagId, ErrTagNotFound)
+		}
+		return nil, fmt.Errorf("get tag by id %s: %w", tagId, result.Error)
+	}
+	return &tag, nil
+}
+
+func (r *TagRepository) Update(ctx context.Context, tag *models.Tag) (*models.Tag, error) {
+	var duplicates int64
+	countQuery := r.db.WithContext(ctx).Model(&models.Tag{}).
+		Where("user_id = ? AND name = ? AND id <> ?", tag.UserID, tag.Name, tag.ID).
+		Count(&duplicates)
+	if countQuery.Error != nil {
+		return nil, fmt.Errorf("check tag name %s: %w", tag.Name, countQuery.Error)
+	}
+	if duplicates > 0 {
+		return nil, fmt.Errorf("update tag with ID %s: %w", tag.ID, ErrTagAlreadyExists)
+	}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Save(tag)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrTagNotFound
+		}
+		return touchTaggedNotes(tx, tag.ID)
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, fmt.Errorf("update tag with ID %s: %w", tag.ID, ErrTagAlreadyExists)
+		}
+		return nil, fmt.Errorf("update tag with ID %s: %w", tag.ID, err)
+	}
+	return tag, nil
+}
+
+func (r *TagRepository) Delete(ctx context.Context, tagId string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Сначала убираем тег со всех заметок
+		if err := touchTaggedNotes(tx, tagId); err != nil {
+			return fmt.Errorf("touch notes of tag with ID %s: %w", tagId, err)
+		}
+		if err := tx.Exec("DELETE FROM note_tags WHERE tag_id = ?", tagId).Error; err != nil {
+			return fmt.Errorf("detach tag with ID %s: %w", tagId, err)
+		}
+		result := tx.Where("id = ?", tagId).Delete(&models.Tag{})
+		if result.Error != nil {
+			return fmt.Errorf("delete tag with ID %s: %w", tagId, result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("delete tag with ID %s: %w", tagId, ErrTagNotFound)
+		}
+		return nil
+	})
+}
+
+// touchTaggedNotes увеличивает версию заметок с тегом: имя тега входит в представление заметки, а значит и в ETag.
+// Заметки в корзине тоже затрагиваются — после восстановления их ETag должен отличаться
+func touchTaggedNotes(tx *gorm.DB, tagId string) error {
+	return tx.Model(&models.Note{}).Unscoped().
+		Where("id IN (SELECT note_id FROM note_tags WHERE tag_id = ?)", tagId).
+		Update("version", gorm.Expr("version + 1")).Error
+}
@@ -0,0 +1,116 @@
+package tags
+
+import (
+	"ToDo/pkg/middleware"
+	"ToDo/pkg/req"
+	"ToDo/pkg/res"
+	"errors"
+	"net/http"
+)
+
+func getUserId(r *http.Request) string {
+	userId, _ := r.Context().Value(middleware.ContextUserIDKey).(string)
+	return userId
+}
+
+func (h *TagHandler) GetAllTags() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userId := getUserId(r)
+		if userId == "" {
+			res.JsonResponse(w, res.ErrorResponse{Error: "unauthorized"}, http.StatusUnauthorized)
+			return
+		}
+
+		tags, err := h.TagService.GetAllTags(r.Context(), userId)
+		if err != nil {
+			res.JsonResponse(w, res.ErrorResponse{Error: "failed to get tags"}, http.StatusInternalServerError)
+			return
+		}
+
+		res.JsonResponse(w, GetAllTagsResponse{Tags: tags}, http.StatusOK)
+	}
+}
+
+func (h *TagHandler) RenameTag() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tagId := r.PathValue("id")
+		if tagId == "" {
+			res.JsonResponse(w, res.ErrorResponse{Error: "tag id is required"}, http.StatusBadRequest)
+			return
+		}
+		body, err := req.HandleBody[RenameTagRequest](&w, r)
+		if err != nil {
+			return
+		}
+		userId := getUserId(r)
+		if userId == "" {
+			res.JsonResponse(w, res.ErrorResponse{Error: "unauthorized"}, http.StatusUnauthorized)
+			return
+		}
+
+		tag, err := h.TagService.GetTag(r.Context(), tagId)
+		if err != nil {
+			if errors.Is(err, ErrTagNotFound) {
+				res.JsonResponse(w, res.ErrorResponse{Error: "tag not found"}, http.StatusNotFound)
+			} else {
+				res.JsonResponse(w, res.ErrorResponse{Error: "failed to get tag by id"}, http.StatusInternalServerError)
+			}
+			return
+		}
+		if tag.UserID != userId {
+			res.JsonResponse(w, res.ErrorResponse{Error: "unauthorized"}, http.StatusUnauthorized)
+			return
+		}
+
+		updatedTag, err := h.TagService.RenameTag(r.Context(), tag, body.Name)
+		if err != nil {
+			switch {
+			case errors.Is(err, ErrInvalidTagName):
+				res.JsonResponse(w, res.ErrorResponse{Error: "invalid tag name"}, http.StatusBadRequest)
+			case errors.Is(err, ErrTagAlreadyExists):
+				res.JsonResponse(w, res.ErrorResponse{Error: "tag already exists"}, http.StatusConflict)
+			default:
+				res.JsonResponse(w, res.ErrorResponse{Error: "failed to rename tag"}, http.StatusInternalServerError)
+			}
+			return
+		}
+
+		res.JsonResponse(w, updatedTag, http.StatusOK)
+	}
+}
+
+func (h *TagHandler) DeleteTag() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tagId := r.PathValue("id")
+		if tagId == "" {
+			res.JsonResponse(w, res.ErrorResponse{Error: "tag id is required"}, http.StatusBadRequest)
+			return
+		}
+		userId := getUserId(r)
+		if userId == "" {
+			res.JsonResponse(w, res.ErrorResponse{Error: "unauthorized"}, http.StatusUnauthorized)
+			return
+		}
+
+		tag, err := h.TagService.GetTag(r.Context(), tagId)
+		if err != nil {
+			if errors.Is(err, ErrTagNotFound) {
+				res.JsonResponse(w, res.ErrorResponse{Error: "tag not found"}, http.StatusNotFound)
+			} else {
+				res.JsonResponse(w, res.ErrorResponse{Error: "failed to delete tag"}, http.StatusInternalServerError)
+			}
+			return
+		}
+		if tag.UserID != userId {
+			res.JsonResponse(w, res.ErrorResponse{Error: "unauthorized"}, http.StatusUnauthorized)
+			return
+		}
+
+		if err := h.TagService.DeleteTag(r.Context(), tagId); err != nil {
+			res.JsonResponse(w, res.ErrorResponse{Error: "failed to delete tag"}, http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
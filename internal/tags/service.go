@@ -0,0 +1,67 @@
+package tags
+
+import (
+	"ToDo/internal/models"
+	"ToDo/pkg/di"
+	"context"
+	"log/slog"
+	"strings"
+)
+
+const maxTagLength = 50
+
+type TagService struct {
+	tagRepository di.ITagRepository
+}
+
+func NewTagService(tagRepo di.ITagRepository) *TagService {
+	return &TagService{tagRepository: tagRepo}
+}
+
+// Normalize приводит имя тега к каноничному виду: без пробелов по краям и в нижнем регистре
+func Normalize(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// NormalizeNames нормализует список тегов, убирая пустые значения и дубликаты
+func NormalizeNames(names []string) ([]string, error) {
+	seen := make(map[string]bool, len(names))
+	result := make([]string, 0, len(names))
+	for _, name := range names {
+		name = Normalize(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		if len([]rune(name)) > maxTagLength {
+			return nil, ErrInvalidTagName
+		}
+		seen[name] = true
+		result = append(result, name)
+	}
+	return result, nil
+}
+
+func (s *TagService) GetAllTags(ctx context.Context, userID string) ([]models.Tag, error) {
+	slog.Info("Fetching all tags", "user_id", userID)
+	return s.tagRepository.GetAll(ctx, userID)
+}
+
+func (s *TagService) GetTag(ctx context.Context, tagID string) (*models.Tag, error) {
+	slog.Info("Fetching tag", "tag_id", tagID)
+	return s.tagRepository.Get(ctx, tagID)
+}
+
+func (s *TagService) RenameTag(ctx context.Context, tag *models.Tag, name string) (*models.Tag, error) {
+	name = Normalize(name)
+	if name == "" || len([]rune(name)) > maxTagLength {
+		return nil, ErrInvalidTagName
+	}
+	slog.Info("Renaming tag", "tag_id", tag.ID, "name", name)
+	tag.Name = name
+	return s.tagRepository.Update(ctx, tag)
+}
+
+func (s *TagService) DeleteTag(ctx context.Context, tagID string) error {
+	slog.Info("Deleting tag", "tag_id", tagID)
+	return s.tagRepository.Delete(ctx, tagID)
+}
@@ -0,0 +1,304 @@
+package tags
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"ToDo/configs"
+	"ToDo/internal/models"
+	"ToDo/pkg/db"
+	"ToDo/pkg/middleware"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockTagRepository — мок для ITagRepository
+type MockTagRepository struct {
+	mock.Mock
+}
+
+func (m *MockTagRepository) GetAll(ctx context.Context, userID string) ([]models.Tag, error) {
+	args := m.Called(ctx, userID)
+	result, _ := args.Get(0).([]models.Tag)
+	return result, args.Error(1)
+}
+
+func (m *MockTagRepository) Get(ctx context.Context, tagID string) (*models.Tag, error) {
+	args := m.Called(ctx, tagID)
+	result, _ := args.Get(0).(*models.Tag)
+	return result, args.Error(1)
+}
+
+func (m *MockTagRepository) Update(ctx context.Context, tag *models.Tag) (*models.Tag, error) {
+	args := m.Called(ctx, tag)
+	result, _ := args.Get(0).(*models.Tag)
+	return result, args.Error(1)
+}
+
+func (m *MockTagRepository) Delete(ctx context.Context, tagID string) error {
+	args := m.Called(ctx, tagID)
+	return args.Error(0)
+}
+
+// TestTagService_RenameTag — имя нормализуется, занятое имя отклоняется
+func TestTagService_RenameTag(t *testing.T) {
+	tests := []struct {
+		name      string
+		newName   string
+		mockSetup func(m *MockTagRepository)
+		wantName  string
+		err       error
+	}{
+		{
+			name:    "Name is normalized",
+			newName: "  Work ",
+			mockSetup: func(m *MockTagRepository) {
+				m.On("Update", mock.Anything, mock.MatchedBy(func(tag *models.Tag) bool {
+					return tag.Name == "work"
+				})).Return(&models.Tag{ID: "tag123", Name: "work", UserID: "user123"}, nil)
+			},
+			wantName: "work",
+		},
+		{
+			name:      "Empty name",
+			newName:   "   ",
+			mockSetup: func(m *MockTagRepository) {},
+			err:       ErrInvalidTagName,
+		},
+		{
+			name:      "Name too long",
+			newName:   strings.Repeat("a", maxTagLength+1),
+			mockSetup: func(m *MockTagRepository) {},
+			err:       ErrInvalidTagName,
+		},
+		{
+			name:    "Name taken by another tag",
+			newName: "home",
+			mockSetup: func(m *MockTagRepository) {
+				m.On("Update", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("update tag with ID tag123: %w", ErrTagAlreadyExists))
+			},
+			err: ErrTagAlreadyExists,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockTagRepository)
+			tt.mockSetup(mockRepo)
+			service := NewTagService(mockRepo)
+
+			tag, err := service.RenameTag(context.Background(), &models.Tag{ID: "tag123", Name: "old", UserID: "user123"}, tt.newName)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err, "expected error")
+			} else {
+				assert.NoError(t, err, "unexpected error")
+				assert.Equal(t, tt.wantName, tag.Name, "tag name mismatch")
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestNormalizeNames(t *testing.T) {
+	names, err := NormalizeNames([]string{" Work", "work", "", "Home "})
+	assert.NoError(t, err, "unexpected error")
+	assert.Equal(t, []string{"work", "home"}, names, "names mismatch")
+
+	_, err = NormalizeNames([]string{strings.Repeat("a", maxTagLength+1)})
+	assert.ErrorIs(t, err, ErrInvalidTagName, "too long name should be rejected")
+}
+
+// newTagRequest собирает запрос к /tags/{id} от имени userID
+func newTagRequest(method, tagID, body, userID string) *http.Request {
+	req := httptest.NewRequest(method, "/tags/"+tagID, strings.NewReader(body))
+	req.SetPathValue("id", tagID)
+	if userID != "" {
+		req = req.WithContext(context.WithValue(req.Context(), middleware.ContextUserIDKey, userID))
+	}
+	return req
+}
+
+// TestTagHandler_RenameTag — конфликт имен дает 409, чужой тег — 401
+func TestTagHandler_RenameTag(t *testing.T) {
+	tests := []struct {
+		name       string
+		userID     string
+		mockSetup  func(m *MockTagRepository)
+		wantStatus int
+	}{
+		{
+			name:   "Tag renamed",
+			userID: "user123",
+			mockSetup: func(m *MockTagRepository) {
+				m.On("Get", mock.Anything, "tag123").Return(&models.Tag{ID: "tag123", Name: "old", UserID: "user123"}, nil)
+				m.On("Update", mock.Anything, mock.Anything).Return(&models.Tag{ID: "tag123", Name: "home", UserID: "user123"}, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:   "Name conflict",
+			userID: "user123",
+			mockSetup: func(m *MockTagRepository) {
+				m.On("Get", mock.Anything, "tag123").Return(&models.Tag{ID: "tag123", Name: "old", UserID: "user123"}, nil)
+				m.On("Update", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("update tag with ID tag123: %w", ErrTagAlreadyExists))
+			},
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:   "Tag of another user",
+			userID: "user456",
+			mockSetup: func(m *MockTagRepository) {
+				m.On("Get", mock.Anything, "tag123").Return(&models.Tag{ID: "tag123", Name: "old", UserID: "user123"}, nil)
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:   "Tag not found",
+			userID: "user123",
+			mockSetup: func(m *MockTagRepository) {
+				m.On("Get", mock.Anything, "tag123").Return(nil, fmt.Errorf("get tag with ID tag123: %w", ErrTagNotFound))
+			},
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockTagRepository)
+			tt.mockSetup(mockRepo)
+			handler := &TagHandler{Config: &configs.Config{}, TagService: NewTagService(mockRepo)}
+
+			rr := httptest.NewRecorder()
+			handler.RenameTag()(rr, newTagRequest("PATCH", "tag123", `{"name":"home"}`, tt.userID))
+
+			assert.Equal(t, tt.wantStatus, rr.Code, "unexpected status code")
+			if tt.wantStatus == http.StatusUnauthorized {
+				mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+// TestTagHandler_DeleteTag — удалить можно только свой тег
+func TestTagHandler_DeleteTag(t *testing.T) {
+	tests := []struct {
+		name       string
+		userID     string
+		mockSetup  func(m *MockTagRepository)
+		wantStatus int
+	}{
+		{
+			name:   "Tag deleted",
+			userID: "user123",
+			mockSetup: func(m *MockTagRepository) {
+				m.On("Get", mock.Anything, "tag123").Return(&models.Tag{ID: "tag123", Name: "work", UserID: "user123"}, nil)
+				m.On("Delete", mock.Anything, "tag123").Return(nil)
+			},
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:   "Tag of another user",
+			userID: "user456",
+			mockSetup: func(m *MockTagRepository) {
+				m.On("Get", mock.Anything, "tag123").Return(&models.Tag{ID: "tag123", Name: "work", UserID: "user123"}, nil)
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "Unauthenticated request",
+			mockSetup:  func(m *MockTagRepository) {},
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockTagRepository)
+			tt.mockSetup(mockRepo)
+			handler := &TagHandler{Config: &configs.Config{}, TagService: NewTagService(mockRepo)}
+
+			rr := httptest.NewRecorder()
+			handler.DeleteTag()(rr, newTagRequest("DELETE", "tag123", "", tt.userID))
+
+			assert.Equal(t, tt.wantStatus, rr.Code, "unexpected status code")
+			if tt.wantStatus == http.StatusUnauthorized {
+				mockRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+// TestTagRepository_Postgres запускается против настоящей базы; изменения откатываются после теста:
+// TAGS_TEST_DSN="host=localhost user=postgres password=... dbname=todo_test port=5432 sslmode=disable"
+func TestTagRepository_Postgres(t *testing.T) {
+	dsn := os.Getenv("TAGS_TEST_DSN")
+	if dsn == "" {
+		t.Skip("TAGS_TEST_DSN is not set")
+	}
+	cfg := &configs.Config{}
+	cfg.Db.Dsn = dsn
+	gormDB, sqlDB, err := db.NewDb(cfg)
+	require.NoError(t, err, "connect database")
+	defer sqlDB.Close()
+	require.NoError(t, gormDB.AutoMigrate(&models.User{}, &models.Tag{}, &models.Note{}), "migrate")
+
+	tx := gormDB.Begin()
+	defer tx.Rollback()
+	repo := NewTagRepository(tx)
+	ctx := context.Background()
+
+	require.NoError(t, tx.Create(&models.User{ID: "tags-test-user", Name: "Tags", Email: "tags-test@example.com", Password: "hash"}).Error)
+	work := models.Tag{ID: "tags-test-work", Name: "work", UserID: "tags-test-user"}
+	home := models.Tag{ID: "tags-test-home", Name: "home", UserID: "tags-test-user"}
+	require.NoError(t, tx.Create(&[]models.Tag{work, home}).Error)
+	note := models.Note{ID: "tags-test-note", Title: "Tagged", UserID: "tags-test-user", Tags: []models.Tag{work, home}}
+	require.NoError(t, tx.Create(&note).Error)
+
+	t.Run("Rename to a taken name", func(t *testing.T) {
+		renamed := work
+		renamed.Name = "home"
+		_, err := repo.Update(ctx, &renamed)
+		assert.ErrorIs(t, err, ErrTagAlreadyExists, "duplicate name should be rejected")
+	})
+
+	t.Run("Rename bumps note version", func(t *testing.T) {
+		renamed := home
+		renamed.Name = "house"
+		_, err := repo.Update(ctx, &renamed)
+		require.NoError(t, err, "rename tag")
+
+		var stored models.Note
+		require.NoError(t, tx.First(&stored, "id = ?", note.ID).Error)
+		assert.Equal(t, note.Version+1, stored.Version, "renamed tag should change the note ETag")
+	})
+
+	t.Run("Delete detaches tag from notes", func(t *testing.T) {
+		require.NoError(t, repo.Delete(ctx, work.ID), "delete tag")
+
+		var stored models.Note
+		require.NoError(t, tx.Preload("Tags").First(&stored, "id = ?", note.ID).Error, "note should survive tag deletion")
+		assert.Equal(t, []string{"house"}, tagNames(stored.Tags), "only the remaining tag should stay attached")
+		assert.Equal(t, note.Version+2, stored.Version, "detached tag should change the note ETag")
+
+		_, err := repo.Get(ctx, work.ID)
+		assert.ErrorIs(t, err, ErrTagNotFound, "deleted tag should be gone")
+		assert.ErrorIs(t, repo.Delete(ctx, work.ID), ErrTagNotFound, "second delete should report not found")
+	})
+}
+
+func tagNames(tags []models.Tag) []string {
+	names := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		names = append(names, tag.Name)
+	}
+	return names
+}
@@ -0,0 +1,10 @@
+package templates
+
+import "errors"
+
+var (
+	ErrTemplateNotFound   = errors.New("template not found")
+	ErrCreateTemplate     = errors.New("failed to create template")
+	ErrInvalidTemplate    = errors.New("invalid template")
+	ErrMissingPlaceholder = errors.New("missing placeholder values")
+)
@@ -0,0 +1,46 @@
+package templates
+
+import (
+	"ToDo/configs"
+	"ToDo/pkg/di"
+	"ToDo/pkg/middleware"
+	"net/http"
+)
+
+type TemplateHandlerDeps struct {
+	Config          *configs.Config
+	TokenDenylist   di.ITokenDenylist
+	TemplateService di.ITemplateService
+	ProjectService  di.IProjectService
+}
+
+type TemplateHandler struct {
+	Config          *configs.Config
+	TemplateService di.ITemplateService
+	ProjectService  di.IProjectService
+}
+
+func NewTemplateHandler(router *http.ServeMux, deps *TemplateHandlerDeps) {
+	handler := &TemplateHandler{
+		Config:          deps.Config,
+		TemplateService: deps.TemplateService,
+		ProjectService:  deps.ProjectService,
+	}
+	middlewares := middleware.Chain(
+		middleware.CORS,
+		middleware.Logging,
+		middleware.RateLimiter(deps.Config.RateLimit.MaxRequests, deps.Config.RateLimit.Burst, deps.Config.RateLimit.TTL),
+		middleware.IsAuthenticated(deps.Config, deps.TokenDenylist),
+	)
+
+	router.Handle("POST /templates", middlewares(handler.CreateTemplate()))
+	router.Handle("GET /templates", middlewares(handler.GetAllTemplates()))
+	router.Handle("GET /templates/{id}", middlewares(handler.GetTemplate()))
+	router.Handle("PATCH /templates/{id}", middlewares(handler.UpdateTemplate()))
+	router.Handle("DELETE /templates/{id}", middlewares(handler.DeleteTemplate()))
+	// Шаблон POST /notes/from-template/{id} ServeMux считает конфликтующим с POST /notes/{id}/restore и соседними
+	// маршрутами заметок, поэтому маршрут регистрируется с переменным первым сегментом, а он проверяется в обработчике
+	// Создание заметки из шаблона подчиняется тем же правилам неподтвержденного email, что и маршруты заметок
+	noteMiddlewares := middleware.Chain(middlewares, middleware.RequireVerifiedEmail(deps.Config))
+	router.Handle("POST /notes/{action}/{id}", noteMiddlewares(handler.CreateNoteFromTemplate()))
+}
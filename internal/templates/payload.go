@@ -0,0 +1,43 @@
+package templates
+
+import (
+	"ToDo/internal/models"
+	"time"
+)
+
+type CreateTemplateRequest struct {
+	Name     string   `json:"name" validate:"required,max=100"`
+	Title    string   `json:"title" validate:"required,max=100"`
+	Content  string   `json:"content" validate:"max=10000"`
+	Status   string   `json:"status" validate:"max=20"`
+	Priority string   `json:"priority" validate:"omitempty,oneof=low normal high urgent"`
+	Tags     []string `json:"tags"`
+	Items    []string `json:"items" validate:"dive,max=500"` // Тексты пунктов чек-листа
+}
+
+type UpdateTemplateRequest struct {
+	Name     string    `json:"name" validate:"omitempty,max=100"`
+	Title    string    `json:"title" validate:"omitempty,max=100"`
+	Content  *string   `json:"content" validate:"omitempty,max=10000"`
+	Status   string    `json:"status" validate:"omitempty,max=20"`
+	Priority string    `json:"priority" validate:"omitempty,oneof=low normal high urgent"`
+	Tags     *[]string `json:"tags"`
+	Items    *[]string `json:"items" validate:"omitempty,dive,max=500"`
+}
+
+// TemplateResponse — шаблон и имена подстановок, которые в нем встречаются
+type TemplateResponse struct {
+	models.Template
+	Placeholders []string `json:"placeholders"`
+}
+
+type GetAllTemplatesResponse struct {
+	Templates []TemplateResponse `json:"templates"`
+}
+
+type CreateNoteFromTemplateRequest struct {
+	Values    map[string]string `json:"values"`     // Значения подстановок; переопределяют встроенные date, time, datetime и weekday
+	Timezone  string            `json:"timezone"`   // IANA-зона для встроенных подстановок, по умолчанию UTC
+	ProjectID *string           `json:"project_id"` // Проект новой заметки
+	DueAt     *time.Time        `json:"due_at"`     // RFC 3339 со смещением часового пояса
+}
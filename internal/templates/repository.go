@@ -0,0 +1,74 @@
+package templates
+
+import (
+	"ToDo/internal/models"
+	"ToDo/pkg/idgen"
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+)
+
+type TemplateRepository struct {
+	db *gorm.DB
+}
+
+func NewTemplateRepository(dataBase *gorm.DB) *TemplateRepository {
+	return &TemplateRepository{
+		db: dataBase,
+	}
+}
+
+func (r *TemplateRepository) Create(ctx context.Context, template *models.Template) (*models.Template, error) {
+	template.ID = idgen.GenerateNanoID()
+	if template.ID == "" {
+		return nil, fmt.Errorf("generate id: %w", ErrCreateTemplate)
+	}
+
+	if err := r.db.WithContext(ctx).Create(template).Error; err != nil {
+		return nil, fmt.Errorf("create template with ID %s: %w", template.ID, err)
+	}
+	return template, nil
+}
+
+func (r *TemplateRepository) GetAll(ctx context.Context, userId string) ([]models.Template, error) {
+	var templates []models.Template
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userId).Order("name asc, created_at asc").Find(&templates).Error; err != nil {
+		return nil, fmt.Errorf("get all templates for user %s: %w", userId, err)
+	}
+	return templates, nil
+}
+
+func (r *TemplateRepository) Get(ctx context.Context, templateId string) (*models.Template, error) {
+	var template models.Template
+	result := r.db.WithContext(ctx).Where("id = ?", templateId).First(&template)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("get template by id %s: %w", templateId, ErrTemplateNotFound)
+		}
+		return nil, fmt.Errorf("get template by id %s: %w", templateId, result.Error)
+	}
+	return &template, nil
+}
+
+func (r *TemplateRepository) Update(ctx context.Context, template *models.Template) (*models.Template, error) {
+	result := r.db.WithContext(ctx).Save(template)
+	if result.Error != nil {
+		return nil, fmt.Errorf("update template with ID %s: %w", template.ID, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, fmt.Errorf("update template with ID %s: %w", template.ID, ErrTemplateNotFound)
+	}
+	return template, nil
+}
+
+func (r *TemplateRepository) Delete(ctx context.Context, templateId string) error {
+	result := r.db.WithContext(ctx).Where("id = ?", templateId).Delete(&models.Template{})
+	if result.Error != nil {
+		return fmt.Errorf("delete template with ID %s: %w", templateId, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("delete template with ID %s: %w", templateId, ErrTemplateNotFound)
+	}
+	return nil
+}
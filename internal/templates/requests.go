@@ -0,0 +1,260 @@
+package templates
+
+import (
+	"ToDo/internal/models"
+	"ToDo/internal/notes"
+	"ToDo/internal/projects"
+	"ToDo/internal/tags"
+	"ToDo/pkg/middleware"
+	"ToDo/pkg/req"
+	"ToDo/pkg/res"
+	"errors"
+	"net/http"
+	"time"
+)
+
+func getUserId(r *http.Request) string {
+	userId, _ := r.Context().Value(middleware.ContextUserIDKey).(string)
+	return userId
+}
+
+func newTemplateResponse(template *models.Template) TemplateResponse {
+	return TemplateResponse{Template: *template, Placeholders: Placeholders(template)}
+}
+
+// loadOwnedTemplate загружает шаблон из пути запроса и проверяет, что он принадлежит пользователю.
+// При ошибке ответ уже записан и возвращается nil
+func (h *TemplateHandler) loadOwnedTemplate(w http.ResponseWriter, r *http.Request) *models.Template {
+	templateId := r.PathValue("id")
+	if templateId == "" {
+		res.JsonResponse(w, res.ErrorResponse{Error: "template id is required"}, http.StatusBadRequest)
+		return nil
+	}
+	userId := getUserId(r)
+	if userId == "" {
+		res.JsonResponse(w, res.ErrorResponse{Error: "unauthorized"}, http.StatusUnauthorized)
+		return nil
+	}
+
+	template, err := h.TemplateService.GetTemplate(r.Context(), templateId)
+	if err != nil {
+		if errors.Is(err, ErrTemplateNotFound) {
+			res.JsonResponse(w, res.ErrorResponse{Error: "template not found"}, http.StatusNotFound)
+		} else {
+			res.JsonResponse(w, res.ErrorResponse{Error: "failed to get template by id"}, http.StatusInternalServerError)
+		}
+		return nil
+	}
+	if template.UserID != userId {
+		res.JsonResponse(w, res.ErrorResponse{Error: "unauthorized"}, http.StatusUnauthorized)
+		return nil
+	}
+	return template
+}
+
+// writeTemplateError отвечает на ошибку сохранения шаблона
+func writeTemplateError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, ErrInvalidTemplate):
+		res.JsonResponse(w, res.ErrorResponse{Error: err.Error()}, http.StatusBadRequest)
+	case errors.Is(err, tags.ErrInvalidTagName):
+		res.JsonResponse(w, res.ErrorResponse{Error: "invalid tag name"}, http.StatusBadRequest)
+	case errors.Is(err, ErrTemplateNotFound):
+		res.JsonResponse(w, res.ErrorResponse{Error: "template not found"}, http.StatusNotFound)
+	default:
+		res.JsonResponse(w, res.ErrorResponse{Error: fallback}, http.StatusInternalServerError)
+	}
+}
+
+func (h *TemplateHandler) CreateTemplate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := req.HandleBody[CreateTemplateRequest](&w, r)
+		if err != nil {
+			return
+		}
+		userId := getUserId(r)
+		if userId == "" {
+			res.JsonResponse(w, res.ErrorResponse{Error: "unauthorized"}, http.StatusUnauthorized)
+			return
+		}
+
+		template, err := h.TemplateService.CreateTemplate(r.Context(), &models.Template{
+			UserID:   userId,
+			Name:     body.Name,
+			Title:    body.Title,
+			Content:  body.Content,
+			Status:   body.Status,
+			Priority: body.Priority,
+			Tags:     body.Tags,
+			Items:    body.Items,
+		})
+		if err != nil {
+			writeTemplateError(w, err, "failed to create template")
+			return
+		}
+		res.JsonResponse(w, newTemplateResponse(template), http.StatusCreated)
+	}
+}
+
+func (h *TemplateHandler) GetAllTemplates() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userId := getUserId(r)
+		if userId == "" {
+			res.JsonResponse(w, res.ErrorResponse{Error: "unauthorized"}, http.StatusUnauthorized)
+			return
+		}
+
+		templates, err := h.TemplateService.GetAllTemplates(r.Context(), userId)
+		if err != nil {
+			res.JsonResponse(w, res.ErrorResponse{Error: "failed to get templates"}, http.StatusInternalServerError)
+			return
+		}
+		response := GetAllTemplatesResponse{Templates: make([]TemplateResponse, 0, len(templates))}
+		for i := range templates {
+			response.Templates = append(response.Templates, newTemplateResponse(&templates[i]))
+		}
+		res.JsonResponse(w, response, http.StatusOK)
+	}
+}
+
+func (h *TemplateHandler) GetTemplate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		template := h.loadOwnedTemplate(w, r)
+		if template == nil {
+			return
+		}
+		res.JsonResponse(w, newTemplateResponse(template), http.StatusOK)
+	}
+}
+
+func (h *TemplateHandler) UpdateTemplate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := req.HandleBody[UpdateTemplateRequest](&w, r)
+		if err != nil {
+			return
+		}
+		template := h.loadOwnedTemplate(w, r)
+		if template == nil {
+			return
+		}
+
+		// Обновляем только переданные поля
+		if body.Name != "" {
+			template.Name = body.Name
+		}
+		if body.Title != "" {
+			template.Title = body.Title
+		}
+		if body.Content != nil {
+			template.Content = *body.Content
+		}
+		if body.Status != "" {
+			template.Status = body.Status
+		}
+		if body.Priority != "" {
+			template.Priority = body.Priority
+		}
+		if body.Tags != nil {
+			template.Tags = *body.Tags
+		}
+		if body.Items != nil {
+			template.Items = *body.Items
+		}
+
+		updatedTemplate, err := h.TemplateService.UpdateTemplate(r.Context(), template)
+		if err != nil {
+			writeTemplateError(w, err, "failed to update template")
+			return
+		}
+		res.JsonResponse(w, newTemplateResponse(updatedTemplate), http.StatusOK)
+	}
+}
+
+func (h *TemplateHandler) DeleteTemplate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		template := h.loadOwnedTemplate(w, r)
+		if template == nil {
+			return
+		}
+
+		if err := h.TemplateService.DeleteTemplate(r.Context(), template.ID); err != nil {
+			writeTemplateError(w, err, "failed to delete template")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// CreateNoteFromTemplate создает заметку из шаблона (POST /notes/from-template/{id}), подставляя значения из тела запроса
+func (h *TemplateHandler) CreateNoteFromTemplate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("action") != "from-template" {
+			http.NotFound(w, r)
+			return
+		}
+		body, err := req.HandleBody[CreateNoteFromTemplateRequest](&w, r)
+		if err != nil {
+			return
+		}
+		template := h.loadOwnedTemplate(w, r)
+		if template == nil {
+			return
+		}
+		location := time.UTC
+		if body.Timezone != "" {
+			loaded, err := time.LoadLocation(body.Timezone)
+			if err != nil {
+				res.JsonResponse(w, res.ErrorResponse{Error: "invalid timezone"}, http.StatusBadRequest)
+				return
+			}
+			location = loaded
+		}
+		if body.ProjectID != nil && !h.checkProject(w, r, template.UserID, *body.ProjectID) {
+			return
+		}
+
+		note, err := h.TemplateService.InstantiateTemplate(r.Context(), template, &models.Note{
+			UserID:    template.UserID,
+			ProjectID: body.ProjectID,
+			DueAt:     body.DueAt,
+		}, body.Values, time.Now().In(location))
+		if err != nil {
+			switch {
+			case errors.Is(err, ErrMissingPlaceholder):
+				res.JsonResponse(w, res.ErrorResponse{Error: err.Error()}, http.StatusBadRequest)
+			case errors.Is(err, notes.ErrInvalidNoteStatus):
+				res.JsonResponse(w, res.ErrorResponse{Error: "invalid note status"}, http.StatusBadRequest)
+			case errors.Is(err, notes.ErrInvalidPriority):
+				res.JsonResponse(w, res.ErrorResponse{Error: "invalid note priority"}, http.StatusBadRequest)
+			case errors.Is(err, tags.ErrInvalidTagName):
+				res.JsonResponse(w, res.ErrorResponse{Error: "invalid tag name"}, http.StatusBadRequest)
+			default:
+				res.JsonResponse(w, res.ErrorResponse{Error: "failed to create note"}, http.StatusInternalServerError)
+			}
+			return
+		}
+		res.JsonResponse(w, note, http.StatusCreated)
+	}
+}
+
+// checkProject проверяет, что проект принадлежит пользователю и не в архиве. При ошибке ответ уже записан
+func (h *TemplateHandler) checkProject(w http.ResponseWriter, r *http.Request, userId, projectId string) bool {
+	project, err := h.ProjectService.GetProject(r.Context(), projectId)
+	if err != nil {
+		if errors.Is(err, projects.ErrProjectNotFound) {
+			res.JsonResponse(w, res.ErrorResponse{Error: "project not found"}, http.StatusNotFound)
+		} else {
+			res.JsonResponse(w, res.ErrorResponse{Error: "failed to get project by id"}, http.StatusInternalServerError)
+		}
+		return false
+	}
+	if project.UserID != userId {
+		res.JsonResponse(w, res.ErrorResponse{Error: "project not found"}, http.StatusNotFound)
+		return false
+	}
+	if project.Archived {
+		res.JsonResponse(w, res.ErrorResponse{Error: projects.ErrProjectArchived.Error()}, http.StatusConflict)
+		return false
+	}
+	return true
+}
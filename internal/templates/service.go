@@ -0,0 +1,162 @@
+package templates
+
+import (
+	"ToDo/internal/models"
+	"ToDo/internal/tags"
+	"ToDo/pkg/di"
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const maxItems = 100
+
+// placeholderPattern — подстановка {{name}}; пробелы внутри скобок допускаются
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+type TemplateService struct {
+	templateRepository di.ITemplateRepository
+	noteService        di.INoteService
+}
+
+func NewTemplateService(templateRepo di.ITemplateRepository, noteService di.INoteService) *TemplateService {
+	return &TemplateService{
+		templateRepository: templateRepo,
+		noteService:        noteService,
+	}
+}
+
+func (s *TemplateService) CreateTemplate(ctx context.Context, template *models.Template) (*models.Template, error) {
+	if err := Validate(template); err != nil {
+		return nil, err
+	}
+	slog.Info("Creating template", "name", template.Name, "user_id", template.UserID)
+	return s.templateRepository.Create(ctx, template)
+}
+
+func (s *TemplateService) GetAllTemplates(ctx context.Context, userID string) ([]models.Template, error) {
+	slog.Info("Fetching all templates", "user_id", userID)
+	return s.templateRepository.GetAll(ctx, userID)
+}
+
+func (s *TemplateService) GetTemplate(ctx context.Context, templateID string) (*models.Template, error) {
+	slog.Info("Fetching template", "template_id", templateID)
+	return s.templateRepository.Get(ctx, templateID)
+}
+
+func (s *TemplateService) UpdateTemplate(ctx context.Context, template *models.Template) (*models.Template, error) {
+	if err := Validate(template); err != nil {
+		return nil, err
+	}
+	slog.Info("Updating template", "template_id", template.ID)
+	return s.templateRepository.Update(ctx, template)
+}
+
+func (s *TemplateService) DeleteTemplate(ctx context.Context, templateID string) error {
+	slog.Info("Deleting template", "template_id", templateID)
+	return s.templateRepository.Delete(ctx, templateID)
+}
+
+// InstantiateTemplate создает заметку из шаблона через NoteService.CreateNote. Встроенные подстановки
+// (date, time, datetime, weekday) вычисляются от now; значения из values их переопределяют.
+// note задает владельца и поля, которых нет в шаблоне (проект, срок)
+func (s *TemplateService) InstantiateTemplate(ctx context.Context, template *models.Template, note *models.Note, values map[string]string, now time.Time) (*models.Note, error) {
+	values = withBuiltins(values, now)
+	if missing := missingPlaceholders(template, values); len(missing) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrMissingPlaceholder, strings.Join(missing, ", "))
+	}
+
+	note.Title = render(template.Title, values)
+	note.Content = render(template.Content, values)
+	note.Status = template.Status
+	note.Priority = template.Priority
+	note.Tags = make([]models.Tag, 0, len(template.Tags))
+	for _, name := range template.Tags {
+		note.Tags = append(note.Tags, models.Tag{Name: name})
+	}
+	note.Items = make([]models.ChecklistItem, 0, len(template.Items))
+	for _, text := range template.Items {
+		note.Items = append(note.Items, models.ChecklistItem{Text: render(text, values)})
+	}
+
+	slog.Info("Creating note from template", "template_id", template.ID, "user_id", note.UserID)
+	return s.noteService.CreateNote(ctx, note)
+}
+
+// Validate нормализует теги и пункты шаблона и проверяет ограничения
+func Validate(template *models.Template) error {
+	template.Name = strings.TrimSpace(template.Name)
+	if template.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidTemplate)
+	}
+	if strings.TrimSpace(template.Title) == "" {
+		return fmt.Errorf("%w: title is required", ErrInvalidTemplate)
+	}
+	names, err := tags.NormalizeNames(template.Tags)
+	if err != nil {
+		return err
+	}
+	template.Tags = names
+	if len(template.Items) > maxItems {
+		return fmt.Errorf("%w: at most %d checklist items are allowed", ErrInvalidTemplate, maxItems)
+	}
+	items := make([]string, 0, len(template.Items))
+	for _, text := range template.Items {
+		if text = strings.TrimSpace(text); text != "" {
+			items = append(items, text)
+		}
+	}
+	template.Items = items
+	return nil
+}
+
+// Placeholders возвращает отсортированные имена подстановок, встречающихся в шаблоне
+func Placeholders(template *models.Template) []string {
+	seen := make(map[string]bool)
+	texts := append([]string{template.Title, template.Content}, template.Items...)
+	for _, text := range texts {
+		for _, match := range placeholderPattern.FindAllStringSubmatch(text, -1) {
+			seen[match[1]] = true
+		}
+	}
+	result := make([]string, 0, len(seen))
+	for name := range seen {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func missingPlaceholders(template *models.Template, values map[string]string) []string {
+	var missing []string
+	for _, name := range Placeholders(template) {
+		if _, ok := values[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+// withBuiltins дополняет значения подстановок встроенными, не затирая переданные
+func withBuiltins(values map[string]string, now time.Time) map[string]string {
+	result := map[string]string{
+		"date":     now.Format("2006-01-02"),
+		"time":     now.Format("15:04"),
+		"datetime": now.Format("2006-01-02 15:04"),
+		"weekday":  now.Weekday().String(),
+	}
+	for name, value := range values {
+		result[name] = value
+	}
+	return result
+}
+
+func render(text string, values map[string]string) string {
+	return placeholderPattern.ReplaceAllStringFunc(text, func(match string) string {
+		return values[placeholderPattern.FindStringSubmatch(match)[1]]
+	})
+}
@@ -0,0 +1,53 @@
+package templates
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"ToDo/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlaceholders(t *testing.T) {
+	template := &models.Template{
+		Title:   "Release {{ version }} — {{date}}",
+		Content: "Owner: {{owner}}, {{version}}",
+		Items:   []string{"Tag {{version}}", "Announce on {{weekday}}", "{{not a placeholder}}"},
+	}
+	assert.Equal(t, []string{"date", "owner", "version", "weekday"}, Placeholders(template), "placeholders mismatch")
+}
+
+func TestRender(t *testing.T) {
+	now := time.Date(2025, time.March, 3, 9, 30, 0, 0, time.UTC)
+	values := withBuiltins(map[string]string{"version": "1.4", "time": "noon"}, now)
+
+	assert.Equal(t, "Release 1.4 on 2025-03-03 (Monday) at noon", render("Release {{ version }} on {{date}} ({{weekday}}) at {{time}}", values))
+	assert.Equal(t, "2025-03-03 09:30", values["datetime"], "builtin datetime mismatch")
+}
+
+func TestValidate(t *testing.T) {
+	template := &models.Template{
+		Name:  "  Release  ",
+		Title: "Release {{version}}",
+		Tags:  []string{"Work", "work", " "},
+		Items: []string{" Build ", "", "Deploy"},
+	}
+	assert.NoError(t, Validate(template), "unexpected error")
+	assert.Equal(t, "Release", template.Name, "name should be trimmed")
+	assert.Equal(t, []string{"work"}, template.Tags, "tags should be normalized")
+	assert.Equal(t, []string{"Build", "Deploy"}, template.Items, "empty items should be dropped")
+
+	assert.ErrorIs(t, Validate(&models.Template{Name: "Empty"}), ErrInvalidTemplate, "title is required")
+}
+
+// TestTemplateService_InstantiateTemplate — без значений для всех подстановок заметка не создается
+func TestTemplateService_InstantiateTemplate(t *testing.T) {
+	service := NewTemplateService(nil, nil)
+	template := &models.Template{ID: "tpl123", Title: "Release {{version}} ({{date}})"}
+
+	_, err := service.InstantiateTemplate(context.Background(), template, &models.Note{UserID: "user123"}, nil, time.Now())
+	assert.ErrorIs(t, err, ErrMissingPlaceholder, "expected missing placeholder error")
+	assert.ErrorContains(t, err, "version", "error should name the missing placeholder")
+}
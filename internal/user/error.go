@@ -5,4 +5,5 @@ import "errors"
 var (
 	ErrUserNotFound      = errors.New("user not found")
 	ErrUserAlreadyExists = errors.New("user already exists")
+	ErrWrongPassword     = errors.New("current password is incorrect")
 )
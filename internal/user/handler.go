@@ -0,0 +1,37 @@
+package user
+
+import (
+	"ToDo/configs"
+	"ToDo/pkg/di"
+	"ToDo/pkg/middleware"
+	"net/http"
+)
+
+type UserHandlerDeps struct {
+	Config        *configs.Config
+	TokenDenylist di.ITokenDenylist
+	UserService   di.IUserService
+}
+
+type UserHandler struct {
+	Config      *configs.Config
+	UserService di.IUserService
+}
+
+func NewUserHandler(router *http.ServeMux, deps *UserHandlerDeps) {
+	handler := &UserHandler{
+		Config:      deps.Config,
+		UserService: deps.UserService,
+	}
+	// Профиль доступен и с неподтвержденным email: в нем можно исправить адрес
+	middlewares := middleware.Chain(
+		middleware.CORS,
+		middleware.Logging,
+		middleware.RateLimiter(deps.Config.RateLimit.MaxRequests, deps.Config.RateLimit.Burst, deps.Config.RateLimit.TTL),
+		middleware.IsAuthenticated(deps.Config, deps.TokenDenylist),
+	)
+
+	router.Handle("GET /users/me", middlewares(handler.GetMe()))
+	router.Handle("PATCH /users/me", middlewares(handler.UpdateMe()))
+	router.Handle("DELETE /users/me", middlewares(handler.DeleteMe()))
+}
@@ -0,0 +1,25 @@
+package user
+
+import "time"
+
+// UserResponse — профиль пользователя без хеша пароля
+type UserResponse struct {
+	ID              string     `json:"id"`
+	Name            string     `json:"name"`
+	Email           string     `json:"email"`
+	EmailVerified   bool       `json:"email_verified"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// UpdateUserRequest — изменения профиля; для смены email или пароля нужен current_password
+type UpdateUserRequest struct {
+	Name            *string `json:"name" validate:"omitempty,min=1,max=200"`
+	Email           *string `json:"email" validate:"omitempty,email,min=10"`
+	Password        *string `json:"password" validate:"omitempty,min=8"`
+	CurrentPassword string  `json:"current_password"`
+}
+
+type DeleteUserRequest struct {
+	Password string `json:"password" validate:"required"`
+}
@@ -2,11 +2,13 @@ package user
 
 import (
 	"ToDo/internal/models"
+	"ToDo/pkg/di"
 	"ToDo/pkg/idgen"
 	"context"
 	"errors"
 	"fmt"
 	"gorm.io/gorm"
+	"time"
 )
 
 type UserRepository struct {
@@ -56,3 +58,70 @@ func (r *UserRepository) FindByEmail(ctx context.Context, email string) (*models
 	}
 	return &user, nil
 }
+
+func (r *UserRepository) UpdatePassword(ctx context.Context, userId, passwordHash string) error {
+	result := r.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", userId).Update("password", passwordHash)
+	if result.Error != nil {
+		return fmt.Errorf("update password: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("update password: %w", ErrUserNotFound)
+	}
+	return nil
+}
+
+// MarkEmailVerified отмечает адрес подтвержденным; повторное подтверждение не меняет исходную дату
+func (r *UserRepository) MarkEmailVerified(ctx context.Context, userId string, at time.Time) error {
+	result := r.db.WithContext(ctx).Model(&models.User{}).
+		Where("id = ? AND email_verified_at IS NULL", userId).
+		Update("email_verified_at", at)
+	if result.Error != nil {
+		return fmt.Errorf("mark email verified: %w", result.Error)
+	}
+	return nil
+}
+
+// Update сохраняет имя, email и отметку подтверждения email
+func (r *UserRepository) Update(ctx context.Context, user *models.User) (*models.User, error) {
+	var duplicates int64
+	countQuery := r.db.WithContext(ctx).Model(&models.User{}).
+		Where("email = ? AND id <> ?", user.Email, user.ID).
+		Count(&duplicates)
+	if countQuery.Error != nil {
+		return nil, fmt.Errorf("check user email: %w", countQuery.Error)
+	}
+	if duplicates > 0 {
+		return nil, fmt.Errorf("update user: %w", ErrUserAlreadyExists)
+	}
+
+	result := r.db.WithContext(ctx).Model(user).Select("name", "email", "email_verified_at").Updates(user)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
+			return nil, fmt.Errorf("update user: %w", ErrUserAlreadyExists)
+		}
+		return nil, fmt.Errorf("update user: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, fmt.Errorf("update user: %w", ErrUserNotFound)
+	}
+	return user, nil
+}
+
+// Delete удаляет пользователя; заметки, проекты, теги и прочие данные удаляются каскадом (OnDelete:CASCADE)
+func (r *UserRepository) Delete(ctx context.Context, userId string) error {
+	result := r.db.WithContext(ctx).Where("id = ?", userId).Delete(&models.User{})
+	if result.Error != nil {
+		return fmt.Errorf("delete user: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("delete user: %w", ErrUserNotFound)
+	}
+	return nil
+}
+
+// WithTransaction выполняет fn с репозиторием, привязанным к транзакции
+func (r *UserRepository) WithTransaction(ctx context.Context, fn func(repo di.IUserRepository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&UserRepository{db: tx})
+	})
+}
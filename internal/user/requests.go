@@ -0,0 +1,107 @@
+package user
+
+import (
+	"ToDo/internal/models"
+	"ToDo/pkg/middleware"
+	"ToDo/pkg/req"
+	"ToDo/pkg/res"
+	"errors"
+	"net/http"
+	"time"
+)
+
+func getUserId(r *http.Request) string {
+	userId, _ := r.Context().Value(middleware.ContextUserIDKey).(string)
+	return userId
+}
+
+func newUserResponse(user *models.User) UserResponse {
+	return UserResponse{
+		ID:              user.ID,
+		Name:            user.Name,
+		Email:           user.Email,
+		EmailVerified:   user.EmailVerifiedAt != nil,
+		EmailVerifiedAt: user.EmailVerifiedAt,
+		CreatedAt:       user.CreatedAt,
+	}
+}
+
+// writeUserError отвечает на ошибку операции с профилем. Удаленный пользователь с еще живым токеном получает 401
+func writeUserError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, ErrUserNotFound):
+		res.JsonResponse(w, res.ErrorResponse{Error: "unauthorized"}, http.StatusUnauthorized)
+	case errors.Is(err, ErrWrongPassword):
+		res.JsonResponse(w, res.ErrorResponse{Error: err.Error()}, http.StatusForbidden)
+	case errors.Is(err, ErrUserAlreadyExists):
+		res.JsonResponse(w, res.ErrorResponse{Error: err.Error()}, http.StatusConflict)
+	default:
+		res.JsonResponse(w, res.ErrorResponse{Error: fallback}, http.StatusInternalServerError)
+	}
+}
+
+func (h *UserHandler) GetMe() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userId := getUserId(r)
+		if userId == "" {
+			res.JsonResponse(w, res.ErrorResponse{Error: "unauthorized"}, http.StatusUnauthorized)
+			return
+		}
+
+		existingUser, err := h.UserService.GetProfile(r.Context(), userId)
+		if err != nil {
+			writeUserError(w, err, "failed to get user")
+			return
+		}
+		res.JsonResponse(w, newUserResponse(existingUser), http.StatusOK)
+	}
+}
+
+// UpdateMe меняет профиль. После смены email или пароля все сессии завершаются и нужно войти заново;
+// после смены email на новый адрес уходит ссылка подтверждения
+func (h *UserHandler) UpdateMe() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userId := getUserId(r)
+		if userId == "" {
+			res.JsonResponse(w, res.ErrorResponse{Error: "unauthorized"}, http.StatusUnauthorized)
+			return
+		}
+		body, err := req.HandleBody[UpdateUserRequest](&w, r)
+		if err != nil {
+			return
+		}
+
+		updatedUser, err := h.UserService.UpdateProfile(r.Context(), userId, models.ProfileUpdate{
+			Name:            body.Name,
+			Email:           body.Email,
+			Password:        body.Password,
+			CurrentPassword: body.CurrentPassword,
+		}, time.Now())
+		if err != nil {
+			writeUserError(w, err, "failed to update user")
+			return
+		}
+		res.JsonResponse(w, newUserResponse(updatedUser), http.StatusOK)
+	}
+}
+
+// DeleteMe удаляет учетную запись вместе со всеми данными пользователя; требует пароль
+func (h *UserHandler) DeleteMe() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userId := getUserId(r)
+		if userId == "" {
+			res.JsonResponse(w, res.ErrorResponse{Error: "unauthorized"}, http.StatusUnauthorized)
+			return
+		}
+		body, err := req.HandleBody[DeleteUserRequest](&w, r)
+		if err != nil {
+			return
+		}
+
+		if err := h.UserService.DeleteAccount(r.Context(), userId, body.Password); err != nil {
+			writeUserError(w, err, "failed to delete user")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,112 @@
+package user
+
+import (
+	"ToDo/internal/models"
+	"ToDo/pkg/di"
+	"context"
+	"fmt"
+	"golang.org/x/crypto/bcrypt"
+	"log/slog"
+	"time"
+)
+
+type UserService struct {
+	userRepository di.IUserRepository
+	authService    di.IAuthService
+}
+
+func NewUserService(userRepo di.IUserRepository, authService di.IAuthService) *UserService {
+	return &UserService{
+		userRepository: userRepo,
+		authService:    authService,
+	}
+}
+
+func (s *UserService) GetProfile(ctx context.Context, userID string) (*models.User, error) {
+	return s.userRepository.FindById(ctx, userID)
+}
+
+// UpdateProfile меняет имя, email и пароль одной транзакцией. Новый email снова требует подтверждения: ссылка
+// уходит на новый адрес. Смена email или пароля завершает все сессии пользователя, включая текущую, — иначе
+// в старых токенах остались бы прежний email и email_verified=true
+func (s *UserService) UpdateProfile(ctx context.Context, userID string, update models.ProfileUpdate, now time.Time) (*models.User, error) {
+	existingUser, err := s.userRepository.FindById(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	emailChanged := update.Email != nil && *update.Email != existingUser.Email
+	if emailChanged || update.Password != nil {
+		if err := checkPassword(existingUser, update.CurrentPassword); err != nil {
+			return nil, err
+		}
+	}
+
+	if emailChanged {
+		existingUser.Email = *update.Email
+		existingUser.EmailVerifiedAt = nil
+	}
+	if update.Name != nil {
+		existingUser.Name = *update.Name
+	}
+	var passwordHash string
+	if update.Password != nil {
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(*update.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, fmt.Errorf("hash password: %w", err)
+		}
+		passwordHash = string(hashedPassword)
+	}
+
+	slog.Info("Updating profile", "user_id", userID, "email_changed", emailChanged, "password_changed", update.Password != nil)
+	var updatedUser *models.User
+	err = s.userRepository.WithTransaction(ctx, func(repo di.IUserRepository) error {
+		var err error
+		if updatedUser, err = repo.Update(ctx, existingUser); err != nil {
+			return err
+		}
+		if passwordHash != "" {
+			return repo.UpdatePassword(ctx, userID, passwordHash)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if emailChanged || update.Password != nil {
+		if err := s.authService.LogoutAll(ctx, userID, now); err != nil {
+			return nil, err
+		}
+	}
+	if emailChanged {
+		// Сбой почты не откатывает смену адреса: ссылку можно запросить через POST /auth/verify/resend
+		if err := s.authService.SendVerificationEmail(ctx, userID, now); err != nil {
+			slog.Error("Failed to send verification email", "user_id", userID, "error", err)
+		}
+	}
+	return updatedUser, nil
+}
+
+// DeleteAccount удаляет пользователя после проверки пароля. Его заметки, проекты, теги, шаблоны, комментарии
+// и токены удаляются базой каскадом; вложения без заметок стирает фоновая очистка
+func (s *UserService) DeleteAccount(ctx context.Context, userID, password string) error {
+	existingUser, err := s.userRepository.FindById(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := checkPassword(existingUser, password); err != nil {
+		return err
+	}
+	slog.Info("Deleting account", "user_id", userID)
+	return s.userRepository.Delete(ctx, userID)
+}
+
+func checkPassword(existingUser *models.User, password string) error {
+	if password == "" {
+		return ErrWrongPassword
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(existingUser.Password), []byte(password)); err != nil {
+		return ErrWrongPassword
+	}
+	return nil
+}
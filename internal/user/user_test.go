@@ -0,0 +1,293 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"ToDo/configs"
+	"ToDo/internal/models"
+	"ToDo/pkg/di"
+	"ToDo/pkg/middleware"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// MockUserRepository — мок для IUserRepository
+type MockUserRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserRepository) Create(ctx context.Context, user *models.User) (*models.User, error) {
+	args := m.Called(ctx, user)
+	result, _ := args.Get(0).(*models.User)
+	return result, args.Error(1)
+}
+
+func (m *MockUserRepository) FindById(ctx context.Context, userId string) (*models.User, error) {
+	args := m.Called(ctx, userId)
+	result, _ := args.Get(0).(*models.User)
+	return result, args.Error(1)
+}
+
+func (m *MockUserRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	args := m.Called(ctx, email)
+	result, _ := args.Get(0).(*models.User)
+	return result, args.Error(1)
+}
+
+func (m *MockUserRepository) UpdatePassword(ctx context.Context, userID, passwordHash string) error {
+	args := m.Called(ctx, userID, passwordHash)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) MarkEmailVerified(ctx context.Context, userID string, at time.Time) error {
+	args := m.Called(ctx, userID, at)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) Update(ctx context.Context, user *models.User) (*models.User, error) {
+	args := m.Called(ctx, user)
+	result, _ := args.Get(0).(*models.User)
+	return result, args.Error(1)
+}
+
+func (m *MockUserRepository) Delete(ctx context.Context, userID string) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+// WithTransaction не открывает транзакцию, а сразу вызывает fn с самим моком
+func (m *MockUserRepository) WithTransaction(ctx context.Context, fn func(repo di.IUserRepository) error) error {
+	return fn(m)
+}
+
+// MockAuthService — мок для IAuthService; профилю нужны только LogoutAll и SendVerificationEmail
+type MockAuthService struct {
+	mock.Mock
+}
+
+func (m *MockAuthService) Register(ctx context.Context, email, password, name string) (string, error) {
+	args := m.Called(ctx, email, password, name)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockAuthService) Login(ctx context.Context, email, password string) (*models.User, error) {
+	args := m.Called(ctx, email, password)
+	result, _ := args.Get(0).(*models.User)
+	return result, args.Error(1)
+}
+
+func (m *MockAuthService) IssueTokens(ctx context.Context, user *models.User, now time.Time) (*models.TokenPair, error) {
+	args := m.Called(ctx, user, now)
+	result, _ := args.Get(0).(*models.TokenPair)
+	return result, args.Error(1)
+}
+
+func (m *MockAuthService) Refresh(ctx context.Context, refreshToken string, now time.Time) (*models.TokenPair, error) {
+	args := m.Called(ctx, refreshToken, now)
+	result, _ := args.Get(0).(*models.TokenPair)
+	return result, args.Error(1)
+}
+
+func (m *MockAuthService) Logout(ctx context.Context, userID, jti string, expiresAt time.Time, refreshToken string, now time.Time) error {
+	args := m.Called(ctx, userID, jti, expiresAt, refreshToken, now)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) LogoutAll(ctx context.Context, userID string, now time.Time) error {
+	args := m.Called(ctx, userID, now)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) RequestPasswordReset(ctx context.Context, email string, now time.Time) error {
+	args := m.Called(ctx, email, now)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) ResetPassword(ctx context.Context, resetToken, password string, now time.Time) error {
+	args := m.Called(ctx, resetToken, password, now)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) SendVerificationEmail(ctx context.Context, userID string, now time.Time) error {
+	args := m.Called(ctx, userID, now)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) VerifyEmail(ctx context.Context, verifyToken string, now time.Time) error {
+	args := m.Called(ctx, verifyToken, now)
+	return args.Error(0)
+}
+
+var errPasswordStep = errors.New("db is down")
+
+func strPtr(value string) *string {
+	return &value
+}
+
+// newStoredUser возвращает подтвержденного пользователя с паролем "old-password"
+func newStoredUser(t *testing.T, verifiedAt time.Time) *models.User {
+	hash, err := bcrypt.GenerateFromPassword([]byte("old-password"), bcrypt.MinCost)
+	assert.NoError(t, err, "failed to hash password")
+	return &models.User{ID: "user123", Name: "John", Email: "john@example.com", Password: string(hash), EmailVerifiedAt: &verifiedAt}
+}
+
+// TestUserService_UpdateProfile — смена email и пароля требует текущего пароля и завершает сессии,
+// новый email сбрасывает подтверждение
+func TestUserService_UpdateProfile(t *testing.T) {
+	now := time.Date(2025, time.March, 3, 9, 0, 0, 0, time.UTC)
+	verifiedAt := now.Add(-24 * time.Hour)
+
+	tests := []struct {
+		name        string
+		update      models.ProfileUpdate
+		setupMocks  func(userRepo *MockUserRepository, authSvc *MockAuthService, stored *models.User)
+		expectedErr error
+		check       func(t *testing.T, updated *models.User)
+	}{
+		{
+			name:   "Rename without password",
+			update: models.ProfileUpdate{Name: strPtr("Johnny")},
+			setupMocks: func(userRepo *MockUserRepository, authSvc *MockAuthService, stored *models.User) {
+				userRepo.On("Update", mock.Anything, mock.AnythingOfType("*models.User")).
+					Return(stored, nil)
+			},
+			check: func(t *testing.T, updated *models.User) {
+				assert.Equal(t, "Johnny", updated.Name, "name mismatch")
+				assert.NotNil(t, updated.EmailVerifiedAt, "rename should keep verification")
+			},
+		},
+		{
+			name:        "Email change without current password",
+			update:      models.ProfileUpdate{Email: strPtr("johnny@example.com")},
+			setupMocks:  func(userRepo *MockUserRepository, authSvc *MockAuthService, stored *models.User) {},
+			expectedErr: ErrWrongPassword,
+		},
+		{
+			name:        "Password change with wrong current password",
+			update:      models.ProfileUpdate{Password: strPtr("new-password"), CurrentPassword: "wrong-password"},
+			setupMocks:  func(userRepo *MockUserRepository, authSvc *MockAuthService, stored *models.User) {},
+			expectedErr: ErrWrongPassword,
+		},
+		{
+			name:   "Email already taken",
+			update: models.ProfileUpdate{Email: strPtr("jane@example.com"), CurrentPassword: "old-password"},
+			setupMocks: func(userRepo *MockUserRepository, authSvc *MockAuthService, stored *models.User) {
+				userRepo.On("Update", mock.Anything, mock.AnythingOfType("*models.User")).Return(nil, ErrUserAlreadyExists)
+			},
+			expectedErr: ErrUserAlreadyExists,
+		},
+		{
+			name:   "Email change requires re-verification and ends all sessions",
+			update: models.ProfileUpdate{Email: strPtr("johnny@example.com"), CurrentPassword: "old-password"},
+			setupMocks: func(userRepo *MockUserRepository, authSvc *MockAuthService, stored *models.User) {
+				userRepo.On("Update", mock.Anything, mock.MatchedBy(func(user *models.User) bool {
+					return user.Email == "johnny@example.com" && user.EmailVerifiedAt == nil
+				})).Return(stored, nil)
+				authSvc.On("LogoutAll", mock.Anything, "user123", now).Return(nil)
+				authSvc.On("SendVerificationEmail", mock.Anything, "user123", now).Return(nil)
+			},
+			check: func(t *testing.T, updated *models.User) {
+				assert.Equal(t, "johnny@example.com", updated.Email, "email mismatch")
+				assert.Nil(t, updated.EmailVerifiedAt, "new email should be unverified")
+			},
+		},
+		{
+			name:   "Password step failure is reported without ending sessions",
+			update: models.ProfileUpdate{Name: strPtr("Johnny"), Password: strPtr("new-password"), CurrentPassword: "old-password"},
+			setupMocks: func(userRepo *MockUserRepository, authSvc *MockAuthService, stored *models.User) {
+				userRepo.On("Update", mock.Anything, mock.AnythingOfType("*models.User")).Return(stored, nil)
+				userRepo.On("UpdatePassword", mock.Anything, "user123", mock.Anything).Return(errPasswordStep)
+			},
+			expectedErr: errPasswordStep,
+		},
+		{
+			name:   "Password change ends all sessions",
+			update: models.ProfileUpdate{Password: strPtr("new-password"), CurrentPassword: "old-password"},
+			setupMocks: func(userRepo *MockUserRepository, authSvc *MockAuthService, stored *models.User) {
+				userRepo.On("Update", mock.Anything, mock.AnythingOfType("*models.User")).
+					Return(stored, nil)
+				userRepo.On("UpdatePassword", mock.Anything, "user123", mock.MatchedBy(func(hash string) bool {
+					return bcrypt.CompareHashAndPassword([]byte(hash), []byte("new-password")) == nil
+				})).Return(nil)
+				authSvc.On("LogoutAll", mock.Anything, "user123", now).Return(nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stored := newStoredUser(t, verifiedAt)
+			userRepo := new(MockUserRepository)
+			userRepo.On("FindById", mock.Anything, "user123").Return(stored, nil)
+			authSvc := new(MockAuthService)
+			tt.setupMocks(userRepo, authSvc, stored)
+			service := NewUserService(userRepo, authSvc)
+
+			updated, err := service.UpdateProfile(context.Background(), "user123", tt.update, now)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr, "unexpected error")
+			} else if assert.NoError(t, err, "unexpected error") && tt.check != nil {
+				tt.check(t, updated)
+			}
+			userRepo.AssertExpectations(t)
+			authSvc.AssertExpectations(t)
+		})
+	}
+}
+
+// TestUserService_DeleteAccount — удаление требует пароль
+func TestUserService_DeleteAccount(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	userRepo.On("FindById", mock.Anything, "user123").Return(newStoredUser(t, time.Now()), nil)
+	userRepo.On("Delete", mock.Anything, "user123").Return(nil).Once()
+	service := NewUserService(userRepo, nil)
+
+	assert.ErrorIs(t, service.DeleteAccount(context.Background(), "user123", "wrong-password"), ErrWrongPassword, "wrong password should be rejected")
+	assert.NoError(t, service.DeleteAccount(context.Background(), "user123", "old-password"), "unexpected error")
+	userRepo.AssertExpectations(t)
+}
+
+// stubUserService отдает заранее заданный профиль
+type stubUserService struct {
+	user *models.User
+}
+
+func (s *stubUserService) GetProfile(ctx context.Context, userID string) (*models.User, error) {
+	return s.user, nil
+}
+
+func (s *stubUserService) UpdateProfile(ctx context.Context, userID string, update models.ProfileUpdate, now time.Time) (*models.User, error) {
+	return s.user, nil
+}
+
+func (s *stubUserService) DeleteAccount(ctx context.Context, userID, password string) error {
+	return nil
+}
+
+// TestUserHandler_GetMe — ответ не содержит хеш пароля
+func TestUserHandler_GetMe(t *testing.T) {
+	stored := newStoredUser(t, time.Date(2025, time.March, 2, 9, 0, 0, 0, time.UTC))
+	handler := &UserHandler{Config: &configs.Config{}, UserService: &stubUserService{user: stored}}
+
+	req := httptest.NewRequest("GET", "/users/me", nil)
+	req = req.WithContext(context.WithValue(req.Context(), middleware.ContextUserIDKey, "user123"))
+	rr := httptest.NewRecorder()
+	handler.GetMe()(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "unexpected status code")
+	assert.NotContains(t, rr.Body.String(), stored.Password, "password hash must not be exposed")
+	assert.False(t, strings.Contains(rr.Body.String(), `"password"`), "password field must not be exposed")
+	var resp UserResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp), "failed to unmarshal response")
+	assert.Equal(t, "john@example.com", resp.Email, "email mismatch")
+	assert.True(t, resp.EmailVerified, "user should be verified")
+}
@@ -0,0 +1,10 @@
+package workflows
+
+import "errors"
+
+var (
+	ErrWorkflowNotFound      = errors.New("workflow not found")
+	ErrCreateWorkflow        = errors.New("failed to create workflow")
+	ErrInvalidWorkflow       = errors.New("invalid workflow")
+	ErrMissingTerminalStatus = errors.New("workflow must contain a terminal status")
+)
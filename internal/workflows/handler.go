@@ -0,0 +1,42 @@
+package workflows
+
+import (
+	"ToDo/configs"
+	"ToDo/pkg/di"
+	"ToDo/pkg/middleware"
+	"net/http"
+)
+
+type WorkflowHandlerDeps struct {
+	Config          *configs.Config
+	TokenDenylist   di.ITokenDenylist
+	WorkflowService di.IWorkflowService
+	ProjectService  di.IProjectService
+}
+
+type WorkflowHandler struct {
+	Config          *configs.Config
+	WorkflowService di.IWorkflowService
+	ProjectService  di.IProjectService
+}
+
+func NewWorkflowHandler(router *http.ServeMux, deps *WorkflowHandlerDeps) {
+	handler := &WorkflowHandler{
+		Config:          deps.Config,
+		WorkflowService: deps.WorkflowService,
+		ProjectService:  deps.ProjectService,
+	}
+	middlewares := middleware.Chain(
+		middleware.CORS,
+		middleware.Logging,
+		middleware.RateLimiter(deps.Config.RateLimit.MaxRequests, deps.Config.RateLimit.Burst, deps.Config.RateLimit.TTL),
+		middleware.IsAuthenticated(deps.Config, deps.TokenDenylist),
+	)
+
+	router.Handle("GET /workflow", middlewares(handler.GetWorkflow()))
+	router.Handle("PUT /workflow", middlewares(handler.SaveWorkflow()))
+	router.Handle("DELETE /workflow", middlewares(handler.DeleteWorkflow()))
+	router.Handle("GET /projects/{id}/workflow", middlewares(handler.GetWorkflow()))
+	router.Handle("PUT /projects/{id}/workflow", middlewares(handler.SaveWorkflow()))
+	router.Handle("DELETE /projects/{id}/workflow", middlewares(handler.DeleteWorkflow()))
+}
@@ -0,0 +1,22 @@
+package workflows
+
+import "ToDo/internal/models"
+
+type WorkflowStatusRequest struct {
+	Name       string   `json:"name" validate:"required,max=20"`
+	Next       []string `json:"next"`        // Статусы, в которые можно перейти из этого
+	Terminal   bool     `json:"terminal"`    // Заметка в этом статусе считается выполненной
+	InProgress bool     `json:"in_progress"` // В этот статус переводит первый отмеченный пункт чек-листа
+}
+
+// SaveWorkflowRequest — новый набор статусов; первый статус присваивается новым заметкам
+type SaveWorkflowRequest struct {
+	Statuses []WorkflowStatusRequest `json:"statuses" validate:"required,min=1,max=20,dive"`
+}
+
+type WorkflowResponse struct {
+	Source    string                  `json:"source"` // project, user или default — откуда взят workflow
+	ProjectID *string                 `json:"project_id"`
+	Initial   string                  `json:"initial"`
+	Statuses  []models.WorkflowStatus `json:"statuses"`
+}
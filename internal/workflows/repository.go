@@ -0,0 +1,82 @@
+package workflows
+
+import (
+	"ToDo/internal/models"
+	"ToDo/pkg/idgen"
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+)
+
+type WorkflowRepository struct {
+	db *gorm.DB
+}
+
+func NewWorkflowRepository(dataBase *gorm.DB) *WorkflowRepository {
+	return &WorkflowRepository{
+		db: dataBase,
+	}
+}
+
+// scoped ограничивает запрос workflow пользователя (projectId == nil) или проекта
+func scoped(query *gorm.DB, userId string, projectId *string) *gorm.DB {
+	query = query.Where("user_id = ?", userId)
+	if projectId == nil {
+		return query.Where("project_id IS NULL")
+	}
+	return query.Where("project_id = ?", *projectId)
+}
+
+func (r *WorkflowRepository) Get(ctx context.Context, userId string, projectId *string) (*models.Workflow, error) {
+	var workflow models.Workflow
+	result := scoped(r.db.WithContext(ctx), userId, projectId).
+		Preload("Statuses", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position asc")
+		}).
+		First(&workflow)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("get workflow for user %s: %w", userId, ErrWorkflowNotFound)
+		}
+		return nil, fmt.Errorf("get workflow for user %s: %w", userId, result.Error)
+	}
+	return &workflow, nil
+}
+
+// Save заменяет workflow пользователя или проекта целиком
+func (r *WorkflowRepository) Save(ctx context.Context, workflow *models.Workflow) (*models.Workflow, error) {
+	workflow.ID = idgen.GenerateNanoID()
+	if workflow.ID == "" {
+		return nil, fmt.Errorf("generate id: %w", ErrCreateWorkflow)
+	}
+	for i := range workflow.Statuses {
+		workflow.Statuses[i].ID = idgen.GenerateNanoID()
+		if workflow.Statuses[i].ID == "" {
+			return nil, fmt.Errorf("generate id: %w", ErrCreateWorkflow)
+		}
+		workflow.Statuses[i].Position = i
+	}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := scoped(tx, workflow.UserID, workflow.ProjectID).Delete(&models.Workflow{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(workflow).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("save workflow for user %s: %w", workflow.UserID, err)
+	}
+	return workflow, nil
+}
+
+func (r *WorkflowRepository) Delete(ctx context.Context, userId string, projectId *string) error {
+	result := scoped(r.db.WithContext(ctx), userId, projectId).Delete(&models.Workflow{})
+	if result.Error != nil {
+		return fmt.Errorf("delete workflow for user %s: %w", userId, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("delete workflow for user %s: %w", userId, ErrWorkflowNotFound)
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package workflows
+
+import (
+	"ToDo/internal/models"
+	"ToDo/internal/projects"
+	"ToDo/pkg/middleware"
+	"ToDo/pkg/req"
+	"ToDo/pkg/res"
+	"errors"
+	"net/http"
+)
+
+func getUserId(r *http.Request) string {
+	userId, _ := r.Context().Value(middleware.ContextUserIDKey).(string)
+	return userId
+}
+
+// resolveScope определяет, чей workflow запрошен: пользователя (/workflow) или проекта (/projects/{id}/workflow).
+// Проект должен принадлежать пользователю. При ошибке ответ уже записан и ok == false
+func (h *WorkflowHandler) resolveScope(w http.ResponseWriter, r *http.Request) (userId string, projectId *string, ok bool) {
+	userId = getUserId(r)
+	if userId == "" {
+		res.JsonResponse(w, res.ErrorResponse{Error: "unauthorized"}, http.StatusUnauthorized)
+		return "", nil, false
+	}
+	id := r.PathValue("id")
+	if id == "" {
+		return userId, nil, true
+	}
+
+	project, err := h.ProjectService.GetProject(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, projects.ErrProjectNotFound) {
+			res.JsonResponse(w, res.ErrorResponse{Error: "project not found"}, http.StatusNotFound)
+		} else {
+			res.JsonResponse(w, res.ErrorResponse{Error: "failed to get project by id"}, http.StatusInternalServerError)
+		}
+		return "", nil, false
+	}
+	if project.UserID != userId {
+		res.JsonResponse(w, res.ErrorResponse{Error: "unauthorized"}, http.StatusUnauthorized)
+		return "", nil, false
+	}
+	return userId, &project.ID, true
+}
+
+func newWorkflowResponse(workflow *models.Workflow) WorkflowResponse {
+	source := "default"
+	switch {
+	case workflow.ProjectID != nil:
+		source = "project"
+	case workflow.ID != "":
+		source = "user"
+	}
+	return WorkflowResponse{
+		Source:    source,
+		ProjectID: workflow.ProjectID,
+		Initial:   workflow.Initial(),
+		Statuses:  workflow.Statuses,
+	}
+}
+
+func (h *WorkflowHandler) GetWorkflow() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userId, projectId, ok := h.resolveScope(w, r)
+		if !ok {
+			return
+		}
+
+		workflow, err := h.WorkflowService.GetWorkflow(r.Context(), userId, projectId)
+		if err != nil {
+			res.JsonResponse(w, res.ErrorResponse{Error: "failed to get workflow"}, http.StatusInternalServerError)
+			return
+		}
+		res.JsonResponse(w, newWorkflowResponse(workflow), http.StatusOK)
+	}
+}
+
+func (h *WorkflowHandler) SaveWorkflow() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := req.HandleBody[SaveWorkflowRequest](&w, r)
+		if err != nil {
+			return
+		}
+		userId, projectId, ok := h.resolveScope(w, r)
+		if !ok {
+			return
+		}
+
+		workflow := &models.Workflow{UserID: userId, ProjectID: projectId}
+		for _, status := range body.Statuses {
+			workflow.Statuses = append(workflow.Statuses, models.WorkflowStatus{Name: status.Name, Next: status.Next, Terminal: status.Terminal, InProgress: status.InProgress})
+		}
+
+		savedWorkflow, err := h.WorkflowService.SaveWorkflow(r.Context(), workflow)
+		if err != nil {
+			if errors.Is(err, ErrInvalidWorkflow) || errors.Is(err, ErrMissingTerminalStatus) {
+				res.JsonResponse(w, res.ErrorResponse{Error: err.Error()}, http.StatusBadRequest)
+			} else {
+				res.JsonResponse(w, res.ErrorResponse{Error: "failed to save workflow"}, http.StatusInternalServerError)
+			}
+			return
+		}
+		res.JsonResponse(w, newWorkflowResponse(savedWorkflow), http.StatusOK)
+	}
+}
+
+// DeleteWorkflow сбрасывает workflow: проект переходит на workflow пользователя, пользователь — на стандартный
+func (h *WorkflowHandler) DeleteWorkflow() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userId, projectId, ok := h.resolveScope(w, r)
+		if !ok {
+			return
+		}
+
+		if err := h.WorkflowService.DeleteWorkflow(r.Context(), userId, projectId); err != nil {
+			if errors.Is(err, ErrWorkflowNotFound) {
+				res.JsonResponse(w, res.ErrorResponse{Error: "workflow not found"}, http.StatusNotFound)
+			} else {
+				res.JsonResponse(w, res.ErrorResponse{Error: "failed to delete workflow"}, http.StatusInternalServerError)
+			}
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
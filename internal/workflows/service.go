@@ -0,0 +1,139 @@
+package workflows
+
+import (
+	"ToDo/internal/models"
+	"ToDo/pkg/di"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+const (
+	StatusDone       = "done"        // Завершающий статус стандартного workflow
+	StatusInProgress = "in_progress" // Статус начатой заметки в стандартном workflow
+	maxStatuses      = 20
+)
+
+var statusNamePattern = regexp.MustCompile(`^[a-z0-9_]{1,20}$`)
+
+type WorkflowService struct {
+	workflowRepository di.IWorkflowRepository
+}
+
+func NewWorkflowService(workflowRepo di.IWorkflowRepository) *WorkflowService {
+	return &WorkflowService{workflowRepository: workflowRepo}
+}
+
+// Default — стандартный workflow: created, in_progress и done с переходами между любыми статусами
+func Default(userID string) *models.Workflow {
+	return &models.Workflow{
+		UserID: userID,
+		Statuses: []models.WorkflowStatus{
+			{Name: "created", Position: 0, Next: []string{"in_progress", "done"}},
+			{Name: StatusInProgress, Position: 1, Next: []string{"created", "done"}, InProgress: true},
+			{Name: StatusDone, Position: 2, Next: []string{"created", "in_progress"}, Terminal: true},
+		},
+	}
+}
+
+// GetWorkflow возвращает действующий workflow: проекта, если он задан, иначе пользователя, иначе стандартный
+func (s *WorkflowService) GetWorkflow(ctx context.Context, userID string, projectID *string) (*models.Workflow, error) {
+	if projectID != nil {
+		workflow, err := s.workflowRepository.Get(ctx, userID, projectID)
+		if err == nil {
+			return workflow, nil
+		}
+		if !errors.Is(err, ErrWorkflowNotFound) {
+			return nil, err
+		}
+	}
+	workflow, err := s.workflowRepository.Get(ctx, userID, nil)
+	if errors.Is(err, ErrWorkflowNotFound) {
+		return Default(userID), nil
+	}
+	return workflow, err
+}
+
+func (s *WorkflowService) SaveWorkflow(ctx context.Context, workflow *models.Workflow) (*models.Workflow, error) {
+	if err := Validate(workflow); err != nil {
+		return nil, err
+	}
+	slog.Info("Saving workflow", "user_id", workflow.UserID, "project_id", workflow.ProjectID, "statuses", len(workflow.Statuses))
+	return s.workflowRepository.Save(ctx, workflow)
+}
+
+func (s *WorkflowService) DeleteWorkflow(ctx context.Context, userID string, projectID *string) error {
+	slog.Info("Resetting workflow", "user_id", userID, "project_id", projectID)
+	return s.workflowRepository.Delete(ctx, userID, projectID)
+}
+
+// Validate нормализует имена статусов и проверяет workflow: уникальные статусы, хотя бы один завершающий статус,
+// не больше одного статуса начатой заметки, переходы только в существующие статусы.
+// Workflow без отметок получает их по именам done и in_progress, как в стандартном
+func Validate(workflow *models.Workflow) error {
+	if len(workflow.Statuses) == 0 || len(workflow.Statuses) > maxStatuses {
+		return fmt.Errorf("%w: from 1 to %d statuses are allowed", ErrInvalidWorkflow, maxStatuses)
+	}
+	known := make(map[string]bool, len(workflow.Statuses))
+	for i := range workflow.Statuses {
+		status := &workflow.Statuses[i]
+		status.Name = strings.ToLower(strings.TrimSpace(status.Name))
+		if !statusNamePattern.MatchString(status.Name) {
+			return fmt.Errorf("%w: invalid status name %q", ErrInvalidWorkflow, status.Name)
+		}
+		if known[status.Name] {
+			return fmt.Errorf("%w: duplicate status %q", ErrInvalidWorkflow, status.Name)
+		}
+		known[status.Name] = true
+	}
+	markDefaultStatuses(workflow)
+	inProgress := 0
+	for _, status := range workflow.Statuses {
+		if status.Terminal && status.InProgress {
+			return fmt.Errorf("%w: status %q cannot be both terminal and in progress", ErrInvalidWorkflow, status.Name)
+		}
+		if status.InProgress {
+			inProgress++
+		}
+	}
+	if workflow.Terminal() == "" {
+		return ErrMissingTerminalStatus
+	}
+	if inProgress > 1 {
+		return fmt.Errorf("%w: only one in progress status is allowed", ErrInvalidWorkflow)
+	}
+	for i := range workflow.Statuses {
+		status := &workflow.Statuses[i]
+		seen := make(map[string]bool, len(status.Next))
+		next := make([]string, 0, len(status.Next))
+		for _, name := range status.Next {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if !known[name] || name == status.Name {
+				return fmt.Errorf("%w: invalid transition %q -> %q", ErrInvalidWorkflow, status.Name, name)
+			}
+			if !seen[name] {
+				seen[name] = true
+				next = append(next, name)
+			}
+		}
+		status.Next = next
+	}
+	return nil
+}
+
+// markDefaultStatuses отмечает done завершающим, а in_progress — начатым, если отметок такого рода нет вовсе
+func markDefaultStatuses(workflow *models.Workflow) {
+	if workflow.Terminal() == "" {
+		if status := workflow.Status(StatusDone); status != nil {
+			status.Terminal = true
+		}
+	}
+	if workflow.InProgress() == "" {
+		if status := workflow.Status(StatusInProgress); status != nil && !status.Terminal {
+			status.InProgress = true
+		}
+	}
+}
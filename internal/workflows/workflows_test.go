@@ -0,0 +1,112 @@
+package workflows
+
+import (
+	"context"
+	"testing"
+
+	"ToDo/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockWorkflowRepository — мок для IWorkflowRepository
+type MockWorkflowRepository struct {
+	mock.Mock
+}
+
+func (m *MockWorkflowRepository) Get(ctx context.Context, userID string, projectID *string) (*models.Workflow, error) {
+	args := m.Called(ctx, userID, projectID)
+	result, _ := args.Get(0).(*models.Workflow)
+	return result, args.Error(1)
+}
+
+func (m *MockWorkflowRepository) Save(ctx context.Context, workflow *models.Workflow) (*models.Workflow, error) {
+	args := m.Called(ctx, workflow)
+	result, _ := args.Get(0).(*models.Workflow)
+	return result, args.Error(1)
+}
+
+func (m *MockWorkflowRepository) Delete(ctx context.Context, userID string, projectID *string) error {
+	args := m.Called(ctx, userID, projectID)
+	return args.Error(0)
+}
+
+// TestWorkflowService_GetWorkflow — workflow проекта, иначе пользователя, иначе стандартный
+func TestWorkflowService_GetWorkflow(t *testing.T) {
+	projectID := "project1"
+	userWorkflow := &models.Workflow{ID: "wf1", UserID: "user123", Statuses: []models.WorkflowStatus{{Name: "done"}}}
+
+	mockRepo := new(MockWorkflowRepository)
+	mockRepo.On("Get", mock.Anything, "user123", &projectID).Return(nil, ErrWorkflowNotFound)
+	mockRepo.On("Get", mock.Anything, "user123", (*string)(nil)).Return(userWorkflow, nil)
+	mockRepo.On("Get", mock.Anything, "user456", (*string)(nil)).Return(nil, ErrWorkflowNotFound)
+	service := NewWorkflowService(mockRepo)
+
+	workflow, err := service.GetWorkflow(context.Background(), "user123", &projectID)
+	assert.NoError(t, err, "unexpected error")
+	assert.Equal(t, "wf1", workflow.ID, "project should fall back to the user workflow")
+
+	workflow, err = service.GetWorkflow(context.Background(), "user456", nil)
+	assert.NoError(t, err, "unexpected error")
+	assert.Equal(t, "created", workflow.Initial(), "user should fall back to the default workflow")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name       string
+		statuses   []models.WorkflowStatus
+		terminal   string // Ожидаемый завершающий статус, по умолчанию done
+		inProgress string // Ожидаемый статус начатой заметки
+		err        error
+	}{
+		{
+			name: "Valid workflow is normalized",
+			statuses: []models.WorkflowStatus{
+				{Name: " Todo ", Next: []string{"REVIEW", "review"}},
+				{Name: "review", Next: []string{"done"}},
+				{Name: "done"},
+			},
+		},
+		{
+			name: "Custom terminal status",
+			statuses: []models.WorkflowStatus{
+				{Name: "todo", Next: []string{"review"}},
+				{Name: "review", Next: []string{"shipped"}, InProgress: true},
+				{Name: "shipped", Terminal: true},
+			},
+			terminal:   "shipped",
+			inProgress: "review",
+		},
+		{name: "Missing terminal status", statuses: []models.WorkflowStatus{{Name: "todo"}}, err: ErrMissingTerminalStatus},
+		{name: "Terminal and in progress", statuses: []models.WorkflowStatus{{Name: "todo"}, {Name: "done", Terminal: true, InProgress: true}}, err: ErrInvalidWorkflow},
+		{name: "Two in progress statuses", statuses: []models.WorkflowStatus{{Name: "a", InProgress: true}, {Name: "b", InProgress: true}, {Name: "done"}}, err: ErrInvalidWorkflow},
+		{name: "Duplicate status", statuses: []models.WorkflowStatus{{Name: "done"}, {Name: "DONE"}}, err: ErrInvalidWorkflow},
+		{name: "Unknown transition", statuses: []models.WorkflowStatus{{Name: "done", Next: []string{"blocked"}}}, err: ErrInvalidWorkflow},
+		{name: "Invalid name", statuses: []models.WorkflowStatus{{Name: "in progress"}, {Name: "done"}}, err: ErrInvalidWorkflow},
+		{name: "Empty workflow", err: ErrInvalidWorkflow},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workflow := &models.Workflow{Statuses: tt.statuses}
+			err := Validate(workflow)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err, "expected error")
+				return
+			}
+			assert.NoError(t, err, "unexpected error")
+			terminal := tt.terminal
+			if terminal == "" {
+				terminal = StatusDone
+			}
+			assert.Equal(t, "todo", workflow.Initial(), "initial status mismatch")
+			assert.Equal(t, terminal, workflow.Terminal(), "terminal status mismatch")
+			assert.Equal(t, tt.inProgress, workflow.InProgress(), "in progress status mismatch")
+			assert.Equal(t, []string{"review"}, workflow.Statuses[0].Next, "transitions should be normalized")
+			assert.True(t, workflow.CanTransition("review", terminal), "review -> terminal should be allowed")
+			assert.False(t, workflow.CanTransition("todo", terminal), "todo -> terminal should be forbidden")
+		})
+	}
+}
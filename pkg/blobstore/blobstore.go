@@ -0,0 +1,51 @@
+package blobstore
+
+import (
+	"ToDo/configs"
+	"ToDo/pkg/di"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrBlobNotFound = errors.New("blob not found")
+	ErrInvalidKey   = errors.New("invalid blob key")
+)
+
+const (
+	StorageLocal = "local"
+	StorageS3    = "s3"
+)
+
+// New создает хранилище, выбранное в конфигурации (ATTACHMENTS.STORAGE)
+func New(conf *configs.Config) (di.IBlobStore, error) {
+	switch conf.Attachments.Storage {
+	case StorageLocal, "":
+		return NewLocalStore(conf.Attachments.Dir)
+	case StorageS3:
+		s3 := conf.Attachments.S3
+		return NewS3Store(S3Options{
+			Endpoint:  s3.Endpoint,
+			Region:    s3.Region,
+			Bucket:    s3.Bucket,
+			AccessKey: s3.AccessKey,
+			SecretKey: s3.SecretKey,
+		})
+	default:
+		return nil, fmt.Errorf("unknown blob storage %q", conf.Attachments.Storage)
+	}
+}
+
+// validKey отсекает пустые ключи и ключи с переходами по каталогам
+func validKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return false
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return false
+		}
+	}
+	return true
+}
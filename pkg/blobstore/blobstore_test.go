@@ -0,0 +1,153 @@
+package blobstore
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"ToDo/pkg/di"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testStore проверяет контракт хранилища: запись, чтение с произвольной позиции, удаление
+func testStore(t *testing.T, store di.IBlobStore) {
+	ctx := context.Background()
+	content := []byte("0123456789abcdefghij")
+	key := "notes/note1/blob1"
+
+	require.NoError(t, store.Put(ctx, key, bytes.NewReader(content), int64(len(content)), "text/plain"), "put failed")
+
+	object, err := store.Open(ctx, key)
+	require.NoError(t, err, "open failed")
+	data, err := io.ReadAll(object)
+	assert.NoError(t, err, "read failed")
+	assert.Equal(t, content, data, "content mismatch")
+
+	position, err := object.Seek(-5, io.SeekEnd)
+	assert.NoError(t, err, "seek failed")
+	assert.Equal(t, int64(15), position, "position mismatch")
+	data, err = io.ReadAll(object)
+	assert.NoError(t, err, "read after seek failed")
+	assert.Equal(t, []byte("fghij"), data, "tail mismatch")
+
+	_, err = object.Seek(10, io.SeekStart)
+	assert.NoError(t, err, "seek failed")
+	head := make([]byte, 3)
+	_, err = io.ReadFull(object, head)
+	assert.NoError(t, err, "read range failed")
+	assert.Equal(t, []byte("abc"), head, "range mismatch")
+	assert.NoError(t, object.Close(), "close failed")
+
+	require.NoError(t, store.Delete(ctx, key), "delete failed")
+	_, err = store.Open(ctx, key)
+	assert.ErrorIs(t, err, ErrBlobNotFound, "deleted blob should be gone")
+	assert.NoError(t, store.Delete(ctx, key), "deleting a missing blob is not an error")
+
+	assert.ErrorIs(t, store.Put(ctx, "../escape", bytes.NewReader(content), int64(len(content)), ""), ErrInvalidKey, "key must stay inside the store")
+}
+
+func TestLocalStore(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	require.NoError(t, err, "create store")
+	testStore(t, store)
+}
+
+// fakeS3 — минимальный S3 в памяти: PUT, HEAD, GET с Range и DELETE объектов
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=access/") ||
+		r.Header.Get("X-Amz-Date") == "" || r.Header.Get("X-Amz-Content-Sha256") == "" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	object, ok := f.objects[r.URL.Path]
+	switch r.Method {
+	case http.MethodPut:
+		data, _ := io.ReadAll(r.Body)
+		if int64(len(data)) != r.ContentLength {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.objects[r.URL.Path] = data
+	case http.MethodDelete:
+		delete(f.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodHead, http.MethodGet:
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		start := 0
+		if byteRange := r.Header.Get("Range"); byteRange != "" {
+			start, _ = strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(byteRange, "bytes="), "-"))
+			object = object[start:]
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(object)))
+		if start > 0 {
+			w.WriteHeader(http.StatusPartialContent)
+		}
+		if r.Method == http.MethodGet {
+			w.Write(object)
+		}
+	}
+}
+
+func TestS3Store(t *testing.T) {
+	fake := &fakeS3{objects: make(map[string][]byte)}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	store, err := NewS3Store(S3Options{Endpoint: server.URL, Bucket: "bucket", AccessKey: "access", SecretKey: "secret"})
+	require.NoError(t, err, "create store")
+	testStore(t, store)
+
+	_, err = NewS3Store(S3Options{Endpoint: "localhost:9000", Bucket: "bucket"})
+	assert.Error(t, err, "endpoint without scheme should be rejected")
+}
+
+// TestS3Store_MinIO запускается против настоящего S3-совместимого сервиса, например локального MinIO:
+// BLOBSTORE_S3_ENDPOINT=http://localhost:9000 BLOBSTORE_S3_BUCKET=test BLOBSTORE_S3_ACCESS_KEY=... BLOBSTORE_S3_SECRET_KEY=...
+func TestS3Store_MinIO(t *testing.T) {
+	endpoint := os.Getenv("BLOBSTORE_S3_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("BLOBSTORE_S3_ENDPOINT is not set")
+	}
+	store, err := NewS3Store(S3Options{
+		Endpoint:  endpoint,
+		Region:    os.Getenv("BLOBSTORE_S3_REGION"),
+		Bucket:    os.Getenv("BLOBSTORE_S3_BUCKET"),
+		AccessKey: os.Getenv("BLOBSTORE_S3_ACCESS_KEY"),
+		SecretKey: os.Getenv("BLOBSTORE_S3_SECRET_KEY"),
+	})
+	require.NoError(t, err, "create store")
+	testStore(t, store)
+}
+
+func TestValidKey(t *testing.T) {
+	for key, want := range map[string]bool{
+		"notes/n1/b1": true,
+		"":            false,
+		"/abs":        false,
+		"a/../b":      false,
+		"a//b":        false,
+		`a\b`:         false,
+	} {
+		assert.Equal(t, want, validKey(key), "key %q", key)
+	}
+}
@@ -0,0 +1,298 @@
+package blobstore
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+	emptyPayload    = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855" // sha256("")
+)
+
+type S3Options struct {
+	Endpoint  string // Адрес сервиса, например http://localhost:9000 для MinIO
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+}
+
+// S3Store хранит объекты в S3-совместимом хранилище (AWS S3, MinIO).
+// Запросы подписываются AWS Signature V4, бакет адресуется в пути (path-style)
+type S3Store struct {
+	endpoint  *url.URL
+	region    string
+	bucket    string
+	accessKey string
+	secretKey string
+	client    *http.Client
+	now       func() time.Time
+}
+
+func NewS3Store(options S3Options) (*S3Store, error) {
+	endpoint, err := url.Parse(options.Endpoint)
+	if err != nil || endpoint.Scheme == "" || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid s3 endpoint %q", options.Endpoint)
+	}
+	if options.Bucket == "" {
+		return nil, fmt.Errorf("s3 bucket is required")
+	}
+	region := options.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+	return &S3Store{
+		endpoint:  endpoint,
+		region:    region,
+		bucket:    options.Bucket,
+		accessKey: options.AccessKey,
+		secretKey: options.SecretKey,
+		client:    &http.Client{},
+		now:       time.Now,
+	}, nil
+}
+
+func (s *S3Store) Put(ctx context.Context, key string, content io.Reader, size int64, contentType string) error {
+	req, err := s.newRequest(ctx, http.MethodPut, key, content)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	if size == 0 {
+		req.Body = http.NoBody
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := s.do(req, unsignedPayload)
+	if err != nil {
+		return fmt.Errorf("put blob %s: %w", key, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("put blob %s: %w", key, statusError(resp))
+	}
+	return nil
+}
+
+// Open узнает размер объекта запросом HEAD; данные читаются лениво запросами GET с заголовком Range
+func (s *S3Store) Open(ctx context.Context, key string) (io.ReadSeekCloser, error) {
+	req, err := s.newRequest(ctx, http.MethodHead, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.do(req, emptyPayload)
+	if err != nil {
+		return nil, fmt.Errorf("open blob %s: %w", key, err)
+	}
+	resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("open blob %s: %w", key, ErrBlobNotFound)
+	default:
+		return nil, fmt.Errorf("open blob %s: %w", key, statusError(resp))
+	}
+	return &s3Object{ctx: ctx, store: s, key: key, size: resp.ContentLength}, nil
+}
+
+// Delete удаляет объект; отсутствие объекта ошибкой не считается
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	req, err := s.newRequest(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.do(req, emptyPayload)
+	if err != nil {
+		return fmt.Errorf("delete blob %s: %w", key, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("delete blob %s: %w", key, statusError(resp))
+	}
+	return nil
+}
+
+func (s *S3Store) newRequest(ctx context.Context, method, key string, body io.Reader) (*http.Request, error) {
+	if !validKey(key) {
+		return nil, ErrInvalidKey
+	}
+	target := *s.endpoint
+	target.Path = strings.TrimSuffix(s.endpoint.Path, "/") + "/" + s.bucket + "/" + key
+	target.RawPath = uriEncode(target.Path)
+	return http.NewRequestWithContext(ctx, method, target.String(), body)
+}
+
+// do подписывает запрос (AWS Signature V4) и отправляет его
+func (s *S3Store) do(req *http.Request, payloadHash string) (*http.Response, error) {
+	now := s.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	scope := now.Format("20060102") + "/" + s.region + "/s3/aws4_request"
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	headers := map[string]string{
+		"host":                 req.URL.Host,
+		"x-amz-content-sha256": payloadHash,
+		"x-amz-date":           amzDate,
+	}
+	if contentType := req.Header.Get("Content-Type"); contentType != "" {
+		headers["content-type"] = contentType
+	}
+	if byteRange := req.Header.Get("Range"); byteRange != "" {
+		headers["range"] = byteRange
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hexSHA256(canonicalRequest)
+
+	signingKey := hmacSHA256([]byte("AWS4"+s.secretKey), now.Format("20060102"))
+	for _, part := range []string{s.region, "s3", "aws4_request"} {
+		signingKey = hmacSHA256(signingKey, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, scope, signedHeaders, signature))
+
+	return s.client.Do(req)
+}
+
+// s3Object — объект S3, читаемый с произвольной позиции
+type s3Object struct {
+	ctx    context.Context
+	store  *S3Store
+	key    string
+	size   int64
+	offset int64
+	body   io.ReadCloser // Ответ на GET с текущей позиции; nil — запрос еще не выполнен
+}
+
+func (o *s3Object) Read(p []byte) (int, error) {
+	if o.offset >= o.size {
+		return 0, io.EOF
+	}
+	if o.body == nil {
+		if err := o.open(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := o.body.Read(p)
+	o.offset += int64(n)
+	return n, err
+}
+
+func (o *s3Object) open() error {
+	req, err := o.store.newRequest(o.ctx, http.MethodGet, o.key, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Range", "bytes="+strconv.FormatInt(o.offset, 10)+"-")
+	resp, err := o.store.do(req, emptyPayload)
+	if err != nil {
+		return fmt.Errorf("read blob %s: %w", o.key, err)
+	}
+	switch {
+	case resp.StatusCode == http.StatusPartialContent:
+	case resp.StatusCode == http.StatusOK && o.offset == 0:
+	case resp.StatusCode == http.StatusNotFound:
+		resp.Body.Close()
+		return fmt.Errorf("read blob %s: %w", o.key, ErrBlobNotFound)
+	default:
+		resp.Body.Close()
+		return fmt.Errorf("read blob %s: %w", o.key, statusError(resp))
+	}
+	o.body = resp.Body
+	return nil
+}
+
+func (o *s3Object) Seek(offset int64, whence int) (int64, error) {
+	var position int64
+	switch whence {
+	case io.SeekStart:
+		position = offset
+	case io.SeekCurrent:
+		position = o.offset + offset
+	case io.SeekEnd:
+		position = o.size + offset
+	default:
+		return 0, errors.New("seek: invalid whence")
+	}
+	if position < 0 {
+		return 0, errors.New("seek: negative position")
+	}
+	if position != o.offset && o.body != nil {
+		o.body.Close()
+		o.body = nil
+	}
+	o.offset = position
+	return position, nil
+}
+
+func (o *s3Object) Close() error {
+	if o.body == nil {
+		return nil
+	}
+	err := o.body.Close()
+	o.body = nil
+	return err
+}
+
+func statusError(resp *http.Response) error {
+	message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+}
+
+// uriEncode кодирует путь по правилам Signature V4: все, кроме A-Z a-z 0-9 - _ . ~ и /
+func uriEncode(path string) string {
+	var encoded strings.Builder
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || strings.IndexByte("-_.~/", c) >= 0 {
+			encoded.WriteByte(c)
+		} else {
+			fmt.Fprintf(&encoded, "%%%02X", c)
+		}
+	}
+	return encoded.String()
+}
+
+func hexSHA256(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
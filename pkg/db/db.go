@@ -14,6 +14,7 @@ import (
 
 func NewDb(conf *configs.Config) (*gorm.DB, *sql.DB, error) {
 	db, err := gorm.Open(postgres.Open(conf.Db.Dsn), &gorm.Config{
+		TranslateError: true, // Нарушения уникальности приходят как gorm.ErrDuplicatedKey
 		Logger: logger.New(
 			log.New(os.Stdout, "\r\n", log.LstdFlags),
 			logger.Config{
@@ -3,31 +3,272 @@ package di
 import (
 	"ToDo/internal/models"
 	"context"
+	"io"
+	"time"
 )
 
 type INoteRepository interface {
 	Create(ctx context.Context, note *models.Note) (*models.Note, error)
-	GetAll(ctx context.Context, userID string, limit, offset int) ([]models.Note, int64, error)
+	GetAll(ctx context.Context, userID string, filter models.NoteFilter) ([]models.Note, int64, error)
+	Search(ctx context.Context, userID string, tsQuery string, filter models.NoteFilter) ([]models.NoteSearchResult, int64, error)
+	GetDue(ctx context.Context, userID string, before time.Time) ([]models.Note, error)
 	Get(ctx context.Context, noteID string) (*models.Note, error)
 	Update(ctx context.Context, note *models.Note) (*models.Note, error)
+	UpdateStatus(ctx context.Context, noteID string, status string) error
+	UpdateProject(ctx context.Context, noteID string, projectID *string) error
 	Delete(ctx context.Context, noteID string) error
+	DeletePermanently(ctx context.Context, noteID string) error
+	GetTrash(ctx context.Context, userID string, limit, offset int) ([]models.Note, int64, error)
+	GetTrashed(ctx context.Context, noteID string) (*models.Note, error)
+	Restore(ctx context.Context, noteID string) error
+	PurgeDeleted(ctx context.Context, before time.Time) (int64, error)
+
+	GetItems(ctx context.Context, noteID string) ([]models.ChecklistItem, error)
+	GetItem(ctx context.Context, itemID string) (*models.ChecklistItem, error)
+	CreateItem(ctx context.Context, item *models.ChecklistItem) (*models.ChecklistItem, error)
+	UpdateItem(ctx context.Context, item *models.ChecklistItem) (*models.ChecklistItem, error)
+	DeleteItem(ctx context.Context, itemID string) error
+
+	CreateRevision(ctx context.Context, revision *models.NoteRevision, keep int) error
+	GetRevisions(ctx context.Context, noteID string) ([]models.NoteRevision, error)
+	GetRevision(ctx context.Context, noteID string, revision int) (*models.NoteRevision, error)
+
+	GetBoard(ctx context.Context, userID string, filter models.NoteFilter) ([]models.Note, error)
+	NeighbourPosition(ctx context.Context, userID, status, position string, next bool, excludeID string) (string, bool, error)
+
+	SaveShare(ctx context.Context, share *models.NoteShare) (*models.NoteShare, error)
+	GetShares(ctx context.Context, noteID string) ([]models.NoteShare, error)
+	GetShare(ctx context.Context, noteID, userID string) (*models.NoteShare, error)
+	DeleteShare(ctx context.Context, noteID, userID string) error
+	GetSharedWith(ctx context.Context, userID string, limit, offset int) ([]models.SharedNote, int64, error)
+
+	CreateLink(ctx context.Context, link *models.NoteLink) (*models.NoteLink, error)
+	GetLinks(ctx context.Context, noteID string) ([]models.NoteLink, error)
+	GetLinkByToken(ctx context.Context, token string) (*models.NoteLink, error)
+	DeleteLink(ctx context.Context, noteID, linkID string) error
+	RecordLinkAccess(ctx context.Context, linkID string, at time.Time) error
+
+	AddDependency(ctx context.Context, userID, noteID, blockerID string) error
+	DeleteDependency(ctx context.Context, noteID, blockerID string) error
+	GetOpenBlockers(ctx context.Context, noteID string) ([]models.Note, error)
+	GetDependencyGraph(ctx context.Context, noteID, userID string) (*models.DependencyGraph, error)
+
+	WithTransaction(ctx context.Context, fn func(repo INoteRepository) error) error
 }
 
 type INoteService interface {
 	CreateNote(ctx context.Context, note *models.Note) (*models.Note, error)
-	GetAllNotes(ctx context.Context, userID string, limit, offset int) ([]models.Note, int64, error)
+	GetAllNotes(ctx context.Context, userID string, filter models.NoteFilter) ([]models.Note, int64, error)
+	SearchNotes(ctx context.Context, userID string, query string, filter models.NoteFilter) ([]models.NoteSearchResult, int64, error)
+	GetUpcomingNotes(ctx context.Context, userID string, now time.Time) (*models.UpcomingNotes, error)
 	GetNote(ctx context.Context, noteID string) (*models.Note, error)
-	UpdateNote(ctx context.Context, note *models.Note) (*models.Note, error)
+	UpdateNote(ctx context.Context, note *models.Note, force bool) (*models.Note, error)
 	DeleteNote(ctx context.Context, noteID string) error
+	DeleteNotePermanently(ctx context.Context, noteID string) error
+	GetTrash(ctx context.Context, userID string, limit, offset int) ([]models.Note, int64, error)
+	GetTrashedNote(ctx context.Context, noteID string) (*models.Note, error)
+	RestoreNote(ctx context.Context, noteID string) (*models.Note, error)
+	MoveNoteToProject(ctx context.Context, note *models.Note, projectID *string) (*models.Note, error)
+
+	GetChecklistItems(ctx context.Context, noteID string) ([]models.ChecklistItem, error)
+	GetChecklistItem(ctx context.Context, itemID string) (*models.ChecklistItem, error)
+	AddChecklistItem(ctx context.Context, note *models.Note, item *models.ChecklistItem) (*models.ChecklistItem, string, error)
+	UpdateChecklistItem(ctx context.Context, note *models.Note, item *models.ChecklistItem) (*models.ChecklistItem, string, error)
+	DeleteChecklistItem(ctx context.Context, note *models.Note, itemID string) (string, error)
+
+	GetRevisions(ctx context.Context, noteID string) ([]models.NoteRevision, error)
+	GetRevision(ctx context.Context, noteID string, revision int) (*models.NoteRevision, error)
+	RestoreRevision(ctx context.Context, note *models.Note, revision int) (*models.Note, error)
+
+	GetOccurrences(ctx context.Context, note *models.Note, from, to time.Time) ([]time.Time, error)
+
+	GetBoard(ctx context.Context, userID string, projectID string) ([]models.BoardColumn, error)
+	MoveNote(ctx context.Context, note *models.Note, status, afterID, beforeID string) (*models.Note, error)
+
+	GetPermission(ctx context.Context, note *models.Note, userID string) (string, error)
+	ShareNote(ctx context.Context, note *models.Note, email, permission string) (*models.NoteShare, error)
+	GetShares(ctx context.Context, noteID string) ([]models.NoteShare, error)
+	RevokeShare(ctx context.Context, noteID, userID string) error
+	GetSharedNotes(ctx context.Context, userID string, limit, offset int) ([]models.SharedNote, int64, error)
+
+	CreateLink(ctx context.Context, note *models.Note, password string, expiresAt *time.Time) (*models.NoteLink, error)
+	GetLinks(ctx context.Context, noteID string) ([]models.NoteLink, error)
+	RevokeLink(ctx context.Context, noteID, linkID string) error
+	OpenLink(ctx context.Context, token, password string, now time.Time) (*models.Note, error)
+
+	AddDependency(ctx context.Context, userID string, note *models.Note, blockerID string) error
+	RemoveDependency(ctx context.Context, noteID, blockerID string) error
+	GetDependencyGraph(ctx context.Context, noteID, userID string) (*models.DependencyGraph, error)
+
+	BatchNotes(ctx context.Context, userID string, ops []models.NoteBatchOperation, atomic bool) ([]models.NoteBatchResult, error)
+}
+
+type ITagRepository interface {
+	GetAll(ctx context.Context, userID string) ([]models.Tag, error)
+	Get(ctx context.Context, tagID string) (*models.Tag, error)
+	Update(ctx context.Context, tag *models.Tag) (*models.Tag, error)
+	Delete(ctx context.Context, tagID string) error
+}
+
+type ITagService interface {
+	GetAllTags(ctx context.Context, userID string) ([]models.Tag, error)
+	GetTag(ctx context.Context, tagID string) (*models.Tag, error)
+	RenameTag(ctx context.Context, tag *models.Tag, name string) (*models.Tag, error)
+	DeleteTag(ctx context.Context, tagID string) error
+}
+
+type IProjectRepository interface {
+	Create(ctx context.Context, project *models.Project) (*models.Project, error)
+	GetAll(ctx context.Context, userID string, includeArchived bool) ([]models.Project, error)
+	Get(ctx context.Context, projectID string) (*models.Project, error)
+	Update(ctx context.Context, project *models.Project) (*models.Project, error)
+	Delete(ctx context.Context, projectID string, cascade bool) error
+}
+
+type IProjectService interface {
+	CreateProject(ctx context.Context, project *models.Project) (*models.Project, error)
+	GetAllProjects(ctx context.Context, userID string, includeArchived bool) ([]models.Project, error)
+	GetProject(ctx context.Context, projectID string) (*models.Project, error)
+	UpdateProject(ctx context.Context, project *models.Project) (*models.Project, error)
+	DeleteProject(ctx context.Context, projectID string, mode string) error
+}
+
+type IWorkflowRepository interface {
+	Get(ctx context.Context, userID string, projectID *string) (*models.Workflow, error)
+	Save(ctx context.Context, workflow *models.Workflow) (*models.Workflow, error)
+	Delete(ctx context.Context, userID string, projectID *string) error
+}
+
+type IWorkflowService interface {
+	GetWorkflow(ctx context.Context, userID string, projectID *string) (*models.Workflow, error)
+	SaveWorkflow(ctx context.Context, workflow *models.Workflow) (*models.Workflow, error)
+	DeleteWorkflow(ctx context.Context, userID string, projectID *string) error
+}
+
+type ITemplateRepository interface {
+	Create(ctx context.Context, template *models.Template) (*models.Template, error)
+	GetAll(ctx context.Context, userID string) ([]models.Template, error)
+	Get(ctx context.Context, templateID string) (*models.Template, error)
+	Update(ctx context.Context, template *models.Template) (*models.Template, error)
+	Delete(ctx context.Context, templateID string) error
+}
+
+type ITemplateService interface {
+	CreateTemplate(ctx context.Context, template *models.Template) (*models.Template, error)
+	GetAllTemplates(ctx context.Context, userID string) ([]models.Template, error)
+	GetTemplate(ctx context.Context, templateID string) (*models.Template, error)
+	UpdateTemplate(ctx context.Context, template *models.Template) (*models.Template, error)
+	DeleteTemplate(ctx context.Context, templateID string) error
+	InstantiateTemplate(ctx context.Context, template *models.Template, note *models.Note, values map[string]string, now time.Time) (*models.Note, error)
+}
+
+type ICommentRepository interface {
+	Create(ctx context.Context, comment *models.Comment) (*models.Comment, error)
+	Get(ctx context.Context, commentID string) (*models.Comment, error)
+	GetByNote(ctx context.Context, noteID string, limit int, after *models.CommentCursor) ([]models.Comment, error)
+	Update(ctx context.Context, comment *models.Comment) (*models.Comment, error)
+	Delete(ctx context.Context, commentID string) error
+	GetMentions(ctx context.Context, userID string, limit int, before *models.CommentCursor) ([]models.Comment, error)
+}
+
+type ICommentService interface {
+	CreateComment(ctx context.Context, note *models.Note, comment *models.Comment) (*models.Comment, error)
+	GetComment(ctx context.Context, commentID string) (*models.Comment, error)
+	GetComments(ctx context.Context, noteID string, limit int, after *models.CommentCursor) ([]models.Comment, error)
+	UpdateComment(ctx context.Context, note *models.Note, comment *models.Comment) (*models.Comment, error)
+	DeleteComment(ctx context.Context, commentID string) error
+	GetMentions(ctx context.Context, userID string, limit int, before *models.CommentCursor) ([]models.Comment, error)
+}
+
+type IAttachmentRepository interface {
+	Create(ctx context.Context, attachment *models.Attachment, quota int64) (*models.Attachment, error)
+	Get(ctx context.Context, attachmentID string) (*models.Attachment, error)
+	GetByNote(ctx context.Context, noteID string) ([]models.Attachment, error)
+	Delete(ctx context.Context, attachmentID string) error
+	GetUsage(ctx context.Context, userID string) (int64, error)
+	GetOrphans(ctx context.Context, limit int) ([]models.Attachment, error)
+}
+
+type IAttachmentService interface {
+	UploadAttachment(ctx context.Context, note *models.Note, attachment *models.Attachment, content io.Reader) (*models.Attachment, error)
+	GetAttachments(ctx context.Context, noteID string) ([]models.Attachment, error)
+	GetAttachment(ctx context.Context, attachmentID string) (*models.Attachment, error)
+	OpenAttachment(ctx context.Context, attachment *models.Attachment) (io.ReadSeekCloser, error)
+	DeleteAttachment(ctx context.Context, attachment *models.Attachment) error
+	GetUsage(ctx context.Context, userID string) (int64, error)
 }
 
 type IAuthService interface {
 	Register(ctx context.Context, email, password, name string) (string, error)
 	Login(ctx context.Context, email, password string) (*models.User, error)
+	IssueTokens(ctx context.Context, user *models.User, now time.Time) (*models.TokenPair, error)
+	Refresh(ctx context.Context, refreshToken string, now time.Time) (*models.TokenPair, error)
+	Logout(ctx context.Context, userID, jti string, expiresAt time.Time, refreshToken string, now time.Time) error
+	LogoutAll(ctx context.Context, userID string, now time.Time) error
+	RequestPasswordReset(ctx context.Context, email string, now time.Time) error
+	ResetPassword(ctx context.Context, resetToken, password string, now time.Time) error
+	SendVerificationEmail(ctx context.Context, userID string, now time.Time) error
+	VerifyEmail(ctx context.Context, verifyToken string, now time.Time) error
+}
+
+type IVerifyTokenRepository interface {
+	Create(ctx context.Context, token *models.EmailVerificationToken) (*models.EmailVerificationToken, error)
+	GetByHash(ctx context.Context, tokenHash string) (*models.EmailVerificationToken, error)
+	MarkUsed(ctx context.Context, tokenID string, at time.Time) error
+	InvalidateUser(ctx context.Context, userID string, at time.Time) error
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+type IResetTokenRepository interface {
+	Create(ctx context.Context, token *models.PasswordResetToken) (*models.PasswordResetToken, error)
+	GetByHash(ctx context.Context, tokenHash string) (*models.PasswordResetToken, error)
+	MarkUsed(ctx context.Context, tokenID string, at time.Time) error
+	InvalidateUser(ctx context.Context, userID string, at time.Time) error
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+type IRefreshTokenRepository interface {
+	Create(ctx context.Context, token *models.RefreshToken) (*models.RefreshToken, error)
+	GetByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error)
+	MarkRotated(ctx context.Context, tokenID string, at time.Time) error
+	RevokeFamily(ctx context.Context, familyID string, at time.Time) error
+	RevokeUser(ctx context.Context, userID string, at time.Time) error
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+// ITokenDenylist — отозванные до истечения токены доступа
+type ITokenDenylist interface {
+	Revoke(ctx context.Context, jti, userID string, expiresAt time.Time) error
+	RevokeAll(ctx context.Context, userID string, issuedBefore, expiresAt time.Time) error
+	IsRevoked(ctx context.Context, jti, userID string, issuedAt time.Time) (bool, error)
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
 }
 
 type IUserRepository interface {
 	Create(ctx context.Context, user *models.User) (*models.User, error)
 	FindById(ctx context.Context, userId string) (*models.User, error)
 	FindByEmail(ctx context.Context, email string) (*models.User, error)
+	UpdatePassword(ctx context.Context, userID, passwordHash string) error
+	MarkEmailVerified(ctx context.Context, userID string, at time.Time) error
+	Update(ctx context.Context, user *models.User) (*models.User, error)
+	Delete(ctx context.Context, userID string) error
+	WithTransaction(ctx context.Context, fn func(repo IUserRepository) error) error
+}
+
+type IUserService interface {
+	GetProfile(ctx context.Context, userID string) (*models.User, error)
+	UpdateProfile(ctx context.Context, userID string, update models.ProfileUpdate, now time.Time) (*models.User, error)
+	DeleteAccount(ctx context.Context, userID, password string) error
+}
+
+// IBlobStore — хранилище содержимого файлов (локальный диск, S3-совместимое хранилище)
+type IBlobStore interface {
+	Put(ctx context.Context, key string, content io.Reader, size int64, contentType string) error
+	Open(ctx context.Context, key string) (io.ReadSeekCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// IMailer — отправка писем пользователям (SMTP, файлы, лог)
+type IMailer interface {
+	Send(ctx context.Context, to, subject, body string) error
 }
@@ -0,0 +1,56 @@
+package mailer
+
+import (
+	"ToDo/pkg/idgen"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileMailer складывает письма в каталог файлами .eml — для разработки и тестов
+type FileMailer struct {
+	dir  string
+	from string
+}
+
+func NewFileMailer(dir, from string) (*FileMailer, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("mail directory is required")
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("create mail directory: %w", err)
+	}
+	if from == "" {
+		from = "todo@localhost"
+	}
+	return &FileMailer{dir: dir, from: from}, nil
+}
+
+func (m *FileMailer) Send(ctx context.Context, to, subject, body string) error {
+	if !validHeader(to) || !validHeader(subject) {
+		return fmt.Errorf("send mail: invalid header value")
+	}
+	now := time.Now()
+	name := fmt.Sprintf("%s-%s.eml", now.UTC().Format("20060102T150405"), idgen.GenerateNanoID())
+	path := filepath.Join(m.dir, name)
+	if err := os.WriteFile(path, buildMessage(m.from, to, subject, body, now), 0o640); err != nil {
+		return fmt.Errorf("write mail to %s: %w", to, err)
+	}
+	slog.Info("Mail saved to file", "to", to, "subject", subject, "path", path)
+	return nil
+}
+
+// LogMailer только пишет письма в лог. Транспорт по умолчанию: ссылки из писем видны в выводе сервера
+type LogMailer struct{}
+
+func NewLogMailer() *LogMailer {
+	return &LogMailer{}
+}
+
+func (m *LogMailer) Send(ctx context.Context, to, subject, body string) error {
+	slog.Info("Mail", "to", to, "subject", subject, "body", body)
+	return nil
+}
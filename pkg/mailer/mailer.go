@@ -0,0 +1,57 @@
+package mailer
+
+import (
+	"ToDo/configs"
+	"ToDo/pkg/di"
+	"bytes"
+	"fmt"
+	"mime"
+	"strings"
+	"time"
+)
+
+const (
+	TransportSMTP = "smtp"
+	TransportFile = "file"
+	TransportLog  = "log"
+)
+
+// New создает отправителя писем, выбранного в конфигурации (MAIL.TRANSPORT)
+func New(conf *configs.Config) (di.IMailer, error) {
+	switch conf.Mail.Transport {
+	case TransportLog, "":
+		return NewLogMailer(), nil
+	case TransportFile:
+		return NewFileMailer(conf.Mail.Dir, conf.Mail.From)
+	case TransportSMTP:
+		smtp := conf.Mail.SMTP
+		return NewSMTPMailer(SMTPOptions{
+			Host:     smtp.Host,
+			Port:     smtp.Port,
+			Username: smtp.Username,
+			Password: smtp.Password,
+			From:     conf.Mail.From,
+		})
+	default:
+		return nil, fmt.Errorf("unknown mail transport %q", conf.Mail.Transport)
+	}
+}
+
+// buildMessage собирает письмо в формате RFC 5322 с текстовым телом в UTF-8
+func buildMessage(from, to, subject, body string, now time.Time) []byte {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", from)
+	fmt.Fprintf(&msg, "To: %s\r\n", to)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\n", "\r\n"))
+	return msg.Bytes()
+}
+
+// validHeader отсекает переводы строк, через которые можно внедрить заголовки
+func validHeader(value string) bool {
+	return !strings.ContainsAny(value, "\r\n")
+}
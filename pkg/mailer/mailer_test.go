@@ -0,0 +1,117 @@
+package mailer
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileMailer(t *testing.T) {
+	dir := t.TempDir()
+	mailer, err := NewFileMailer(dir, "todo@example.com")
+	require.NoError(t, err, "create mailer")
+
+	require.NoError(t, mailer.Send(context.Background(), "john@example.com", "Сброс пароля", "line one\nline two"), "send failed")
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	require.NoError(t, err, "glob failed")
+	require.Len(t, files, 1, "one message expected")
+
+	data, err := os.ReadFile(files[0])
+	require.NoError(t, err, "read message")
+	message := string(data)
+	assert.Contains(t, message, "To: john@example.com\r\n", "recipient header")
+	assert.Contains(t, message, "Subject: =?utf-8?q?", "subject should be encoded")
+	assert.True(t, strings.HasSuffix(message, "\r\n\r\nline one\r\nline two"), "body should use CRLF line endings")
+
+	assert.Error(t, mailer.Send(context.Background(), "john@example.com\r\nBcc: x@example.com", "Hi", ""), "header injection should be rejected")
+}
+
+// fakeSMTP — минимальный SMTP-сервер на одно соединение; возвращает канал с полученным письмом
+func fakeSMTP(t *testing.T) (string, int, <-chan string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err, "listen")
+	t.Cleanup(func() { listener.Close() })
+	received := make(chan string, 1)
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+		reply("220 localhost ESMTP")
+		var data strings.Builder
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			command := strings.ToUpper(strings.TrimSpace(line))
+			switch {
+			case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+				reply("250-localhost")
+				reply("250 8BITMIME")
+			case strings.HasPrefix(command, "MAIL"), strings.HasPrefix(command, "RCPT"), strings.HasPrefix(command, "RSET"):
+				reply("250 OK")
+			case command == "DATA":
+				reply("354 End data with <CR><LF>.<CR><LF>")
+				for {
+					line, err := reader.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if line == ".\r\n" {
+						break
+					}
+					data.WriteString(line)
+				}
+				received <- data.String()
+				reply("250 OK")
+			case command == "QUIT":
+				reply("221 Bye")
+				return
+			default:
+				reply("502 Not implemented")
+			}
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	portNumber, _ := strconv.Atoi(port)
+	return host, portNumber, received
+}
+
+func TestSMTPMailer(t *testing.T) {
+	host, port, received := fakeSMTP(t)
+	mailer, err := NewSMTPMailer(SMTPOptions{Host: host, Port: port, From: "todo@example.com"})
+	require.NoError(t, err, "create mailer")
+
+	require.NoError(t, mailer.Send(context.Background(), "john@example.com", "Reset", "token: abc"), "send failed")
+	message := <-received
+	assert.Contains(t, message, "From: todo@example.com\r\n", "sender header")
+	assert.Contains(t, message, "token: abc", "body mismatch")
+}
+
+// TestSMTPMailer_StandIn отправляет письмо на локальную заглушку SMTP (например, MailHog из docker-compose)
+func TestSMTPMailer_StandIn(t *testing.T) {
+	host := os.Getenv("MAILER_SMTP_HOST")
+	if host == "" {
+		t.Skip("MAILER_SMTP_HOST is not set")
+	}
+	port, err := strconv.Atoi(os.Getenv("MAILER_SMTP_PORT"))
+	require.NoError(t, err, "MAILER_SMTP_PORT must be a number")
+	mailer, err := NewSMTPMailer(SMTPOptions{Host: host, Port: port, From: "todo@example.com"})
+	require.NoError(t, err, "create mailer")
+
+	assert.NoError(t, mailer.Send(context.Background(), "john@example.com", "Test", "Hello from ToDo"), "send failed")
+}
@@ -0,0 +1,63 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+type SMTPOptions struct {
+	Host     string
+	Port     int
+	Username string // Пусто — без аутентификации (локальные заглушки вроде MailHog)
+	Password string
+	From     string
+}
+
+// SMTPMailer отправляет письма через SMTP-сервер. STARTTLS включается, если сервер его поддерживает
+type SMTPMailer struct {
+	options SMTPOptions
+	addr    string
+}
+
+func NewSMTPMailer(options SMTPOptions) (*SMTPMailer, error) {
+	if options.Host == "" || options.Port == 0 {
+		return nil, errors.New("smtp host and port are required")
+	}
+	if options.From == "" {
+		return nil, errors.New("mail sender address is required")
+	}
+	return &SMTPMailer{
+		options: options,
+		addr:    net.JoinHostPort(options.Host, strconv.Itoa(options.Port)),
+	}, nil
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, to, subject, body string) error {
+	if !validHeader(to) || !validHeader(subject) {
+		return fmt.Errorf("send mail: invalid header value")
+	}
+	var auth smtp.Auth
+	if m.options.Username != "" {
+		auth = smtp.PlainAuth("", m.options.Username, m.options.Password, m.options.Host)
+	}
+
+	// smtp.SendMail не принимает контекст, поэтому отправка прерывается по отмене только ожиданием
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(m.addr, auth, m.options.From, []string{to}, buildMessage(m.options.From, to, subject, body, time.Now()))
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("send mail to %s: %w", to, err)
+		}
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("send mail to %s: %w", to, ctx.Err())
+	}
+}
@@ -2,15 +2,20 @@ package middleware
 
 import (
 	"ToDo/configs"
+	"ToDo/pkg/di"
 	token2 "ToDo/pkg/token"
 	"context"
+	"log/slog"
 	"net/http"
 	"strings"
 )
 
 type key string
 
-const ContextUserIDKey key = "userID"
+const (
+	ContextUserIDKey key = "userID"
+	ContextTokenKey  key = "token" // Разобранный токен доступа (token.JwtDate)
+)
 
 func writeUnauthorized(w http.ResponseWriter) {
 	w.WriteHeader(http.StatusUnauthorized)
@@ -20,7 +25,8 @@ func writeUnauthorized(w http.ResponseWriter) {
 	}
 }
 
-func IsAuthenticated(config *configs.Config) func(http.Handler) http.Handler {
+// IsAuthenticated пропускает запросы с действующим токеном доступа, который не отозван в denylist
+func IsAuthenticated(config *configs.Config, denylist di.ITokenDenylist) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			authHeader := r.Header.Get("Authorization")
@@ -38,7 +44,16 @@ func IsAuthenticated(config *configs.Config) func(http.Handler) http.Handler {
 				writeUnauthorized(w)
 				return
 			}
+			revoked, err := denylist.IsRevoked(r.Context(), data.ID, data.UserId, data.IssuedAt)
+			if err != nil {
+				slog.Error("Failed to check token revocation", "error", err)
+			}
+			if revoked || err != nil { // При недоступном denylist токен не принимается
+				writeUnauthorized(w)
+				return
+			}
 			ctx := context.WithValue(r.Context(), ContextUserIDKey, data.UserId)
+			ctx = context.WithValue(ctx, ContextTokenKey, *data)
 			req := r.WithContext(ctx)
 			next.ServeHTTP(w, req)
 		})
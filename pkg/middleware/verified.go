@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"ToDo/configs"
+	"ToDo/pkg/res"
+	token2 "ToDo/pkg/token"
+	"errors"
+	"net/http"
+)
+
+var ErrEmailNotVerified = errors.New("email not verified")
+
+// RequireVerifiedEmail ставится после IsAuthenticated. В режиме block запросы с неподтвержденным email
+// отклоняются с 403, в режиме flag пропускаются с заголовком X-Email-Verified: false.
+// Статус берется из токена доступа, поэтому после подтверждения клиент должен обновить токен
+func RequireVerifiedEmail(config *configs.Config) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			data, _ := r.Context().Value(ContextTokenKey).(token2.JwtDate)
+			if !data.EmailVerified {
+				if config.Auth.UnverifiedEmail == "block" {
+					res.JsonResponse(w, res.ErrorResponse{Error: ErrEmailNotVerified.Error()}, http.StatusForbidden)
+					return
+				}
+				w.Header().Set("X-Email-Verified", "false")
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
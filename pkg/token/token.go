@@ -1,13 +1,20 @@
 package token
 
 import (
+	"ToDo/pkg/idgen"
+	"errors"
 	"github.com/golang-jwt/jwt/v5"
 	"log/slog"
+	"time"
 )
 
 type JwtDate struct {
-	UserId string
-	Email  string
+	UserId        string
+	Email         string
+	EmailVerified bool      // Адрес подтвержден на момент выпуска токена
+	ID            string    // jti — по нему токен можно отозвать до истечения
+	IssuedAt      time.Time // Заполняется при разборе токена
+	ExpiresAt     time.Time // Заполняется при разборе токена
 }
 
 type JWTSecret struct {
@@ -20,10 +27,20 @@ func NewJWT(secret string) *JWTSecret {
 	}
 }
 
-func (j *JWTSecret) GenerateToken(date JwtDate) (string, error) {
+// GenerateToken выпускает токен доступа, действующий lifetime с момента now
+func (j *JWTSecret) GenerateToken(date JwtDate, now time.Time, lifetime time.Duration) (string, error) {
+	jti := idgen.GenerateNanoID()
+	if jti == "" {
+		return "", errors.New("failed to generate token id")
+	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"userId": date.UserId,
-		"email":  date.Email,
+		"jti":            jti,
+		"userId":         date.UserId,
+		"email":          date.Email,
+		"email_verified": date.EmailVerified,
+		"iat":            now.Unix(),
+		"nbf":            now.Unix(),
+		"exp":            now.Add(lifetime).Unix(),
 	})
 
 	secret, err := token.SignedString([]byte(j.Secret))
@@ -36,9 +53,10 @@ func (j *JWTSecret) GenerateToken(date JwtDate) (string, error) {
 
 func (j *JWTSecret) ParseToken(token string) (bool, *JwtDate) {
 	slog.Info("Parsing Token", "token", token)
+	// Токены без exp не принимаются: раньше они выпускались бессрочными
 	t, err := jwt.Parse(token, func(token *jwt.Token) (any, error) {
 		return []byte(j.Secret), nil
-	})
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired(), jwt.WithIssuedAt())
 	if err != nil {
 		slog.Error(err.Error(), "can not parse token", err)
 		return false, nil
@@ -59,8 +77,29 @@ func (j *JWTSecret) ParseToken(token string) (bool, *JwtDate) {
 		slog.Error("invalid email in token claims", "actual_value", claims["email"])
 		return false, nil
 	}
+	emailVerified, _ := claims["email_verified"].(bool) // В старых токенах поля нет: адрес не подтвержден
+	// Без jti токен нельзя отозвать, такие токены не принимаются
+	jti, ok := claims["jti"].(string)
+	if !ok || jti == "" {
+		slog.Error("missing jti in token claims")
+		return false, nil
+	}
+	issuedAt, err := claims.GetIssuedAt()
+	if err != nil || issuedAt == nil {
+		slog.Error("invalid iat in token claims", "actual_value", claims["iat"])
+		return false, nil
+	}
+	expiresAt, err := claims.GetExpirationTime()
+	if err != nil || expiresAt == nil {
+		slog.Error("invalid exp in token claims", "actual_value", claims["exp"])
+		return false, nil
+	}
 	return t.Valid, &JwtDate{
-		UserId: userID,
-		Email:  email,
+		UserId:        userID,
+		Email:         email,
+		EmailVerified: emailVerified,
+		ID:            jti,
+		IssuedAt:      issuedAt.Time,
+		ExpiresAt:     expiresAt.Time,
 	}
 }
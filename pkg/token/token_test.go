@@ -1,6 +1,9 @@
 package token
 
-import "testing"
+import (
+	"testing"
+	"time"
+)
 
 const email = "test@gmail.com"
 
@@ -9,7 +12,7 @@ func TestJWTSecret_GenerateToken(t *testing.T) {
 
 	token, err := jwtService.GenerateToken(JwtDate{
 		Email: email,
-	})
+	}, time.Now(), time.Minute)
 	if err != nil {
 		t.Fatalf("Error generating token: %v", err)
 		return
@@ -26,4 +29,25 @@ func TestJWTSecret_GenerateToken(t *testing.T) {
 		return
 	}
 
+	if data.ID == "" || data.ExpiresAt.IsZero() {
+		t.Fatalf("Token should carry jti and exp: %+v", data)
+		return
+	}
+
+}
+
+func TestJWTSecret_ParseToken_Expired(t *testing.T) {
+	jwtService := NewJWT("RxbxgRcFCFes0enila83XSdWzejBmKuw4cHiPuMgiU8")
+
+	token, err := jwtService.GenerateToken(JwtDate{
+		Email: email,
+	}, time.Now().Add(-time.Hour), time.Minute)
+	if err != nil {
+		t.Fatalf("Error generating token: %v", err)
+		return
+	}
+
+	if isValid, _ := jwtService.ParseToken(token); isValid {
+		t.Fatalf("Expired token should be rejected")
+	}
 }